@@ -7,38 +7,84 @@ type Api interface {
 	GetSubDirs(dirPath string) ([]string, error)
 
 	OpenRepo(path string) async.Task[string]
-	CloneRepo(uri, path string) async.Task[any]
+	CloneRepo(repoID, uri, path string) async.Task[any]
 	CloseRepo(repoID string) error
+	CancelRemoteCommands(repoID string) error
+	GetCredentialPrompts() ([]CredentialPrompt, error)
+	AnswerCredentialPrompt(answer CredentialAnswer) error
 
 	GetRepoChanges(repoID string) ([]RepoChange, error)
 	TriggerRefreshRepo(repoID string) error
+	LoadMoreHistory(repoID string) error
 	TriggerSearch(search Search) error
 
 	GetBranches(args GetBranchesReq) ([]Branch, error)
 	GetFiles(args FilesReq) ([]string, error)
+	GetFileBlame(repoID, path, ref string) ([]BlameLine, error)
+	GetReflog(repoID, ref string) ([]ReflogEntry, error)
+	GetWorktrees(repoID string) ([]Worktree, error)
+	GetSubmodules(repoID string) ([]Submodule, error)
+	UpdateSubmodules(repoID string) error
+	AddWorktree(req AddWorktreeReq) (string, error)
+	RemoveWorktree(repoID, path string, isForced bool) error
 	GetCommitDiff(info CommitDiffInfoReq) (CommitDiff, error)
 	GetFileDiff(info FileDiffInfoReq) ([]CommitDiff, error)
 	GetCommitDetails(req CommitDetailsReq) (CommitDetailsRsp, error)
 	GetAmbiguousBranchBranches(args AmbiguousBranchBranchesReq) ([]Branch, error)
 
 	Commit(info CommitInfoReq) error
+	GetUncommittedFiles(repoID string) ([]UncommittedFile, error)
+	StageFile(repoID, path string) error
+	GetConflictFiles(repoID string) ([]string, error)
+	GetConflictFile(repoID, path string) (ConflictFile, error)
+	ResolveConflict(req ResolveConflictReq) error
+	UnstageFile(repoID, path string) error
+	StageSection(req StageSectionReq) error
+	UnstageSection(req StageSectionReq) error
 	UndoCommit(repoID, id string) error
 	UndoUncommittedFileChanges(repoID, path string) error
 	UncommitLastCommit(repoID string) error
+	AmendCommit(repoID, message string) error
+	RewordCommit(req RewordCommitReq) error
+	DropCommit(repoID, id string) error
+	SquashCommits(req SquashCommitsReq) error
 	UndoAllUncommittedChanges(repoID string) error
 	CleanWorkingFolder(repoID string) error
 
+	CreateTag(req CreateTagReq) error
+	DeleteTag(repoID, name string) error
+	PushTag(repoID, name string) error
+
+	GetStashes(repoID string) ([]Stash, error)
+	StashPush(req StashPushReq) error
+	StashApply(repoID, name string) error
+	StashPop(repoID, name string) error
+	StashDrop(repoID, name string) error
+	RemoveLockFile(repoID, path string) error
+
 	ShowBranch(name BranchName) error
 	HideBranch(name BranchName) error
 
 	Checkout(repoId, name, displayName string) error
+	CheckoutCommit(repoID, commitID string) error
 	PushBranch(repoID, branchName string) error
 	PullCurrentBranch(repoID string) error
 	PullBranch(name BranchName) error
+	PushBranchTo(req RemoteBranchReq) error
+	PullBranchFrom(req RemoteBranchReq) error
 	MergeBranch(name BranchName) error
 	MergeSquashBranch(repoID, branchName string) error
+	CherryPick(req CherryPickReq) error
+	CherryPickContinue(repoID string) error
+	CherryPickAbort(repoID string) error
+	RebaseBranch(req RebaseBranchReq) error
+	RebaseContinue(repoID string) error
+	RebaseSkip(repoID string) error
+	RebaseAbort(repoID string) error
 	CreateBranch(name BranchName) error
+	CreateBranchAt(repoID, branchName, commitID string) error
 	DeleteBranch(repoID, branchName string, isForced bool) error
 	SetAsParentBranch(req SetParentReq) error
 	UnsetAsParentBranch(name BranchName) error
+	SetBranchName(req SetBranchNameReq) error
 }
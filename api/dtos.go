@@ -19,6 +19,15 @@ const (
 	DiffConflictEnd
 )
 
+type ConflictChoice int
+
+const (
+	ChooseNone ConflictChoice = iota
+	ChooseOurs
+	ChooseTheirs
+	ChooseBoth // Ours followed by theirs
+)
+
 type GetBranchesReq struct {
 	RepoID                    string
 	IncludeOnlyCurrent        bool
@@ -30,6 +39,12 @@ type GetBranchesReq struct {
 	SortOnLatest              bool
 }
 
+type RemoteBranchReq struct {
+	RepoID     string
+	Remote     string
+	BranchName string
+}
+
 type BranchName struct {
 	RepoID     string
 	BranchName string
@@ -42,6 +57,12 @@ type SetParentReq struct {
 	ParentName string
 }
 
+type SetBranchNameReq struct {
+	RepoID   string
+	CommitID string // A commit on the unnamed or ambiguous branch
+	Name     string // Empty to remove the name
+}
+
 type Search struct {
 	RepoID string
 	Text   string
@@ -58,11 +79,13 @@ type CommitDetailsReq struct {
 }
 
 type CommitDetailsRsp struct {
-	Id          string
-	BranchName  string
-	BranchColor Color
-	Message     string
-	Files       []string
+	Id               string
+	BranchName       string
+	BranchColor      Color
+	Message          string
+	Files            []string
+	BranchRule       string   // The rule, which determined the commit branch
+	BranchCandidates []string // The possible branches, if more than one, when the branch was determined
 }
 
 type CommitDiffInfoReq struct {
@@ -81,8 +104,136 @@ type AmbiguousBranchBranchesReq struct {
 }
 
 type CommitInfoReq struct {
+	RepoID       string
+	Message      string
+	IsOnlyStaged bool // Commit only staged changes, otherwise all changes are committed
+}
+
+type UncommittedFile struct {
+	Path       string
+	IsStaged   bool
+	IsUnstaged bool // A partially staged file is both staged and unstaged
+}
+
+// ConflictFile is a conflicted file with the base (:1:), ours (:2:) and theirs (:3:) stages,
+// and the merged chunks of unchanged and conflicting lines
+type BlameLine struct {
+	CommitID    string
+	SID         string
+	Author      string
+	AuthorTime  time.Time
+	Subject     string
+	LineNumber  int
+	Text        string
+	BranchName  string // The branch of the commit
+	BranchColor Color
+}
+
+type ReflogEntry struct {
+	Ref      string // HEAD or branch name
+	Index    int
+	Selector string // E.g. HEAD@{2}
+	CommitID string
+	SID      string
+	Time     time.Time
+	Action   string // E.g. "commit", "checkout" or "reset"
+	Message  string
+	Subject  string // The commit subject
+}
+
+type Worktree struct {
+	Path       string
+	HeadID     string
+	BranchName string // Empty if detached
+	IsMain     bool
+	IsCurrent  bool // The worktree of the repo
+	IsDetached bool
+	IsLocked   bool
+	IsPrunable bool // The worktree folder is missing
+}
+
+type AddWorktreeReq struct {
+	RepoID     string
+	Path       string // A sibling folder of the main worktree is used, if empty
+	BranchName string
+}
+
+type Submodule struct {
+	Path          string // Relative the repo path
+	CommitID      string
+	IsInitialized bool
+	IsChanged     bool // The checked out commit differs from the recorded commit
+	HasConflicts  bool
+}
+
+type ConflictFile struct {
+	Path      string
+	Base      string
+	Ours      string
+	Theirs    string
+	HasBase   bool
+	HasOurs   bool // False if deleted by us
+	HasTheirs bool // False if deleted by them
+	Chunks    []ConflictChunk
+}
+
+type ConflictChunk struct {
+	IsConflict bool
+	Lines      []string // Lines of a non conflict chunk
+	Ours       []string
+	Base       []string
+	Theirs     []string
+}
+
+type ResolveConflictReq struct {
 	RepoID  string
-	Message string
+	Path    string
+	Choices []ConflictChoice // A choice for each conflict chunk
+}
+
+type StageSectionReq struct {
+	RepoID   string
+	Path     string
+	Section  SectionDiff
+	FromLine int // Index in Section.LinesDiffs
+	ToLine   int // Index in Section.LinesDiffs, -1 for whole section
+}
+
+type RewordCommitReq struct {
+	RepoID   string
+	CommitID string
+	Message  string
+}
+
+type SquashCommitsReq struct {
+	RepoID  string
+	FromID  string // The oldest commit in the range
+	ToID    string // The newest commit in the range
+	Message string // The message of the FromID commit is kept if empty
+}
+
+type RebaseBranchReq struct {
+	RepoID     string
+	BranchName string
+	OntoName   string // The inferred parent branch is used if empty
+}
+
+type CherryPickReq struct {
+	RepoID    string
+	CommitIDs []string
+}
+
+type CreateTagReq struct {
+	RepoID   string
+	Name     string
+	CommitID string
+	Message  string // Annotated tag if message is specified, otherwise a lightweight tag
+}
+
+type StashPushReq struct {
+	RepoID           string
+	Message          string
+	IncludeUntracked bool
 }
 
 type Repo struct {
@@ -91,8 +242,13 @@ type Repo struct {
 	CurrentBranchName  string
 	RepoPath           string
 	UncommittedChanges int
+	StagedChanges      int
 	MergeMessage       string
+	IsCherryPicking    bool
+	RebaseMessage      string // Set while a rebase is in progress, e.g. "Rebasing feature (2/5)"
 	Conflicts          int
+	Submodules         int // Submodules with a changed commit or content
+	Remotes            []string
 	ConsoleGraph       Graph
 }
 
@@ -119,6 +275,7 @@ const (
 	BranchToLeft   // ╰
 	BranchToRight  // ╯
 	ConnectLine    // │
+	SquashMerged   // ╌ squash merged or rebased into the branch to the left
 )
 
 type Commit struct {
@@ -141,6 +298,7 @@ type Commit struct {
 	IsPartialLogCommit bool
 	IsAmbiguous        bool
 	IsAmbiguousTip     bool
+	IsStash            bool
 }
 
 type GraphColumn struct {
@@ -160,12 +318,15 @@ type Branch struct {
 	Index                int
 	IsAmbiguousBranch    bool
 	RemoteName           string
+	Remote               string // Remote of a remote branch, or of the upstream branch of a local branch
 	LocalName            string
 	IsRemote             bool
 	IsGitBranch          bool
 	IsCurrent            bool
 	IsSetAsParent        bool
 	IsMainBranch         bool
+	IsSquashMerged       bool // Merged by a squash merge or a rebase, i.e. safe to delete
+	IsUserNamed          bool // An unnamed or ambiguous branch, which a user has named
 	TipID                string
 	HasLocalOnly         bool
 	HasRemoteOnly        bool
@@ -173,12 +334,22 @@ type Branch struct {
 	X                    int
 	AmbiguousTipId       string
 	AmbiguousBranchNames []string
+	WorktreePath         string // Path of the other worktree, where the branch is checked out
 
 	IsShown bool
 	IsIn    bool
 	IsOut   bool
 }
 
+type Stash struct {
+	Id      string
+	Sid     string
+	Name    string
+	Branch  string
+	Message string
+	Time    time.Time
+}
+
 type Branches []Branch
 
 func (bs Branches) Contains(predicate func(b Branch) bool) bool {
@@ -191,10 +362,34 @@ func (bs Branches) Contains(predicate func(b Branch) bool) bool {
 }
 
 type RepoChange struct {
-	IsStarting bool
-	ViewRepo   Repo
-	SearchText string
-	Error      error
+	IsStarting     bool
+	ViewRepo       Repo
+	SearchText     string
+	Error          error
+	RemoteProgress *RemoteProgress // Progress of a running remote command, e.g. a push
+}
+
+// RemoteProgress is the progress of a remote git command, e.g. "Receiving objects" at 45%
+type RemoteProgress struct {
+	Phase       string
+	Percent     int
+	Current     int
+	Total       int
+	Transferred string // E.g. "1.20 MiB"
+	Throughput  string // E.g. "2.40 MiB/s"
+}
+
+// CredentialPrompt is a prompt of a git command, e.g. for a password, which the client answers
+type CredentialPrompt struct {
+	ID       string
+	Prompt   string
+	IsSecret bool // E.g. a password, which should be masked
+}
+
+type CredentialAnswer struct {
+	ID         string
+	Answer     string
+	IsCanceled bool
 }
 
 type CommitDiff struct {
@@ -209,6 +404,7 @@ type FileDiff struct {
 	PathBefore   string
 	PathAfter    string
 	IsRenamed    bool
+	IsSubmodule  bool
 	DiffMode     DiffMode
 	SectionDiffs []SectionDiff
 }
@@ -0,0 +1,62 @@
+package console
+
+import (
+	"github.com/jroimartin/gocui"
+	"github.com/michael-reichenauer/gmc/utils/cui"
+)
+
+// BlameView shows the lines of a file with the commit, which last changed each line, where
+// Enter shows the commit of the current line in the repo view
+type BlameView struct {
+	ui   cui.UI
+	vm   *blameVM
+	view cui.View
+}
+
+func NewBlameView(ui cui.UI, blamer Blamer, repoID, path, ref string,
+	showCommit func(commitID, branchName string)) *BlameView {
+	t := &BlameView{ui: ui}
+	t.vm = newBlameVM(ui, t, blamer, repoID, path, ref, showCommit)
+	t.view = t.newView()
+	return t
+}
+
+func (t *BlameView) newView() cui.View {
+	view := t.ui.NewViewFromPageFunc(t.vm.getPage)
+	view.Properties().Name = "BlameView"
+	view.Properties().Title = t.vm.title()
+	view.Properties().HasFrame = true
+	view.Properties().OnLoad = t.vm.load
+	view.SetKey(gocui.KeyEnter, t.showCommit)
+	view.SetKey(gocui.KeyEsc, t.Close)
+	view.SetKey(gocui.KeyCtrlC, t.Close)
+	view.SetKey(gocui.KeyCtrlQ, t.Close)
+	view.SetKey('q', t.Close)
+	view.SetKey(gocui.KeyArrowLeft, func() { t.view.ScrollHorizontal(-1) })
+	view.SetKey(gocui.KeyArrowRight, func() { t.view.ScrollHorizontal(1) })
+	return view
+}
+
+func (t *BlameView) Show() {
+	t.view.Show(func(w, h int) cui.Rect { return cui.Rect{X: 0, Y: 1, W: w - 1, H: h - 1} })
+	t.view.SetTop()
+	t.view.SetCurrentView()
+}
+
+func (t *BlameView) Close() {
+	t.view.Close()
+}
+
+func (t *BlameView) NotifyChanged() {
+	t.view.NotifyChanged()
+}
+
+func (t *BlameView) PostOnUIThread(f func()) {
+	t.view.PostOnUIThread(f)
+}
+
+func (t *BlameView) showCommit() {
+	if t.vm.selectLine(t.view.ViewPage().CurrentLine) {
+		t.Close()
+	}
+}
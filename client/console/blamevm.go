@@ -0,0 +1,89 @@
+package console
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/michael-reichenauer/gmc/api"
+	"github.com/michael-reichenauer/gmc/utils"
+	"github.com/michael-reichenauer/gmc/utils/async"
+	"github.com/michael-reichenauer/gmc/utils/cui"
+	"github.com/michael-reichenauer/gmc/utils/git"
+	"github.com/michael-reichenauer/gmc/utils/linq"
+)
+
+type Blamer interface {
+	GetFileBlame(repoID, path, ref string) ([]api.BlameLine, error)
+}
+
+type blameVM struct {
+	ui         cui.UI
+	viewer     cui.Viewer
+	blamer     Blamer
+	repoID     string
+	path       string
+	ref        string
+	lines      []api.BlameLine
+	showCommit func(commitID, branchName string)
+}
+
+func newBlameVM(ui cui.UI, viewer cui.Viewer, blamer Blamer, repoID, path, ref string,
+	showCommit func(commitID, branchName string)) *blameVM {
+	return &blameVM{ui: ui, viewer: viewer, blamer: blamer, repoID: repoID, path: path, ref: ref,
+		showCommit: showCommit}
+}
+
+func (t *blameVM) load() {
+	progress := t.ui.ShowProgress("Getting blame ...")
+	async.RunRE(func() ([]api.BlameLine, error) { return t.blamer.GetFileBlame(t.repoID, t.path, t.ref) }).
+		Then(func(lines []api.BlameLine) {
+			progress.Close()
+			t.lines = lines
+			t.viewer.NotifyChanged()
+		}).
+		Catch(func(err error) {
+			progress.Close()
+			t.ui.ShowErrorMessageBox("Failed to get blame:\n%s\n%v", t.path, err)
+		})
+}
+
+func (t *blameVM) title() string {
+	if t.ref == "" {
+		return fmt.Sprintf("Blame: %s", t.path)
+	}
+	return fmt.Sprintf("Blame: %s (%s)", t.path, git.ToSid(t.ref))
+}
+
+// selectLine shows the commit of the line in the repo view
+func (t *blameVM) selectLine(index int) bool {
+	if index < 0 || index >= len(t.lines) {
+		return false
+	}
+	l := t.lines[index]
+	t.showCommit(l.CommitID, l.BranchName)
+	return true
+}
+
+func (t *blameVM) getPage(viewPage cui.ViewPage) cui.ViewText {
+	numberWidth := len(fmt.Sprintf("%d", len(t.lines)))
+	lines := linq.Map(t.lines, func(v api.BlameLine) string { return toBlameText(v, numberWidth) })
+	return cui.ViewText{Lines: pageLines(lines, viewPage), Total: len(lines)}
+}
+
+// toBlameText returns the line text, where the commit column has the commit branch color
+func toBlameText(l api.BlameLine, numberWidth int) string {
+	var sb strings.Builder
+	if l.CommitID == git.UncommittedID {
+		sb.WriteString(cui.ColorText(cui.Color(l.BranchColor), utils.Text("Uncommitted", 11)))
+		sb.WriteString(utils.Text("", 21))
+	} else {
+		sb.WriteString(cui.ColorText(cui.Color(l.BranchColor), utils.Text(l.SID, 6)))
+		sb.WriteString(" ")
+		sb.WriteString(cui.Dark(utils.Text(l.Author, 10)))
+		sb.WriteString(" ")
+		sb.WriteString(cui.Dark(l.AuthorTime.Format(dateTimeColumnFormat)[2:]))
+	}
+	sb.WriteString(cui.Dark(fmt.Sprintf(" %*d│ ", numberWidth, l.LineNumber)))
+	sb.WriteString(l.Text)
+	return sb.String()
+}
@@ -12,12 +12,19 @@ type BranchDlg interface {
 }
 
 func newBranchDlg(ui cui.UI, createBranch func(name string)) BranchDlg {
-	h := &branchDlg{ui: ui, createBranch: createBranch}
+	h := &branchDlg{ui: ui, title: "Create Branch", createBranch: createBranch}
+	return h
+}
+
+// newBranchNameDlg returns a dialog to name an unnamed or ambiguous branch
+func newBranchNameDlg(ui cui.UI, setName func(name string)) BranchDlg {
+	h := &branchDlg{ui: ui, title: "Name Branch", createBranch: setName}
 	return h
 }
 
 type branchDlg struct {
 	ui           cui.UI
+	title        string
 	createBranch func(name string)
 	boxView      cui.View
 	textView     cui.View
@@ -42,7 +49,7 @@ func (t *branchDlg) Show() {
 
 func (t *branchDlg) newBranchView() cui.View {
 	view := t.ui.NewView("\n\nName:")
-	view.Properties().Title = "Create Branch"
+	view.Properties().Title = t.title
 	view.Properties().Name = "CreateBranchDlg"
 	view.Properties().HideHorizontalScrollbar = true
 	view.Properties().HideVerticalScrollbar = true
@@ -9,6 +9,7 @@ import (
 	"github.com/michael-reichenauer/gmc/utils/async"
 	"github.com/michael-reichenauer/gmc/utils/cui"
 	"github.com/michael-reichenauer/gmc/utils/git"
+	"github.com/michael-reichenauer/gmc/utils/linq"
 	"github.com/michael-reichenauer/gmc/utils/log"
 )
 
@@ -16,6 +17,9 @@ type Committer interface {
 	GetCommitDiff(info api.CommitDiffInfoReq) (api.CommitDiff, error)
 	GetFileDiff(info api.FileDiffInfoReq) ([]api.CommitDiff, error)
 	Commit(info api.CommitInfoReq) error
+	GetUncommittedFiles(repoID string) ([]api.UncommittedFile, error)
+	StageFile(repoID, path string) error
+	UnstageFile(repoID, path string) error
 }
 
 func NewCommitView(ui cui.UI, committer Committer, repoID, branchName string, changes int) *CommitView {
@@ -28,10 +32,12 @@ type CommitView struct {
 	committer   Committer
 	commitView  cui.View
 	messageView cui.View
+	filesView   cui.View
 	buttonsView cui.View
 	repoID      string
 	branchName  string
 	changes     int
+	files       []api.UncommittedFile
 }
 
 func (h *CommitView) Show(text string) {
@@ -48,16 +54,20 @@ func (h *CommitView) Show(text string) {
 	h.commitView = h.newCommitView(subject)
 	h.buttonsView = h.newButtonsView()
 	h.messageView = h.newMessageView(message)
+	h.filesView = h.newFilesView()
 
-	bb, tb, bbb := h.getBounds()
+	bb, tb, fb, bbb := h.getBounds()
 	h.commitView.Show(bb)
 	h.buttonsView.Show(bbb)
 	h.messageView.Show(tb)
+	h.filesView.Show(fb)
 
 	h.commitView.SetTop()
 	h.messageView.SetTop()
+	h.filesView.SetTop()
 	h.buttonsView.SetTop()
 	h.commitView.SetCurrentView()
+	h.loadFiles()
 }
 
 // The total dialog with title and frame
@@ -93,6 +103,22 @@ func (h *CommitView) newMessageView(text string) cui.View {
 	view.SetKey(gocui.KeyCtrlC, h.onCancel)
 	view.SetKey(gocui.KeyEsc, h.onCancel)
 	view.SetKey(gocui.KeyCtrlD, h.showDiff)
+	view.SetKey(gocui.KeyTab, h.goToFiles)
+	return view
+}
+
+// The changed files, where checked files are staged and will be committed
+func (h *CommitView) newFilesView() cui.View {
+	view := h.ui.NewViewFromPageFunc(h.getFilesPage)
+	view.Properties().Title = "Files (Space to stage/unstage)"
+	view.Properties().HasFrame = true
+	view.Properties().HideHorizontalScrollbar = true
+	view.Properties().OnMouseLeft = func(_, _ int) { h.goToFiles() }
+	view.SetKey(gocui.KeySpace, h.toggleStaged)
+	view.SetKey(gocui.KeyCtrlO, h.onOk)
+	view.SetKey(gocui.KeyCtrlC, h.onCancel)
+	view.SetKey(gocui.KeyEsc, h.onCancel)
+	view.SetKey(gocui.KeyCtrlD, h.showDiff)
 	view.SetKey(gocui.KeyTab, h.goToSubject)
 	return view
 }
@@ -110,6 +136,7 @@ func (h *CommitView) newButtonsView() cui.View {
 }
 
 func (h *CommitView) Close() {
+	h.filesView.Close()
 	h.messageView.Close()
 	h.buttonsView.Close()
 	h.commitView.Close()
@@ -122,15 +149,90 @@ func (h *CommitView) goToSubject() {
 	h.commitView.SetCurrentView()
 }
 
-func (h *CommitView) getBounds() (cui.BoundFunc, cui.BoundFunc, cui.BoundFunc) {
-	box := cui.CenterBounds(10, 5, 70, 15)
+func (h *CommitView) goToFiles() {
+	h.filesView.SetCurrentView()
+}
+
+func (h *CommitView) getBounds() (cui.BoundFunc, cui.BoundFunc, cui.BoundFunc, cui.BoundFunc) {
+	box := cui.CenterBounds(10, 5, 70, 22)
 	msg := cui.Relative(box, func(b cui.Rect) cui.Rect {
-		return cui.Rect{X: b.X, Y: b.Y + 2, W: b.W, H: b.H - 4}
+		return cui.Rect{X: b.X, Y: b.Y + 2, W: b.W, H: b.H - 11}
+	})
+	files := cui.Relative(box, func(b cui.Rect) cui.Rect {
+		return cui.Rect{X: b.X, Y: b.Y + b.H - 8, W: b.W, H: 6}
 	})
 	buttons := cui.Relative(box, func(b cui.Rect) cui.Rect {
 		return cui.Rect{X: b.X, Y: b.Y + b.H - 1, W: b.W, H: 1}
 	})
-	return box, msg, buttons
+	return box, msg, files, buttons
+}
+
+func (h *CommitView) getFilesPage(viewPage cui.ViewPage) cui.ViewText {
+	first := viewPage.FirstLine
+	last := first + viewPage.Height
+	if last > len(h.files) {
+		last = len(h.files)
+	}
+	if first > last {
+		first = last
+	}
+
+	var lines []string
+	for _, f := range h.files[first:last] {
+		lines = append(lines, h.fileText(f))
+	}
+	return cui.ViewText{Lines: lines, Total: len(h.files)}
+}
+
+func (h *CommitView) fileText(f api.UncommittedFile) string {
+	switch {
+	case f.IsStaged && f.IsUnstaged:
+		// Partially staged, e.g. only some sections of the file are staged
+		return cui.Yellow("[~] ") + f.Path
+	case f.IsStaged:
+		return cui.Green("[x] ") + f.Path
+	default:
+		return "[ ] " + f.Path
+	}
+}
+
+func (h *CommitView) loadFiles() {
+	async.RunRE(func() ([]api.UncommittedFile, error) { return h.committer.GetUncommittedFiles(h.repoID) }).
+		Then(func(files []api.UncommittedFile) {
+			h.files = files
+			h.updateTitle()
+			h.filesView.NotifyChanged()
+		}).
+		Catch(func(err error) { h.ui.ShowErrorMessageBox("Failed to get files,\n%v", err) })
+}
+
+func (h *CommitView) toggleStaged() {
+	index := h.filesView.ViewPage().CurrentLine
+	if index < 0 || index >= len(h.files) {
+		return
+	}
+	f := h.files[index]
+
+	async.RunE(func() error {
+		if f.IsStaged && !f.IsUnstaged {
+			return h.committer.UnstageFile(h.repoID, f.Path)
+		}
+		return h.committer.StageFile(h.repoID, f.Path)
+	}).
+		Then(func(_ any) { h.loadFiles() }).
+		Catch(func(err error) { h.ui.ShowErrorMessageBox("Failed to stage/unstage,\n%v", err) })
+}
+
+func (h *CommitView) stagedCount() int {
+	return len(linq.Filter(h.files, func(v api.UncommittedFile) bool { return v.IsStaged }))
+}
+
+func (h *CommitView) updateTitle() {
+	if staged := h.stagedCount(); staged > 0 {
+		h.commitView.SetTitle(fmt.Sprintf("Commit %d staged of %d files on: %s", staged, len(h.files), h.branchName))
+		return
+	}
+	h.commitView.SetTitle(fmt.Sprintf("Commit %d files on: %s", len(h.files), h.branchName))
 }
 
 func (h *CommitView) onButtonsClick(x int, y int) {
@@ -155,8 +257,11 @@ func (h *CommitView) onOk() {
 		total = total + "\n\n" + msg
 	}
 
+	// If some files are staged, only those are committed, otherwise all changes are committed
+	isOnlyStaged := h.stagedCount() > 0
+
 	progress := h.ui.ShowProgress("Committing ...")
-	req := api.CommitInfoReq{RepoID: h.repoID, Message: total}
+	req := api.CommitInfoReq{RepoID: h.repoID, Message: total, IsOnlyStaged: isOnlyStaged}
 	async.RunE(func() error { return h.committer.Commit(req) }).
 		Then(func(r any) {
 			progress.Close()
@@ -0,0 +1,148 @@
+package console
+
+import (
+	"github.com/jroimartin/gocui"
+	"github.com/michael-reichenauer/gmc/api"
+	"github.com/michael-reichenauer/gmc/utils/cui"
+)
+
+// ConflictView shows the conflicted files and, for the selected file, ours/base/theirs for each
+// conflict, where ours, theirs or both can be chosen before the file is written and staged
+type ConflictView struct {
+	ui        cui.UI
+	vm        *conflictVM
+	filesView cui.View
+	fileView  cui.View
+	isFiles   bool // True when the files view is the current view
+}
+
+func NewConflictView(ui cui.UI, resolver ConflictResolver, repoID string) *ConflictView {
+	t := &ConflictView{ui: ui}
+	t.vm = newConflictVM(ui, t, resolver, repoID)
+	t.filesView = t.newFilesView()
+	t.fileView = t.newFileView()
+	return t
+}
+
+func (t *ConflictView) newFilesView() cui.View {
+	view := t.ui.NewViewFromPageFunc(t.vm.getFilesPage)
+	view.Properties().Name = "ConflictFilesView"
+	view.Properties().Title = "Conflicted Files"
+	view.Properties().HasFrame = true
+	view.Properties().HideHorizontalScrollbar = true
+	view.Properties().OnLoad = t.vm.load
+	view.Properties().OnMoved = t.onFileMoved
+	view.SetKey(gocui.KeyEnter, t.goToFile)
+	t.setCommonKeys(view)
+	return view
+}
+
+func (t *ConflictView) newFileView() cui.View {
+	view := t.ui.NewViewFromPageFunc(t.vm.getFilePage)
+	view.Properties().Name = "ConflictFileView"
+	view.Properties().Title = "Conflicts"
+	view.Properties().HasFrame = true
+	view.Properties().OnMouseRight = t.showContextMenu
+	view.SetKey('o', func() { t.choose(api.ChooseOurs) })
+	view.SetKey('t', func() { t.choose(api.ChooseTheirs) })
+	view.SetKey('b', func() { t.choose(api.ChooseBoth) })
+	view.SetKey('n', func() { t.goToConflict(true) })
+	view.SetKey('p', func() { t.goToConflict(false) })
+	view.SetKey(gocui.KeyArrowLeft, func() { t.fileView.ScrollHorizontal(-1) })
+	view.SetKey(gocui.KeyArrowRight, func() { t.fileView.ScrollHorizontal(1) })
+	t.setCommonKeys(view)
+	return view
+}
+
+func (t *ConflictView) setCommonKeys(view cui.View) {
+	view.SetKey(gocui.KeyEsc, t.Close)
+	view.SetKey(gocui.KeyCtrlC, t.Close)
+	view.SetKey(gocui.KeyCtrlQ, t.Close)
+	view.SetKey('q', t.Close)
+	view.SetKey(gocui.KeyTab, t.toggleView)
+	view.SetKey(gocui.KeyCtrlS, t.vm.resolve)
+	view.SetKey('w', t.vm.resolve)
+}
+
+func (t *ConflictView) Show() {
+	files, file := t.getBounds()
+	t.filesView.Show(files)
+	t.fileView.Show(file)
+	t.filesView.SetTop()
+	t.fileView.SetTop()
+	t.fileView.SetCurrentView()
+}
+
+func (t *ConflictView) Close() {
+	t.filesView.Close()
+	t.fileView.Close()
+}
+
+func (t *ConflictView) NotifyChanged() {
+	t.fileView.SetTitle(t.vm.title())
+	t.filesView.NotifyChanged()
+	t.fileView.NotifyChanged()
+}
+
+func (t *ConflictView) PostOnUIThread(f func()) {
+	t.fileView.PostOnUIThread(f)
+}
+
+func (t *ConflictView) getBounds() (cui.BoundFunc, cui.BoundFunc) {
+	files := func(w, h int) cui.Rect {
+		return cui.Rect{X: 0, Y: 1, W: t.filesWidth(w), H: h - 1}
+	}
+	file := func(w, h int) cui.Rect {
+		fw := t.filesWidth(w) + 2
+		return cui.Rect{X: fw, Y: 1, W: w - fw - 1, H: h - 1}
+	}
+	return files, file
+}
+
+func (t *ConflictView) filesWidth(w int) int {
+	if w/4 < 30 {
+		return w / 4
+	}
+	return 30
+}
+
+func (t *ConflictView) onFileMoved() {
+	t.vm.selectFile(t.filesView.ViewPage().CurrentLine)
+}
+
+func (t *ConflictView) goToFile() {
+	t.isFiles = false
+	t.fileView.SetCurrentView()
+}
+
+func (t *ConflictView) toggleView() {
+	if !t.isFiles {
+		t.isFiles = true
+		t.filesView.SetCurrentView()
+		return
+	}
+	t.goToFile()
+}
+
+func (t *ConflictView) choose(choice api.ConflictChoice) {
+	line := t.fileView.ViewPage().CurrentLine
+	t.vm.choose(line, choice)
+	// Move to the next conflict to make it easy to resolve conflict after conflict
+	t.fileView.SetCurrentLine(t.vm.nextConflictLine(line, true))
+}
+
+func (t *ConflictView) goToConflict(isNext bool) {
+	line := t.fileView.ViewPage().CurrentLine
+	t.fileView.SetCurrentLine(t.vm.nextConflictLine(line, isNext))
+}
+
+func (t *ConflictView) showContextMenu(x int, y int) {
+	line := t.fileView.ViewPage().FirstLine + y
+	cm := t.ui.NewMenu("")
+	cm.Add(cui.MenuItem{Text: "Use Ours", Key: "o", Action: func() { t.vm.choose(line, api.ChooseOurs) }})
+	cm.Add(cui.MenuItem{Text: "Use Theirs", Key: "t", Action: func() { t.vm.choose(line, api.ChooseTheirs) }})
+	cm.Add(cui.MenuItem{Text: "Use Both", Key: "b", Action: func() { t.vm.choose(line, api.ChooseBoth) }})
+	cm.Add(cui.MenuItem{Text: "Write and Stage File", Key: "w", Action: t.vm.resolve})
+	cm.Add(cui.MenuItem{Text: "Close", Key: "Esc", Action: t.Close})
+	cm.Show(x+3, y+2)
+}
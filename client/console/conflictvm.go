@@ -0,0 +1,268 @@
+package console
+
+import (
+	"fmt"
+
+	"github.com/michael-reichenauer/gmc/api"
+	"github.com/michael-reichenauer/gmc/utils/async"
+	"github.com/michael-reichenauer/gmc/utils/cui"
+	"github.com/michael-reichenauer/gmc/utils/linq"
+)
+
+type ConflictResolver interface {
+	GetConflictFiles(repoID string) ([]string, error)
+	GetConflictFile(repoID, path string) (api.ConflictFile, error)
+	ResolveConflict(req api.ResolveConflictReq) error
+}
+
+// conflictLine is a shown line in a conflicted file, conflict is the index of the conflict
+// the line belongs to or -1 for unchanged lines
+type conflictLine struct {
+	text     string
+	conflict int
+}
+
+type conflictVM struct {
+	ui       cui.UI
+	viewer   cui.Viewer
+	resolver ConflictResolver
+	repoID   string
+	files    []string
+	path     string
+	file     api.ConflictFile
+	choices  []api.ConflictChoice
+	lines    []conflictLine
+}
+
+func newConflictVM(ui cui.UI, viewer cui.Viewer, resolver ConflictResolver, repoID string) *conflictVM {
+	return &conflictVM{ui: ui, viewer: viewer, resolver: resolver, repoID: repoID}
+}
+
+// load loads the conflicted files and shows the first file (or the current file if still conflicted)
+func (t *conflictVM) load() {
+	progress := t.ui.ShowProgress("Getting conflicts ...")
+	async.RunRE(func() ([]string, error) { return t.resolver.GetConflictFiles(t.repoID) }).
+		Then(func(files []string) {
+			progress.Close()
+			t.files = files
+			if len(files) == 0 {
+				t.path = ""
+				t.file = api.ConflictFile{}
+				t.setLines()
+				t.viewer.NotifyChanged()
+				return
+			}
+			if !linq.Contains(files, t.path) {
+				t.path = files[0]
+			}
+			t.loadFile(t.path)
+		}).
+		Catch(func(err error) {
+			progress.Close()
+			t.ui.ShowErrorMessageBox("Failed to get conflicts:\n%v", err)
+		})
+}
+
+func (t *conflictVM) selectFile(index int) {
+	if index < 0 || index >= len(t.files) || t.files[index] == t.path {
+		return
+	}
+	t.loadFile(t.files[index])
+}
+
+func (t *conflictVM) loadFile(path string) {
+	async.RunRE(func() (api.ConflictFile, error) { return t.resolver.GetConflictFile(t.repoID, path) }).
+		Then(func(file api.ConflictFile) {
+			t.path = path
+			t.file = file
+			t.choices = make([]api.ConflictChoice, t.conflictsCount())
+			t.setLines()
+			t.viewer.NotifyChanged()
+		}).
+		Catch(func(err error) { t.ui.ShowErrorMessageBox("Failed to get conflict:\n%s\n%v", path, err) })
+}
+
+// choose sets the choice for the conflict at the line (or the next conflict after the line)
+func (t *conflictVM) choose(line int, choice api.ConflictChoice) {
+	conflict := t.conflictAt(line)
+	if conflict == -1 {
+		return
+	}
+	t.choices[conflict] = choice
+	t.setLines()
+	t.viewer.NotifyChanged()
+}
+
+// resolve writes and stages the file, when all conflicts have a choice
+func (t *conflictVM) resolve() {
+	if t.path == "" {
+		return
+	}
+	if unresolved := t.unresolvedCount(); unresolved > 0 {
+		t.ui.ShowErrorMessageBox("%d conflict(s) are not resolved yet.\n"+
+			"Use 'o' (ours), 't' (theirs) or 'b' (both) for each conflict.", unresolved)
+		return
+	}
+
+	req := api.ResolveConflictReq{RepoID: t.repoID, Path: t.path, Choices: t.choices}
+	async.RunE(func() error { return t.resolver.ResolveConflict(req) }).
+		Then(func(_ any) { t.load() }).
+		Catch(func(err error) { t.ui.ShowErrorMessageBox("Failed to resolve:\n%s\n%v", t.path, err) })
+}
+
+// nextConflictLine returns the first line of the next (or previous) conflict after the line
+func (t *conflictVM) nextConflictLine(line int, isNext bool) int {
+	current := -1
+	if line >= 0 && line < len(t.lines) {
+		current = t.lines[line].conflict
+	}
+	if isNext {
+		for i := line + 1; i < len(t.lines); i++ {
+			if t.lines[i].conflict != -1 && t.lines[i].conflict != current {
+				return i
+			}
+		}
+		return line
+	}
+
+	for i := line - 1; i >= 0; i-- {
+		if t.lines[i].conflict != -1 && t.lines[i].conflict != current {
+			// Find the first line of that conflict
+			c := t.lines[i].conflict
+			for i > 0 && t.lines[i-1].conflict == c {
+				i--
+			}
+			return i
+		}
+	}
+	return line
+}
+
+func (t *conflictVM) getFilesPage(viewPage cui.ViewPage) cui.ViewText {
+	lines := linq.Map(t.files, func(v string) string {
+		if v == t.path {
+			return cui.White(v)
+		}
+		return v
+	})
+	return cui.ViewText{Lines: pageLines(lines, viewPage), Total: len(lines)}
+}
+
+func (t *conflictVM) getFilePage(viewPage cui.ViewPage) cui.ViewText {
+	if len(t.files) == 0 {
+		return cui.ViewText{Lines: []string{cui.Green("All conflicts are resolved.")}, Total: 1}
+	}
+	lines := linq.Map(t.lines, func(v conflictLine) string { return v.text })
+	return cui.ViewText{Lines: pageLines(lines, viewPage), Total: len(lines)}
+}
+
+func (t *conflictVM) title() string {
+	if t.path == "" {
+		return "Conflicts"
+	}
+	return fmt.Sprintf("%s (%d of %d conflicts resolved)",
+		t.path, t.conflictsCount()-t.unresolvedCount(), t.conflictsCount())
+}
+
+// conflictAt returns the index of the conflict at the line, or the next conflict after the line
+func (t *conflictVM) conflictAt(line int) int {
+	for i := line; i >= 0 && i < len(t.lines); i++ {
+		if t.lines[i].conflict != -1 {
+			return t.lines[i].conflict
+		}
+	}
+	return -1
+}
+
+func (t *conflictVM) conflictsCount() int {
+	return len(linq.Filter(t.file.Chunks, func(v api.ConflictChunk) bool { return v.IsConflict }))
+}
+
+func (t *conflictVM) unresolvedCount() int {
+	return len(linq.Filter(t.choices, func(v api.ConflictChoice) bool { return v == api.ChooseNone }))
+}
+
+func (t *conflictVM) setLines() {
+	t.lines = toConflictLines(t.file, t.choices)
+}
+
+// toConflictLines returns the lines to show for the file, where each conflict shows ours,
+// base and theirs lines, and where the chosen lines are highlighted
+func toConflictLines(file api.ConflictFile, choices []api.ConflictChoice) []conflictLine {
+	var lines []conflictLine
+	add := func(conflict int, text string) {
+		lines = append(lines, conflictLine{text: text, conflict: conflict})
+	}
+
+	conflict := 0
+	for _, c := range file.Chunks {
+		if !c.IsConflict {
+			for _, l := range c.Lines {
+				add(-1, "  "+l)
+			}
+			continue
+		}
+
+		choice := api.ChooseNone
+		if conflict < len(choices) {
+			choice = choices[conflict]
+		}
+		isOurs := choice == api.ChooseOurs || choice == api.ChooseBoth
+		isTheirs := choice == api.ChooseTheirs || choice == api.ChooseBoth
+
+		add(conflict, cui.Yellow(fmt.Sprintf("▼ Conflict %d: %s", conflict+1, choiceText(choice))))
+		add(conflict, cui.Cyan("<<<<<<< Ours"))
+		for _, l := range c.Ours {
+			add(conflict, chosenLine(l, isOurs, choice))
+		}
+		if file.HasBase {
+			add(conflict, cui.Dark("||||||| Base"))
+			for _, l := range c.Base {
+				add(conflict, cui.Dark("  "+l))
+			}
+		}
+		add(conflict, cui.Cyan("======="))
+		for _, l := range c.Theirs {
+			add(conflict, chosenLine(l, isTheirs, choice))
+		}
+		add(conflict, cui.Cyan(">>>>>>> Theirs"))
+		conflict++
+	}
+	return lines
+}
+
+func chosenLine(line string, isChosen bool, choice api.ConflictChoice) string {
+	switch {
+	case isChosen:
+		return cui.Green("+ " + line)
+	case choice != api.ChooseNone:
+		return cui.Dark("- " + line)
+	default:
+		return "  " + line
+	}
+}
+
+func choiceText(choice api.ConflictChoice) string {
+	switch choice {
+	case api.ChooseOurs:
+		return "use ours"
+	case api.ChooseTheirs:
+		return "use theirs"
+	case api.ChooseBoth:
+		return "use both (ours first)"
+	default:
+		return "not resolved (o: ours, t: theirs, b: both)"
+	}
+}
+
+func pageLines(lines []string, viewPage cui.ViewPage) []string {
+	first := viewPage.FirstLine
+	last := first + viewPage.Height
+	if last > len(lines) {
+		last = len(lines)
+	}
+	if first > last {
+		first = last
+	}
+	return lines[first:last]
+}
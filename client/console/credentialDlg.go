@@ -0,0 +1,117 @@
+package console
+
+import (
+	"github.com/jroimartin/gocui"
+	"github.com/michael-reichenauer/gmc/api"
+	"github.com/michael-reichenauer/gmc/utils/cui"
+)
+
+type CredentialDlg interface {
+	Show()
+}
+
+// newCredentialDlg returns a dialog for a git credential prompt, e.g. for a password, where the
+// answer is masked for secret prompts. The answer func is called once, when the dialog is closed
+func newCredentialDlg(ui cui.UI, prompt api.CredentialPrompt, answer func(answer string, ok bool)) CredentialDlg {
+	return &credentialDlg{ui: ui, prompt: prompt, answer: answer}
+}
+
+type credentialDlg struct {
+	ui          cui.UI
+	prompt      api.CredentialPrompt
+	answer      func(answer string, ok bool)
+	boxView     cui.View
+	textView    cui.View
+	buttonsView cui.View
+}
+
+func (t *credentialDlg) Show() {
+	t.boxView = t.newBoxView()
+	t.buttonsView = t.newButtonsView()
+	t.textView = t.newTextView()
+
+	bb, tb, bbb := t.getBounds()
+	t.boxView.Show(bb)
+	t.buttonsView.Show(bbb)
+	t.textView.Show(tb)
+
+	t.boxView.SetTop()
+	t.buttonsView.SetTop()
+	t.textView.SetTop()
+	t.textView.SetCurrentView()
+}
+
+func (t *credentialDlg) newBoxView() cui.View {
+	view := t.ui.NewView("\n " + t.prompt.Prompt)
+	view.Properties().Title = "Credentials"
+	view.Properties().Name = "CredentialDlg"
+	view.Properties().HideHorizontalScrollbar = true
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideCurrentLineMarker = true
+	return view
+}
+
+func (t *credentialDlg) newButtonsView() cui.View {
+	view := t.ui.NewView(" [OK] [Cancel]")
+	view.Properties().OnMouseLeft = t.onButtonsClick
+	view.Properties().HideHorizontalScrollbar = true
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideCurrentLineMarker = true
+	return view
+}
+
+func (t *credentialDlg) newTextView() cui.View {
+	view := t.ui.NewView("")
+	view.Properties().HasFrame = true
+	view.Properties().HideCurrentLineMarker = true
+	view.Properties().IsEditable = true
+	view.Properties().IsMasked = t.prompt.IsSecret
+	view.SetKey(gocui.KeyCtrlO, t.onOk)
+	view.SetKey(gocui.KeyEnter, t.onOk)
+	view.SetKey(gocui.KeyCtrlC, t.onCancel)
+	view.SetKey(gocui.KeyEsc, t.onCancel)
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideHorizontalScrollbar = true
+	return view
+}
+
+func (t *credentialDlg) Close() {
+	t.textView.Close()
+	t.buttonsView.Close()
+	t.boxView.Close()
+}
+
+func (t *credentialDlg) getBounds() (cui.BoundFunc, cui.BoundFunc, cui.BoundFunc) {
+	box := cui.CenterBounds(50, 6, 70, 6)
+	text := cui.Relative(box, func(b cui.Rect) cui.Rect {
+		return cui.Rect{X: b.X + 1, Y: b.Y + 2, W: b.W - 2, H: 1}
+	})
+	buttons := cui.Relative(box, func(b cui.Rect) cui.Rect {
+		return cui.Rect{X: b.X, Y: b.Y + b.H - 1, W: b.W, H: 1}
+	})
+	return box, text, buttons
+}
+
+func (t *credentialDlg) onButtonsClick(x int, y int) {
+	if x > 0 && x < 5 {
+		t.onOk()
+	}
+	if x > 5 && x < 14 {
+		t.onCancel()
+	}
+}
+
+func (t *credentialDlg) onCancel() {
+	t.Close()
+	t.answer("", false)
+}
+
+func (t *credentialDlg) onOk() {
+	lines := t.textView.ReadLines()
+	answer := ""
+	if len(lines) > 0 {
+		answer = lines[0]
+	}
+	t.Close()
+	t.answer(answer, true)
+}
@@ -64,6 +64,14 @@ func getDetails(c api.Commit, cb api.Branch, cd api.CommitDetailsRsp) (string, s
 	}
 	title := "Commit: " + sid
 
+	branchRule := ""
+	if cd.BranchRule != "" {
+		branchRule = cui.Dark("Branch rule:") + " " + cd.BranchRule + "\n"
+	}
+	if len(cd.BranchCandidates) > 0 {
+		branchRule += cui.Dark("Candidates: ") + " " + strings.Join(cd.BranchCandidates, ", ") + "\n"
+	}
+
 	remote := ""
 	if c.IsLocalOnly {
 		remote = cui.Dark("Remote sync: ") + cui.GreenDk("▲") + " pushable\n"
@@ -75,6 +83,7 @@ func getDetails(c api.Commit, cb api.Branch, cd api.CommitDetailsRsp) (string, s
 	message := fmt.Sprintf(
 		cui.Dark("Id:")+"          %s\n"+
 			cui.Dark("Branch:     ")+" %s\n"+
+			"%s"+
 			cui.Dark("Children:   ")+" %s\n"+
 			cui.Dark("Parents:    ")+" %s\n"+
 			remote+
@@ -82,7 +91,7 @@ func getDetails(c api.Commit, cb api.Branch, cd api.CommitDetailsRsp) (string, s
 			cui.Blue(strings.Repeat("_", 50))+
 			cui.Blue("\n%d Files:\n")+
 			"%s",
-		id, cui.ColorText(cui.Color(cd.BranchColor), cd.BranchName),
+		id, cui.ColorText(cui.Color(cd.BranchColor), cd.BranchName), branchRule,
 		strings.Join(children, ", "), strings.Join(parents, ", "),
 		cd.Message, len(cd.Files), files)
 
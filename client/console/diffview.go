@@ -67,6 +67,8 @@ func (t *diffView) newLeftSide() cui.View {
 	view.SetKey('q', t.Close)
 	view.SetKey('1', t.ToUnified)
 	view.SetKey('2', t.ToSideBySide)
+	view.SetKey('s', t.stageSection)
+	view.SetKey('u', t.unstageSection)
 	view.SetKey(gocui.KeyArrowLeft, t.scrollHorizontalLeft)
 	view.SetKey(gocui.KeyArrowRight, t.scrollHorizontalRight)
 
@@ -194,6 +196,16 @@ func (t *diffView) scrollHorizontalRight() {
 	t.leftSide.ScrollHorizontal(1)
 }
 
+// stageSection stages the section at the top of the view
+func (t *diffView) stageSection() {
+	t.vm.stageSectionAt(t.leftSide.ViewPage().FirstLine)
+}
+
+// unstageSection unstages the section at the top of the view
+func (t *diffView) unstageSection() {
+	t.vm.unstageSectionAt(t.leftSide.ViewPage().FirstLine)
+}
+
 func (t *diffView) showContextMenu(x int, y int) {
 	cm := t.ui.NewMenu("")
 	if t.isUnified {
@@ -202,6 +214,12 @@ func (t *diffView) showContextMenu(x int, y int) {
 		cm.Add(cui.MenuItem{Text: "Show Unified Diff", Key: "1", Action: func() { t.ToUnified() }})
 	}
 
+	if t.vm.canStage() {
+		line := t.leftSide.ViewPage().FirstLine + y
+		cm.Add(cui.MenuItem{Text: "Stage Section", Key: "s", Action: func() { t.vm.stageSectionAt(line) }})
+		cm.Add(cui.MenuItem{Text: "Unstage Section", Key: "u", Action: func() { t.vm.unstageSectionAt(line) }})
+	}
+
 	cm.Add(cui.MenuItem{Text: "Close", Key: "Esc", Action: t.Close})
 	cm.Show(x+3, y+2)
 }
@@ -5,8 +5,10 @@ import (
 	"strings"
 
 	"github.com/michael-reichenauer/gmc/api"
+	"github.com/michael-reichenauer/gmc/utils/async"
 	"github.com/michael-reichenauer/gmc/utils/cui"
 	"github.com/michael-reichenauer/gmc/utils/git"
+	"github.com/michael-reichenauer/gmc/utils/linq"
 )
 
 type DiffGetter interface {
@@ -14,11 +16,29 @@ type DiffGetter interface {
 	GetFileDiff(info api.FileDiffInfoReq) ([]api.CommitDiff, error)
 }
 
+// Stager is used to stage/unstage sections when showing the uncommitted diff
+type Stager interface {
+	GetUncommittedFiles(repoID string) ([]api.UncommittedFile, error)
+	StageSection(req api.StageSectionReq) error
+	UnstageSection(req api.StageSectionReq) error
+}
+
+// diffSection is the range of diff lines shown for a section
+type diffSection struct {
+	path    string
+	section api.SectionDiff
+	first   int
+	last    int
+}
+
 type diffVM struct {
 	ui             cui.UI
 	viewer         cui.Viewer
 	diffGetter     DiffGetter
+	stager         Stager
 	commitDiffs    []api.CommitDiff
+	files          []api.UncommittedFile
+	sections       []diffSection
 	commitID       string
 	path           string
 	isDiffReady    bool
@@ -34,7 +54,12 @@ type diffVM struct {
 const viewWidth = 200
 
 func newCommitDiffVM(ui cui.UI, viewer cui.Viewer, diffGetter DiffGetter, repoID string, commitID string) *diffVM {
-	return &diffVM{ui: ui, viewer: viewer, diffGetter: diffGetter, repoID: repoID, commitID: commitID}
+	t := &diffVM{ui: ui, viewer: viewer, diffGetter: diffGetter, repoID: repoID, commitID: commitID}
+	if stager, ok := diffGetter.(Stager); ok && commitID == git.UncommittedID {
+		// Uncommitted changes can be staged/unstaged
+		t.stager = stager
+	}
+	return t
 }
 
 func newFileDiffVM(ui cui.UI, viewer cui.Viewer, diffGetter DiffGetter, repoID string, path string) *diffVM {
@@ -55,6 +80,10 @@ func (t *diffVM) loadCommitDiff() {
 
 	go func() {
 		diff, err := t.diffGetter.GetCommitDiff(api.CommitDiffInfoReq{RepoID: t.repoID, CommitID: t.commitID})
+		var files []api.UncommittedFile
+		if err == nil && t.stager != nil {
+			files, err = t.stager.GetUncommittedFiles(t.repoID)
+		}
 		t.viewer.PostOnUIThread(func() {
 			progress.Close()
 			if err != nil {
@@ -62,7 +91,9 @@ func (t *diffVM) loadCommitDiff() {
 				return
 			}
 			t.commitDiffs = []api.CommitDiff{diff}
+			t.files = files
 			t.isDiffReady = true
+			t.isDiff = false // Recreate diff lines, when reloading
 			t.viewer.NotifyChanged()
 		})
 	}()
@@ -153,6 +184,7 @@ func (t *diffVM) loadingText(isLeft bool) cui.ViewText {
 func (t *diffVM) setDiffSides(firstCharIndex int) {
 	t.leftLines = nil
 	t.rightLines = nil
+	t.sections = nil
 	t.maxWidth = 0
 	// Adding diff summery with changed files list, count, ...
 	t.firstCharIndex = firstCharIndex
@@ -166,9 +198,11 @@ func (t *diffVM) setDiffSides(firstCharIndex int) {
 
 			// Add all diff sections in a file
 			for _, ds := range df.SectionDiffs {
+				first := len(t.leftLines)
 				t.addDiffSectionHeader(df, ds)
 				t.addDiffSectionLines(ds)
 				t.addLeftAndRight(cui.Dark(strings.Repeat("─", viewWidth)))
+				t.sections = append(t.sections, diffSection{path: df.PathAfter, section: ds, first: first, last: len(t.leftLines) - 1})
 			}
 		}
 		t.addLeftAndRight("")
@@ -241,7 +275,7 @@ func (t *diffVM) addFileHeader(df api.FileDiff) {
 	t.addLeftAndRight("")
 	t.addLeftAndRight(cui.Blue(strings.Repeat("─", viewWidth)))
 	fileText := cui.Cyan(fmt.Sprintf("%s %s", t.toDiffType(df), df.PathAfter))
-	t.addLeftAndRight(fileText)
+	t.addLeftAndRight(fileText + t.stagedText(df.PathAfter))
 	if df.IsRenamed {
 		renamedText := cui.Dark(fmt.Sprintf("Renamed:    %s -> %s", df.PathBefore, df.PathAfter))
 		t.addLeftAndRight(renamedText)
@@ -361,7 +395,61 @@ func (t *diffVM) add(left, right string) {
 	t.rightLines = append(t.rightLines, right)
 }
 
+func (t *diffVM) stagedText(path string) string {
+	f, ok := linq.Find(t.files, func(v api.UncommittedFile) bool { return v.Path == path })
+	if !ok || !f.IsStaged {
+		return ""
+	}
+	if f.IsUnstaged {
+		return cui.Yellow("  (partially staged)")
+	}
+	return cui.Green("  (staged)")
+}
+
+func (t *diffVM) canStage() bool {
+	return t.stager != nil && t.isDiffReady
+}
+
+// sectionAt returns the section shown at the line or the next section below the line
+func (t *diffVM) sectionAt(line int) (diffSection, bool) {
+	for _, s := range t.sections {
+		if line <= s.last {
+			return s, true
+		}
+	}
+	return diffSection{}, false
+}
+
+func (t *diffVM) stageSectionAt(line int) {
+	if !t.canStage() {
+		return
+	}
+	t.runStageSection(line, "stage", t.stager.StageSection)
+}
+
+func (t *diffVM) unstageSectionAt(line int) {
+	if !t.canStage() {
+		return
+	}
+	t.runStageSection(line, "unstage", t.stager.UnstageSection)
+}
+
+func (t *diffVM) runStageSection(line int, action string, stageFunc func(req api.StageSectionReq) error) {
+	s, ok := t.sectionAt(line)
+	if !ok {
+		return
+	}
+
+	req := api.StageSectionReq{RepoID: t.repoID, Path: s.path, Section: s.section, FromLine: 0, ToLine: -1}
+	async.RunE(func() error { return stageFunc(req) }).
+		Then(func(_ any) { t.load() }).
+		Catch(func(err error) { t.ui.ShowErrorMessageBox("Failed to %s section:\n%v", action, err) })
+}
+
 func (t *diffVM) toDiffType(df api.FileDiff) string {
+	if df.IsSubmodule && df.DiffMode == api.DiffModified {
+		return "Submodule: "
+	}
 	switch df.DiffMode {
 	case api.DiffModified:
 		return "Modified:  "
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/michael-reichenauer/gmc/api"
 	"github.com/michael-reichenauer/gmc/common/config"
@@ -23,9 +24,35 @@ func NewMainWindow(ui cui.UI, configService *config.Service) *MainWindow {
 
 func (t *MainWindow) Show(api api.Api, path string) {
 	t.api = api
+	go t.monitorCredentialPromptsRoutine()
 	t.ShowRepo(path)
 }
 
+// monitorCredentialPromptsRoutine shows credential prompts of git commands, e.g. a password
+// prompt, when pushing, and sends the answers back
+func (t *MainWindow) monitorCredentialPromptsRoutine() {
+	for {
+		prompts, err := t.api.GetCredentialPrompts()
+		if err != nil {
+			log.Warnf("Failed to get credential prompts, %v", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		for _, p := range prompts {
+			prompt := p
+			t.ui.Post(func() {
+				dlg := newCredentialDlg(t.ui, prompt, func(answer string, ok bool) {
+					err := t.api.AnswerCredentialPrompt(api.CredentialAnswer{ID: prompt.ID, Answer: answer, IsCanceled: !ok})
+					if err != nil {
+						log.Warnf("Failed to answer credential prompt, %v", err)
+					}
+				})
+				dlg.Show()
+			})
+		}
+	}
+}
+
 func (t *MainWindow) ShowRepo(path string) {
 	progress := t.ui.ShowProgress("Opening repo:\n%s", path)
 
@@ -2,6 +2,7 @@ package console
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/michael-reichenauer/gmc/api"
 	"github.com/michael-reichenauer/gmc/utils/cui"
@@ -61,12 +62,32 @@ func (t *menus) getMainMenuItems(currentLineIndex int) []cui.MenuItem {
 
 	// Commit items
 	items = append(items, cui.MenuSeparator(fmt.Sprintf("Commit: %s", c.SID)))
-	items = append(items, cui.MenuItem{Text: "Toggle Details ...", Key: "Enter", Action: t.vm.repoViewer.ShowCommitDetails})
+	if c.IsPartialLogCommit {
+		items = append(items, cui.MenuItem{Text: "Load More History", Key: "Enter", Action: t.vm.LoadMoreHistory})
+	} else {
+		items = append(items, cui.MenuItem{Text: "Toggle Details ...", Key: "Enter", Action: t.vm.repoViewer.ShowCommitDetails})
+	}
 	if c.ID == git.UncommittedID {
 		items = append(items, cui.MenuItem{Text: "Commit ...", Key: "C", Action: t.vm.showCommitDialog})
 	}
+	if t.vm.repo.Conflicts > 0 {
+		items = append(items, cui.MenuItem{Text: "Resolve Conflicts ...", Action: t.vm.showConflictView})
+	}
 	items = append(items, cui.MenuItem{Text: "Commit Diff ...", Key: "D", Action: func() { t.vm.showCommitDiff(c.ID) }})
+	items = append(items, t.getCherryPickMenuItems(currentLineIndex)...)
 	items = append(items, cui.MenuItem{Text: "Undo/Restore", Title: "Undo", ItemsFunc: t.getUndoMenuItems})
+	items = append(items, cui.MenuItem{Text: "Stash", Title: "Stash", ItemsFunc: func() []cui.MenuItem {
+		return t.getStashMenuItems(c)
+	}})
+	if !c.IsUncommitted && !c.IsStash && !c.IsPartialLogCommit {
+		items = append(items, cui.MenuItem{Text: "Tags", Title: "Tags", ItemsFunc: func() []cui.MenuItem {
+			return t.getTagMenuItems(c)
+		}})
+	}
+	if c.IsLocalOnly && t.vm.repo.Branches[c.BranchIndex].IsCurrent {
+		items = append(items, cui.MenuItem{Text: "Edit Commit", Title: fmt.Sprintf("Edit Commit %s", c.SID),
+			ItemsFunc: func() []cui.MenuItem { return t.getEditCommitMenuItems(currentLineIndex) }})
+	}
 
 	// Branches items
 	items = append(items, cui.MenuSeparator("Branches"))
@@ -83,6 +104,7 @@ func (t *menus) getMainMenuItems(currentLineIndex int) []cui.MenuItem {
 		ItemsFunc: t.getMergeMenuItems})
 	items = append(items, cui.MenuItem{Text: "MergeSquash", Title: fmt.Sprintf("MergeSquash Into: %s", t.vm.repo.CurrentBranchName),
 		ItemsFunc: t.getMergeSquashMenuItems})
+	items = append(items, t.getRebaseMenuItems(c)...)
 	items = append(items, cui.MenuItem{Text: "Create Branch ...", Key: "B", Action: t.vm.showCreateBranchDialog})
 	items = append(items, cui.MenuItem{Text: "Delete Branch", ItemsFunc: t.getDeleteBranchMenuItems})
 
@@ -95,7 +117,11 @@ func (t *menus) getMainMenuItems(currentLineIndex int) []cui.MenuItem {
 	items = append(items, cui.MenuSeparator("More"))
 	items = append(items, cui.MenuItem{Text: "Search/Filter ...", Key: "F", Action: t.vm.ShowSearchView})
 	items = append(items, cui.MenuItem{Text: "File History", Title: "All Files", ItemsFunc: t.getFileDiffsMenuItems})
+	items = append(items, cui.MenuItem{Text: "Blame File", Title: "All Files", ItemsFunc: t.getBlameFilesMenuItems})
+	items = append(items, cui.MenuItem{Text: "Reflog", Title: "Reflog", ItemsFunc: t.getReflogMenuItems})
 	items = append(items, cui.MenuItem{Text: "Open Repo", Title: "Open", ItemsFunc: t.vm.repoViewer.OpenRepoMenuItems})
+	items = append(items, cui.MenuItem{Text: "Open Worktree", Title: "Worktrees", ItemsFunc: t.getWorktreeMenuItems})
+	items = append(items, cui.MenuItem{Text: "Submodules", Title: "Submodules", ItemsFunc: t.getSubmoduleMenuItems})
 	items = append(items, cui.MenuItem{Text: "Clone Repo ...", Title: "Clone", Action: t.vm.showCloneDialog})
 	items = append(items, cui.MenuItem{Text: "Help ...", Key: "H", Action: func() { ShowHelpDlg(t.ui) }})
 
@@ -179,6 +205,18 @@ func (t *menus) getBranchHierarchyMenuItems(commit api.Commit) []cui.MenuItem {
 		}})
 	}
 
+	if commit.IsAmbiguous || !b.IsGitBranch {
+		items = append(items, cui.MenuItem{Text: "Name Branch ...", Action: func() {
+			t.vm.showBranchNameDialog(commit.ID)
+		}})
+	}
+	if b.IsUserNamed && !commit.IsAmbiguous {
+		txt := fmt.Sprintf("Unset %s Name", b.DisplayName)
+		items = append(items, cui.MenuItem{Text: txt, Action: func() {
+			t.vm.SetBranchName(commit.ID, "")
+		}})
+	}
+
 	if commit.IsAmbiguous && len(b.AmbiguousBranchNames) > 0 {
 		subItems := lo.Map(b.AmbiguousBranchNames, func(v string, _ int) cui.MenuItem {
 			vv := v
@@ -192,6 +230,11 @@ func (t *menus) getBranchHierarchyMenuItems(commit api.Commit) []cui.MenuItem {
 
 func (t *menus) toSwitchBranchMenuItem(branch api.Branch) cui.MenuItem {
 	return cui.MenuItem{Text: t.branchItemText(branch), Action: func() {
+		if branch.WorktreePath != "" {
+			// Branch cannot be checked out here, since it is checked out in another worktree
+			t.vm.repoViewer.ShowRepo(branch.WorktreePath)
+			return
+		}
 		t.vm.SwitchToBranch(branch.Name, branch.DisplayName)
 	}}
 }
@@ -247,10 +290,14 @@ func (t *menus) branchItemText(branch api.Branch) string {
 	} else if branch.IsOut {
 		prefix = "╯"
 	}
+	suffix := ""
+	if branch.WorktreePath != "" {
+		suffix = " (worktree)"
+	}
 	if branch.IsCurrent {
-		return prefix + "●" + branch.DisplayName
+		return prefix + "●" + branch.DisplayName + suffix
 	} else {
-		return prefix + " " + branch.DisplayName
+		return prefix + " " + branch.DisplayName + suffix
 	}
 }
 
@@ -294,6 +341,113 @@ func (t *menus) getUndoMenuItems() []cui.MenuItem {
 	return items
 }
 
+func (t *menus) getCherryPickMenuItems(currentLineIndex int) []cui.MenuItem {
+	if t.vm.repo.IsCherryPicking {
+		// A cherry-pick is paused (e.g. due to conflicts), it can be continued or aborted
+		return []cui.MenuItem{
+			{Text: "Continue Cherry-pick", Action: t.vm.CherryPickContinue},
+			{Text: "Abort Cherry-pick", Action: t.vm.CherryPickAbort},
+		}
+	}
+
+	c := t.vm.repo.Commits[currentLineIndex]
+	current, ok := t.vm.CurrentBranch()
+	if !ok || c.IsUncommitted || c.IsStash || c.IsPartialLogCommit ||
+		t.vm.repo.Branches[c.BranchIndex].DisplayName == current.DisplayName {
+		// No current branch (detached branch) or commit is already on the current branch
+		return nil
+	}
+
+	items := []cui.MenuItem{{Text: fmt.Sprintf("Commit %s", c.SID), Action: func() {
+		t.vm.CherryPick([]string{c.ID})
+	}}}
+
+	ids := t.vm.GetBranchCommitsUpTo(currentLineIndex)
+	if len(ids) > 1 {
+		text := fmt.Sprintf("Commits %s..%s (%d commits)", git.ToSid(ids[0]), c.SID, len(ids))
+		items = append(items, cui.MenuItem{Text: text, Action: func() { t.vm.CherryPick(ids) }})
+	}
+
+	title := fmt.Sprintf("Cherry-pick onto %s", current.DisplayName)
+	return []cui.MenuItem{{Text: title, Title: title, Items: items}}
+}
+
+// getEditCommitMenuItems returns items for editing a local (unpushed) commit on the current branch
+func (t *menus) getEditCommitMenuItems(currentLineIndex int) []cui.MenuItem {
+	c := t.vm.repo.Commits[currentLineIndex]
+	var items []cui.MenuItem
+
+	if c.IsCurrent {
+		items = append(items, cui.MenuItem{Text: "Amend Commit ...", Action: func() { t.vm.showAmendCommitDialog(c) }})
+	}
+	items = append(items, cui.MenuItem{Text: "Reword Commit ...", Action: func() { t.vm.showRewordCommitDialog(c) }})
+	items = append(items, cui.MenuItem{Text: "Drop Commit", Action: func() { t.vm.DropCommit(c) }})
+
+	// Squash the range of local commits on the branch up to the selected commit
+	ids := linq.Filter(t.vm.GetBranchCommitsUpTo(currentLineIndex), t.vm.isLocalOnlyCommit)
+	if len(ids) > 1 {
+		text := fmt.Sprintf("Squash Commits %s..%s (%d commits) ...", git.ToSid(ids[0]), c.SID, len(ids))
+		items = append(items, cui.MenuItem{Text: text, Action: func() { t.vm.showSquashCommitsDialog(ids) }})
+	}
+
+	return items
+}
+
+func (t *menus) getStashMenuItems(c api.Commit) []cui.MenuItem {
+	var items []cui.MenuItem
+
+	if t.vm.repo.UncommittedChanges > 0 {
+		items = append(items, cui.MenuItem{Text: "Stash Changes ...", Action: func() {
+			t.vm.showStashDialog(false)
+		}})
+		items = append(items, cui.MenuItem{Text: "Stash Changes incl. Untracked ...", Action: func() {
+			t.vm.showStashDialog(true)
+		}})
+	}
+
+	stashes := t.vm.GetStashes()
+	if c.IsStash {
+		// Selected commit is a stash, show its actions first
+		if s, ok := linq.Find(stashes, func(v api.Stash) bool { return v.Id == c.ID }); ok {
+			items = append(items, cui.MenuSeparator(s.Name))
+			items = append(items, t.getStashActionMenuItems(s)...)
+		}
+	}
+
+	if len(stashes) > 0 {
+		items = append(items, cui.MenuSeparator("Stashes"))
+		items = append(items, linq.Map(stashes, func(s api.Stash) cui.MenuItem {
+			return cui.MenuItem{Text: fmt.Sprintf("%s: %s", s.Name, s.Message), Title: s.Name,
+				Items: t.getStashActionMenuItems(s)}
+		})...)
+	}
+
+	return items
+}
+
+func (t *menus) getStashActionMenuItems(s api.Stash) []cui.MenuItem {
+	return []cui.MenuItem{
+		{Text: "Diff ...", Action: func() { t.vm.showCommitDiff(s.Id) }},
+		{Text: "Apply", Action: func() { t.vm.StashApply(s.Name) }},
+		{Text: "Pop", Action: func() { t.vm.StashPop(s.Name) }},
+		{Text: "Drop", Action: func() { t.vm.StashDrop(s.Name) }},
+	}
+}
+
+func (t *menus) getTagMenuItems(c api.Commit) []cui.MenuItem {
+	items := []cui.MenuItem{{Text: "Create Tag ...", Action: func() { t.vm.showCreateTagDialog(c) }}}
+
+	for _, tag := range c.Tags {
+		tag := tag
+		items = append(items, cui.MenuItem{Text: tag, Title: tag, Items: []cui.MenuItem{
+			{Text: "Push", Action: func() { t.vm.PushTag(tag) }},
+			{Text: "Delete", Action: func() { t.vm.DeleteTag(tag) }},
+		}})
+	}
+
+	return items
+}
+
 func (t *menus) getUncommittedFilesMenuItems() []cui.MenuItem {
 	files := t.vm.GetUncommittedFiles()
 
@@ -333,7 +487,8 @@ func (t *menus) getPullBranchMenuItems() []cui.MenuItem {
 
 	// Add current branch if it has commits that can be pulled
 	current, ok := t.vm.CurrentBranch()
-	if ok && current.HasRemoteOnly {
+	if ok && (current.HasRemoteOnly || len(t.vm.repo.Remotes) > 1) {
+		// With multiple remotes, the current branch can be updated from other remotes as well
 		pushItem := t.toPullCurrentBranchMenuItem(current)
 		pushItem.Key = "U"
 		items = append(items, pushItem)
@@ -354,23 +509,54 @@ func (t *menus) getPullBranchMenuItems() []cui.MenuItem {
 }
 
 func (t *menus) toPushBranchMenuItem(branch api.Branch) cui.MenuItem {
+	if len(t.vm.repo.Remotes) > 1 {
+		// Multiple remotes, let user choose which remote to push to
+		return cui.MenuItem{Text: t.branchItemText(branch), Title: "Push To Remote",
+			Items: t.getRemoteMenuItems(branch, func(remote string) { t.vm.PushBranchTo(remote, branch.DisplayName) })}
+	}
+
 	return cui.MenuItem{Text: t.branchItemText(branch), Action: func() {
 		t.vm.PushBranch(branch.DisplayName)
 	}}
 }
 
 func (t *menus) toPullBranchMenuItem(branch api.Branch) cui.MenuItem {
+	if branch.Remote != "" && branch.LocalName != "" {
+		// Remote branch with a local branch, pull from the remote branch remote (might not be origin)
+		return cui.MenuItem{Text: t.branchItemText(branch), Action: func() {
+			t.vm.PullBranchFrom(branch.Remote, branch.LocalName)
+		}}
+	}
+
 	return cui.MenuItem{Text: t.branchItemText(branch), Action: func() {
 		t.vm.PullBranch(branch.DisplayName)
 	}}
 }
 
 func (t *menus) toPullCurrentBranchMenuItem(branch api.Branch) cui.MenuItem {
+	if len(t.vm.repo.Remotes) > 1 {
+		// Multiple remotes, let user choose which remote to pull from
+		return cui.MenuItem{Text: t.branchItemText(branch), Title: "Pull From Remote",
+			Items: t.getRemoteMenuItems(branch, func(remote string) { t.vm.PullBranchFrom(remote, branch.Name) })}
+	}
+
 	return cui.MenuItem{Text: t.branchItemText(branch), Action: func() {
 		t.vm.PullCurrentBranch()
 	}}
 }
 
+// getRemoteMenuItems returns remote items, where the branch upstream remote is listed first
+func (t *menus) getRemoteMenuItems(branch api.Branch, action func(remote string)) []cui.MenuItem {
+	remotes := t.vm.repo.Remotes
+	if branch.Remote != "" {
+		remotes = append([]string{branch.Remote}, linq.Filter(remotes, func(v string) bool { return v != branch.Remote })...)
+	}
+
+	return linq.Map(remotes, func(remote string) cui.MenuItem {
+		return cui.MenuItem{Text: remote, Action: func() { action(remote) }}
+	})
+}
+
 func (t *menus) getMergeMenuItems() []cui.MenuItem {
 	current, ok := t.vm.CurrentBranch()
 	if !ok {
@@ -403,6 +589,39 @@ func (t *menus) getMergeSquashMenuItems() []cui.MenuItem {
 		})
 }
 
+func (t *menus) getRebaseMenuItems(c api.Commit) []cui.MenuItem {
+	if t.vm.repo.RebaseMessage != "" {
+		// A rebase is paused (e.g. due to conflicts), it can be continued, skipped or aborted
+		return []cui.MenuItem{
+			{Text: "Continue Rebase", Action: t.vm.RebaseContinue},
+			{Text: "Skip Commit in Rebase", Action: t.vm.RebaseSkip},
+			{Text: "Abort Rebase", Action: t.vm.RebaseAbort},
+		}
+	}
+
+	b := t.vm.repo.Branches[c.BranchIndex]
+	if !b.IsGitBranch || (b.IsRemote && b.LocalName == "") {
+		// Only local branches can be rebased
+		return nil
+	}
+
+	items := []cui.MenuItem{
+		{Text: "Rebase onto Parent", Action: func() { t.vm.RebaseBranch(b.Name, "") }},
+		{Text: "Rebase onto", Title: "Rebase onto", ItemsFunc: func() []cui.MenuItem {
+			return linq.FilterMap(t.vm.GetShownBranches(false),
+				func(v api.Branch) bool { return v.DisplayName != b.DisplayName },
+				func(v api.Branch) cui.MenuItem {
+					return cui.MenuItem{Text: t.branchItemText(v), Action: func() {
+						t.vm.RebaseBranch(b.Name, v.Name)
+					}}
+				})
+		}},
+	}
+
+	title := fmt.Sprintf("Rebase: %s", b.DisplayName)
+	return []cui.MenuItem{{Text: "Rebase", Title: title, Items: items}}
+}
+
 func (t *menus) getFileDiffsMenuItems() []cui.MenuItem {
 	c := t.vm.repo.Commits[t.vm.currentIndex]
 	ref := c.ID
@@ -421,12 +640,128 @@ func (t *menus) getFileDiffsMenuItems() []cui.MenuItem {
 		})
 }
 
+func (t *menus) getBlameFilesMenuItems() []cui.MenuItem {
+	c := t.vm.repo.Commits[t.vm.currentIndex]
+	filesRef := c.ID
+	blameRef := c.ID
+	if c.ID == git.UncommittedID {
+		// For uncommitted changes, the working tree files are blamed
+		cb, ok := t.vm.CurrentBranch()
+		if !ok {
+			return []cui.MenuItem{}
+		}
+		filesRef = cb.Name
+		blameRef = ""
+	}
+
+	return linq.Map(t.vm.GetFiles(filesRef),
+		func(v string) cui.MenuItem {
+			return cui.MenuItem{Text: v, Action: func() { t.vm.showBlameView(v, blameRef) }}
+		})
+}
+
+func (t *menus) getReflogMenuItems() []cui.MenuItem {
+	items := []cui.MenuItem{{Text: "HEAD", Action: func() { t.vm.showReflogView("") }}}
+	return append(items, linq.FilterMap(t.vm.GetAllBranches(),
+		func(b api.Branch) bool { return b.IsGitBranch && !b.IsRemote },
+		func(b api.Branch) cui.MenuItem {
+			return cui.MenuItem{Text: t.branchItemText(b), Action: func() { t.vm.showReflogView(b.Name) }}
+		})...)
+}
+
+func (t *menus) getWorktreeMenuItems() []cui.MenuItem {
+	worktrees := t.vm.GetWorktrees()
+	items := linq.FilterMap(worktrees,
+		func(w api.Worktree) bool { return !w.IsCurrent && !w.IsPrunable },
+		func(w api.Worktree) cui.MenuItem {
+			return cui.MenuItem{Text: worktreeItemText(w), Action: func() { t.vm.repoViewer.ShowRepo(w.Path) }}
+		})
+	if len(items) > 0 {
+		items = append(items, cui.MenuSeparator(""))
+	}
+
+	items = append(items, cui.MenuItem{Text: "Add Worktree", Title: "Add Worktree for Branch", ItemsFunc: func() []cui.MenuItem {
+		return linq.FilterMap(t.vm.GetAllBranches(),
+			func(b api.Branch) bool {
+				return b.IsGitBranch && !b.IsCurrent && b.WorktreePath == "" &&
+					!(b.IsRemote && b.LocalName != "")
+			},
+			func(b api.Branch) cui.MenuItem {
+				return cui.MenuItem{Text: t.branchItemText(b), Action: func() { t.vm.AddWorktree(b.Name) }}
+			})
+	}})
+
+	removeItems := linq.FilterMap(worktrees,
+		func(w api.Worktree) bool { return !w.IsMain && !w.IsCurrent },
+		func(w api.Worktree) cui.MenuItem {
+			return cui.MenuItem{Text: worktreeItemText(w), Action: func() { t.vm.RemoveWorktree(w) }}
+		})
+	if len(removeItems) > 0 {
+		items = append(items, cui.MenuItem{Text: "Remove Worktree", Title: "Remove Worktree", Items: removeItems})
+	}
+	return items
+}
+
+func (t *menus) getSubmoduleMenuItems() []cui.MenuItem {
+	items := linq.Map(t.vm.GetSubmodules(), func(s api.Submodule) cui.MenuItem {
+		if !s.IsInitialized {
+			// Not yet cloned, so it needs to be initialized before it can be opened
+			return cui.MenuItem{Text: submoduleItemText(s), Action: t.vm.UpdateSubmodules}
+		}
+		return cui.MenuItem{Text: submoduleItemText(s), Action: func() {
+			t.vm.repoViewer.ShowRepo(filepath.Join(t.vm.repo.RepoPath, s.Path))
+		}}
+	})
+	if len(items) > 0 {
+		items = append(items, cui.MenuSeparator(""))
+	}
+	items = append(items, cui.MenuItem{Text: "Update/Init Submodules", Action: t.vm.UpdateSubmodules})
+	return items
+}
+
+func submoduleItemText(s api.Submodule) string {
+	state := ""
+	switch {
+	case !s.IsInitialized:
+		state = " (not initialized)"
+	case s.HasConflicts:
+		state = " (conflicts)"
+	case s.IsChanged:
+		state = " (changed)"
+	}
+	return fmt.Sprintf("%s %s%s", s.Path, git.ToSid(s.CommitID), state)
+}
+
+func worktreeItemText(w api.Worktree) string {
+	name := w.BranchName
+	if name == "" {
+		name = git.ToSid(w.HeadID)
+	}
+	return fmt.Sprintf("%s (%s)", w.Path, name)
+}
+
 func (t *menus) getDeleteBranchMenuItems() []cui.MenuItem {
-	return linq.FilterMap(t.vm.GetAllBranches(),
-		func(b api.Branch) bool { return b.IsGitBranch && !b.IsMainBranch && !b.IsCurrent },
+	branches := linq.Filter(t.vm.GetAllBranches(),
+		func(b api.Branch) bool { return b.IsGitBranch && !b.IsMainBranch && !b.IsCurrent })
+
+	// Squash merged or rebased branches are safe to delete and listed first, where a force delete
+	// is needed, since git does not know the branch commits are merged
+	squashMerged := linq.FilterMap(branches,
+		func(b api.Branch) bool { return b.IsSquashMerged },
+		func(b api.Branch) cui.MenuItem {
+			return cui.MenuItem{Text: t.branchItemText(b) + " (squash/rebase merged)", Action: func() {
+				t.vm.DeleteBranch(b.Name, true)
+			}}
+		})
+	others := linq.FilterMap(branches,
+		func(b api.Branch) bool { return !b.IsSquashMerged },
 		func(b api.Branch) cui.MenuItem {
 			return cui.MenuItem{Text: t.branchItemText(b), Action: func() {
 				t.vm.DeleteBranch(b.Name, false)
 			}}
 		})
+	if len(squashMerged) > 0 && len(others) > 0 {
+		squashMerged = append(squashMerged, cui.MenuSeparator(""))
+	}
+	return append(squashMerged, others...)
 }
@@ -0,0 +1,155 @@
+package console
+
+import (
+	"strings"
+
+	"github.com/jroimartin/gocui"
+	"github.com/michael-reichenauer/gmc/utils/cui"
+)
+
+type MessageDlg interface {
+	Show()
+}
+
+// newMessageDlg shows a dialog for editing a commit message (subject and body), e.g. when
+// amending or rewording a commit
+func newMessageDlg(ui cui.UI, title, message string, onOk func(message string)) MessageDlg {
+	h := &messageDlg{ui: ui, title: title, message: message, onOkFunc: onOk}
+	return h
+}
+
+type messageDlg struct {
+	ui          cui.UI
+	title       string
+	message     string
+	onOkFunc    func(message string)
+	subjectView cui.View
+	bodyView    cui.View
+	buttonsView cui.View
+}
+
+func (t *messageDlg) Show() {
+	lines := strings.Split(t.message, "\n")
+	subject := lines[0]
+	body := ""
+	if len(lines) > 2 && strings.TrimSpace(lines[1]) == "" {
+		body = strings.Join(lines[2:], "\n")
+	} else if len(lines) > 1 {
+		body = strings.Join(lines[1:], "\n")
+	}
+
+	t.subjectView = t.newSubjectView(subject)
+	t.buttonsView = t.newButtonsView()
+	t.bodyView = t.newBodyView(body)
+
+	bb, mb, bbb := t.getBounds()
+	t.subjectView.Show(bb)
+	t.buttonsView.Show(bbb)
+	t.bodyView.Show(mb)
+
+	t.subjectView.SetTop()
+	t.bodyView.SetTop()
+	t.buttonsView.SetTop()
+	t.subjectView.SetCurrentView()
+}
+
+// The total dialog with title and frame, where the first line is the subject
+func (t *messageDlg) newSubjectView(text string) cui.View {
+	view := t.ui.NewView(text)
+	view.Properties().Title = t.title
+	view.Properties().Name = "MessageDlg"
+	view.Properties().IsEditable = true
+	view.Properties().HideHorizontalScrollbar = true
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideCurrentLineMarker = true
+	view.Properties().OnMouseLeft = func(_, _ int) { t.goToSubject() }
+	view.SetKey(gocui.KeyEnter, t.onOk)
+	view.SetKey(gocui.KeyCtrlO, t.onOk)
+	view.SetKey(gocui.KeyCtrlC, t.onCancel)
+	view.SetKey(gocui.KeyEsc, t.onCancel)
+	view.SetKey(gocui.KeyTab, t.goToBody)
+	view.SetKey(gocui.KeyArrowDown, t.goToBody)
+	return view
+}
+
+func (t *messageDlg) newBodyView(text string) cui.View {
+	view := t.ui.NewView(text)
+	view.Properties().Title = strings.Repeat(" ", 67)
+	view.Properties().IsEditable = true
+	view.Properties().HasFrame = false
+	view.Properties().HideHorizontalScrollbar = true
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideCurrentLineMarker = true
+	view.Properties().OnMouseLeft = func(_, _ int) { t.goToBody() }
+	view.SetKey(gocui.KeyCtrlO, t.onOk)
+	view.SetKey(gocui.KeyCtrlC, t.onCancel)
+	view.SetKey(gocui.KeyEsc, t.onCancel)
+	view.SetKey(gocui.KeyTab, t.goToSubject)
+	return view
+}
+
+func (t *messageDlg) newButtonsView() cui.View {
+	view := t.ui.NewView(" [OK] [Cancel]")
+	view.Properties().Title = strings.Repeat(" ", 67)
+	view.Properties().HasFrame = true
+	view.Properties().OnMouseLeft = t.onButtonsClick
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideCurrentLineMarker = true
+	view.Properties().HideHorizontalScrollbar = true
+	return view
+}
+
+func (t *messageDlg) Close() {
+	t.bodyView.Close()
+	t.buttonsView.Close()
+	t.subjectView.Close()
+}
+
+func (t *messageDlg) goToSubject() {
+	t.subjectView.SetCurrentView()
+}
+
+func (t *messageDlg) goToBody() {
+	t.bodyView.SetCurrentView()
+}
+
+func (t *messageDlg) getBounds() (cui.BoundFunc, cui.BoundFunc, cui.BoundFunc) {
+	box := cui.CenterBounds(10, 5, 70, 15)
+	body := cui.Relative(box, func(b cui.Rect) cui.Rect {
+		return cui.Rect{X: b.X, Y: b.Y + 2, W: b.W, H: b.H - 4}
+	})
+	buttons := cui.Relative(box, func(b cui.Rect) cui.Rect {
+		return cui.Rect{X: b.X, Y: b.Y + b.H - 1, W: b.W, H: 1}
+	})
+	return box, body, buttons
+}
+
+func (t *messageDlg) onButtonsClick(x int, y int) {
+	if x > 0 && x < 5 {
+		t.onOk()
+	}
+	if x > 5 && x < 14 {
+		t.onCancel()
+	}
+}
+
+func (t *messageDlg) onCancel() {
+	t.Close()
+}
+
+func (t *messageDlg) onOk() {
+	subject := strings.TrimSpace(t.subjectView.ReadLines()[0])
+	body := strings.TrimRight(strings.Join(t.bodyView.ReadLines(), "\n"), "\n")
+	if subject == "" {
+		t.ui.ShowErrorMessageBox("Empty commit message subject is not allowed.")
+		return
+	}
+
+	message := subject
+	if len(body) > 0 {
+		message = message + "\n\n" + body
+	}
+
+	t.onOkFunc(message)
+	t.Close()
+}
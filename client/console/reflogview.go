@@ -0,0 +1,92 @@
+package console
+
+import (
+	"github.com/jroimartin/gocui"
+	"github.com/michael-reichenauer/gmc/utils/cui"
+)
+
+// ReflogView shows the reflog of HEAD or a branch, where an entry can be diffed, checked out or
+// used to create a branch, e.g. to recover commits of a deleted branch
+type ReflogView struct {
+	ui   cui.UI
+	vm   *reflogVM
+	view cui.View
+}
+
+func NewReflogView(ui cui.UI, getter ReflogGetter, handler reflogHandler, repoID, ref string) *ReflogView {
+	t := &ReflogView{ui: ui}
+	t.vm = newReflogVM(ui, t, getter, handler, repoID, ref)
+	t.view = t.newView()
+	return t
+}
+
+func (t *ReflogView) newView() cui.View {
+	view := t.ui.NewViewFromPageFunc(t.vm.getPage)
+	view.Properties().Name = "ReflogView"
+	view.Properties().Title = t.vm.title()
+	view.Properties().HasFrame = true
+	view.Properties().OnLoad = t.vm.load
+	view.Properties().OnMouseRight = t.showContextMenu
+	view.SetKey(gocui.KeyEnter, t.showDiff)
+	view.SetKey('d', t.showDiff)
+	view.SetKey('b', t.createBranch)
+	view.SetKey('c', t.checkout)
+	view.SetKey('m', t.showCurrentContextMenu)
+	view.SetKey(gocui.KeyEsc, t.Close)
+	view.SetKey(gocui.KeyCtrlC, t.Close)
+	view.SetKey(gocui.KeyCtrlQ, t.Close)
+	view.SetKey('q', t.Close)
+	view.SetKey(gocui.KeyArrowLeft, func() { t.view.ScrollHorizontal(-1) })
+	view.SetKey(gocui.KeyArrowRight, func() { t.view.ScrollHorizontal(1) })
+	return view
+}
+
+func (t *ReflogView) Show() {
+	t.view.Show(func(w, h int) cui.Rect { return cui.Rect{X: 0, Y: 1, W: w - 1, H: h - 1} })
+	t.view.SetTop()
+	t.view.SetCurrentView()
+}
+
+func (t *ReflogView) Close() {
+	t.view.Close()
+}
+
+func (t *ReflogView) NotifyChanged() {
+	t.view.NotifyChanged()
+}
+
+func (t *ReflogView) PostOnUIThread(f func()) {
+	t.view.PostOnUIThread(f)
+}
+
+func (t *ReflogView) showDiff() {
+	t.vm.showDiff(t.view.ViewPage().CurrentLine)
+}
+
+func (t *ReflogView) createBranch() {
+	if t.vm.createBranch(t.view.ViewPage().CurrentLine) {
+		t.Close()
+	}
+}
+
+func (t *ReflogView) checkout() {
+	if t.vm.checkout(t.view.ViewPage().CurrentLine) {
+		t.Close()
+	}
+}
+
+func (t *ReflogView) showCurrentContextMenu() {
+	vp := t.view.ViewPage()
+	t.showContextMenu(0, vp.CurrentLine-vp.FirstLine)
+}
+
+func (t *ReflogView) showContextMenu(x int, y int) {
+	index := t.view.ViewPage().FirstLine + y
+	t.view.SetCurrentLine(index)
+	cm := t.ui.NewMenu("")
+	cm.Add(cui.MenuItem{Text: "Show Diff", Key: "d", Action: func() { t.vm.showDiff(index) }})
+	cm.Add(cui.MenuItem{Text: "Create Branch ...", Key: "b", Action: t.createBranch})
+	cm.Add(cui.MenuItem{Text: "Checkout (Detached)", Key: "c", Action: t.checkout})
+	cm.Add(cui.MenuItem{Text: "Close", Key: "Esc", Action: t.Close})
+	cm.Show(x+3, y+2)
+}
@@ -0,0 +1,115 @@
+package console
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/michael-reichenauer/gmc/api"
+	"github.com/michael-reichenauer/gmc/utils"
+	"github.com/michael-reichenauer/gmc/utils/async"
+	"github.com/michael-reichenauer/gmc/utils/cui"
+	"github.com/michael-reichenauer/gmc/utils/linq"
+)
+
+type ReflogGetter interface {
+	GetReflog(repoID, ref string) ([]api.ReflogEntry, error)
+}
+
+// reflogHandler handles the actions for a reflog entry (implemented by repoVM)
+type reflogHandler interface {
+	showCommitDiff(commitID string)
+	showCreateBranchAtDialog(commitID string)
+	CheckoutCommit(commitID string)
+}
+
+type reflogVM struct {
+	ui      cui.UI
+	viewer  cui.Viewer
+	getter  ReflogGetter
+	handler reflogHandler
+	repoID  string
+	ref     string
+	entries []api.ReflogEntry
+}
+
+func newReflogVM(ui cui.UI, viewer cui.Viewer, getter ReflogGetter, handler reflogHandler,
+	repoID, ref string) *reflogVM {
+	return &reflogVM{ui: ui, viewer: viewer, getter: getter, handler: handler, repoID: repoID, ref: ref}
+}
+
+func (t *reflogVM) load() {
+	progress := t.ui.ShowProgress("Getting reflog ...")
+	async.RunRE(func() ([]api.ReflogEntry, error) { return t.getter.GetReflog(t.repoID, t.ref) }).
+		Then(func(entries []api.ReflogEntry) {
+			progress.Close()
+			t.entries = entries
+			t.viewer.NotifyChanged()
+		}).
+		Catch(func(err error) {
+			progress.Close()
+			t.ui.ShowErrorMessageBox("Failed to get reflog:\n%s\n%v", t.ref, err)
+		})
+}
+
+func (t *reflogVM) title() string {
+	if t.ref == "" {
+		return "Reflog: HEAD"
+	}
+	return fmt.Sprintf("Reflog: %s", t.ref)
+}
+
+func (t *reflogVM) entry(index int) (api.ReflogEntry, bool) {
+	if index < 0 || index >= len(t.entries) {
+		return api.ReflogEntry{}, false
+	}
+	return t.entries[index], true
+}
+
+func (t *reflogVM) showDiff(index int) {
+	if e, ok := t.entry(index); ok {
+		t.handler.showCommitDiff(e.CommitID)
+	}
+}
+
+func (t *reflogVM) createBranch(index int) bool {
+	e, ok := t.entry(index)
+	if ok {
+		t.handler.showCreateBranchAtDialog(e.CommitID)
+	}
+	return ok
+}
+
+func (t *reflogVM) checkout(index int) bool {
+	e, ok := t.entry(index)
+	if ok {
+		t.handler.CheckoutCommit(e.CommitID)
+	}
+	return ok
+}
+
+func (t *reflogVM) getPage(viewPage cui.ViewPage) cui.ViewText {
+	if len(t.entries) == 0 {
+		return cui.ViewText{Lines: []string{cui.Dark("No reflog entries")}, Total: 1}
+	}
+	// The last entry has the highest index and thus the longest selector
+	selectorWidth := len(t.entries[len(t.entries)-1].Selector)
+	lines := linq.Map(t.entries, func(v api.ReflogEntry) string { return toReflogText(v, selectorWidth) })
+	return cui.ViewText{Lines: pageLines(lines, viewPage), Total: len(lines)}
+}
+
+func toReflogText(e api.ReflogEntry, selectorWidth int) string {
+	var sb strings.Builder
+	sb.WriteString(cui.Dark(utils.Text(e.Selector, selectorWidth)))
+	sb.WriteString(" ")
+	sb.WriteString(cui.Dark(e.Time.Format(dateTimeColumnFormat)[2:]))
+	sb.WriteString(" ")
+	sb.WriteString(cui.Blue(e.SID))
+	sb.WriteString(" ")
+	sb.WriteString(cui.Yellow(utils.Text(e.Action, 16)))
+	sb.WriteString(" ")
+	sb.WriteString(e.Message)
+	if e.Subject != "" && e.Subject != e.Message {
+		sb.WriteString(cui.Dark(fmt.Sprintf(" (%s)", e.Subject)))
+	}
+	return sb.String()
+}
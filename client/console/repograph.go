@@ -135,6 +135,8 @@ func (t *RepoGraph) graphConnectRune(bm utils.Bitmask) rune {
 		return '┼'
 	case api.ConnectLine:
 		return '│'
+	case api.SquashMerged:
+		return '╌'
 	case api.Pass:
 		return '─'
 	case api.BBlank:
@@ -243,6 +243,10 @@ func (t *repoLayout) writeSubject(
 		sb.WriteString(cui.Dark(subject))
 		return
 	}
+	if c.IsStash {
+		sb.WriteString(cui.Cyan(subject))
+		return
+	}
 	if c.IsUncommitted {
 		if repo.Conflicts > 0 {
 			sb.WriteString(cui.Red(subject))
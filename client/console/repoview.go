@@ -188,6 +188,12 @@ func (t *RepoView) onEnterClick() {
 		return
 	}
 
+	if t.vm.repo.Commits[t.vm.currentIndex].IsPartialLogCommit {
+		// The row after the last shown commit, when the repo has more commits
+		t.vm.LoadMoreHistory()
+		return
+	}
+
 	t.ShowCommitDetails()
 }
 
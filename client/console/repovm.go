@@ -2,8 +2,10 @@ package console
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/michael-reichenauer/gmc/api"
@@ -51,6 +53,9 @@ type repoVM struct {
 	searchText        string
 	done              chan struct{}
 	repoID            string
+
+	remoteProgress     cui.Progress // The progress of a running remote command, e.g. a push
+	remoteProgressText string
 }
 
 type trace struct {
@@ -79,6 +84,15 @@ func (t *repoVM) triggerRefresh() {
 	_ = t.api.TriggerRefreshRepo(t.repoID)
 }
 
+// LoadMoreHistory loads older commits, when the repo has more commits than are shown
+func (t *repoVM) LoadMoreHistory() {
+	t.startCommand(
+		"Loading more history ...",
+		func() error { return t.api.LoadMoreHistory(t.repoID) },
+		func(err error) string { return fmt.Sprintf("Failed to load more history:\n%v", err) },
+		nil)
+}
+
 func (t *repoVM) SetSearch(text string) {
 	t.startCommand(
 		"Trigger search repo",
@@ -121,6 +135,10 @@ func (t *repoVM) monitorModelRoutine() {
 		rc := r
 		t.ui.Post(func() {
 			log.Debugf("Repo change event:")
+			if rc.RemoteProgress != nil {
+				t.showRemoteProgress(*rc.RemoteProgress)
+				return
+			}
 			if progress != nil {
 				log.Debugf("Repo change event: closing previous progress")
 				progress.Close()
@@ -223,11 +241,66 @@ func (t *repoVM) showCommitDialog() {
 	commitView.Show(message)
 }
 
+func (t *repoVM) showConflictView() {
+	conflictView := NewConflictView(t.ui, t.api, t.repoID)
+	conflictView.Show()
+}
+
 func (t *repoVM) showCreateBranchDialog() {
 	branchView := newBranchDlg(t.ui, t.CreateBranch)
 	branchView.Show()
 }
 
+func (t *repoVM) showCreateBranchAtDialog(commitID string) {
+	branchView := newBranchDlg(t.ui, func(name string) { t.CreateBranchAt(name, commitID) })
+	branchView.Show()
+}
+
+func (t *repoVM) showReflogView(ref string) {
+	reflogView := NewReflogView(t.ui, t.api, t, t.repoID, ref)
+	reflogView.Show()
+}
+
+func (t *repoVM) showStashDialog(includeUntracked bool) {
+	stashDlg := newStashDlg(t.ui, includeUntracked, t.StashPush)
+	stashDlg.Show()
+}
+
+func (t *repoVM) showCreateTagDialog(c api.Commit) {
+	tagDlg := newTagDlg(t.ui, c.SID, func(name, message string) { t.CreateTag(name, c.ID, message) })
+	tagDlg.Show()
+}
+
+func (t *repoVM) showAmendCommitDialog(c api.Commit) {
+	title := fmt.Sprintf("Amend Commit %s (incl. staged changes)", c.SID)
+	messageDlg := newMessageDlg(t.ui, title, c.Message, t.AmendCommit)
+	messageDlg.Show()
+}
+
+func (t *repoVM) showRewordCommitDialog(c api.Commit) {
+	title := fmt.Sprintf("Reword Commit %s", c.SID)
+	messageDlg := newMessageDlg(t.ui, title, c.Message, func(message string) { t.RewordCommit(c.ID, message) })
+	messageDlg.Show()
+}
+
+// showSquashCommitsDialog shows a dialog for the message of the squashed commits (oldest first),
+// prefilled with the messages of all the commits
+func (t *repoVM) showSquashCommitsDialog(ids []string) {
+	var messages []string
+	for _, id := range ids {
+		if c, ok := linq.Find(t.repo.Commits, func(v api.Commit) bool { return v.ID == id }); ok {
+			messages = append(messages, c.Message)
+		}
+	}
+
+	title := fmt.Sprintf("Squash Commits %s..%s", git.ToSid(ids[0]), git.ToSid(ids[len(ids)-1]))
+	message := strings.Join(messages, "\n\n")
+	messageDlg := newMessageDlg(t.ui, title, message, func(message string) {
+		t.SquashCommits(ids[0], ids[len(ids)-1], message)
+	})
+	messageDlg.Show()
+}
+
 func (t *repoVM) showCloneDialog() {
 	baseBath := ""
 	paths := t.configService.GetState().RecentParentFolders
@@ -249,6 +322,24 @@ func (t *repoVM) showFileDiff(path string) {
 	diffView.Show()
 }
 
+func (t *repoVM) showBlameView(path, ref string) {
+	blameView := NewBlameView(t.ui, t.api, t.repoID, path, ref, t.ShowCommit)
+	blameView.Show()
+}
+
+// ShowCommit scrolls to the commit, and shows the commit branch first if needed
+func (t *repoVM) ShowCommit(commitID, branchName string) {
+	if lo.ContainsBy(t.repo.Commits, func(v api.Commit) bool { return v.ID == commitID }) {
+		t.ScrollToBranch(branchName, commitID)
+		return
+	}
+	if branchName == "" {
+		t.ui.ShowErrorMessageBox("Commit %s is not in the repo", git.ToSid(commitID))
+		return
+	}
+	t.ShowBranch(branchName, commitID)
+}
+
 func (t *repoVM) ShowSearchView() {
 	t.repoViewer.ShowSearchView()
 }
@@ -336,6 +427,53 @@ func (t *repoVM) UncommitLastCommit() {
 		Catch(func(err error) { t.ui.ShowErrorMessageBox("Failed to uncommit:\n%s", err) })
 }
 
+func (t *repoVM) AmendCommit(message string) {
+	t.startCommand(
+		"Amending commit",
+		func() error { return t.api.AmendCommit(t.repoID, message) },
+		func(err error) string { return fmt.Sprintf("Failed to amend commit:\n%s", err) },
+		nil)
+}
+
+func (t *repoVM) RewordCommit(id, message string) {
+	t.startCommand(
+		fmt.Sprintf("Rewording commit %s", git.ToSid(id)),
+		func() error {
+			return t.api.RewordCommit(api.RewordCommitReq{RepoID: t.repoID, CommitID: id, Message: message})
+		},
+		func(err error) string { return fmt.Sprintf("Failed to reword commit:\n%s\n%s", git.ToSid(id), err) },
+		nil)
+}
+
+func (t *repoVM) DropCommit(c api.Commit) {
+	text := fmt.Sprintf("Do you want to drop commit %s:\n%s?", c.SID, c.Subject)
+	msgBox := t.ui.MessageBox("Drop Commit", cui.Yellow(text))
+	msgBox.ShowCancel = true
+	msgBox.OnOK = func() {
+		t.startCommand(
+			fmt.Sprintf("Dropping commit %s", c.SID),
+			func() error { return t.api.DropCommit(t.repoID, c.ID) },
+			func(err error) string { return fmt.Sprintf("Failed to drop commit:\n%s\n%s", c.SID, err) },
+			nil)
+	}
+	msgBox.Show()
+}
+
+func (t *repoVM) SquashCommits(fromID, toID, message string) {
+	t.startCommand(
+		fmt.Sprintf("Squashing commits %s..%s", git.ToSid(fromID), git.ToSid(toID)),
+		func() error {
+			return t.api.SquashCommits(api.SquashCommitsReq{RepoID: t.repoID, FromID: fromID, ToID: toID, Message: message})
+		},
+		func(err error) string { return fmt.Sprintf("Failed to squash commits:\n%s", err) },
+		nil)
+}
+
+func (t *repoVM) isLocalOnlyCommit(id string) bool {
+	c, ok := linq.Find(t.repo.Commits, func(v api.Commit) bool { return v.ID == id })
+	return ok && c.IsLocalOnly
+}
+
 func (t *repoVM) UndoCommit(id string) {
 	async.RunE(func() error { return t.api.UndoCommit(t.repoID, id) }).
 		Catch(func(err error) { t.ui.ShowErrorMessageBox("Failed to undo commit:\n%s:\n%s", id, err) })
@@ -351,6 +489,80 @@ func (t *repoVM) CleanWorkingFolder() {
 		Catch(func(err error) { t.ui.ShowErrorMessageBox("Failed to clean working folder:\n%s", err) })
 }
 
+func (t *repoVM) GetStashes() []api.Stash {
+	stashes, _ := t.api.GetStashes(t.repoID)
+	return stashes
+}
+
+func (t *repoVM) StashPush(message string, includeUntracked bool) {
+	t.startCommand(
+		"Stashing changes",
+		func() error {
+			return t.api.StashPush(api.StashPushReq{RepoID: t.repoID, Message: message, IncludeUntracked: includeUntracked})
+		},
+		func(err error) string { return fmt.Sprintf("Failed to stash:\n%s", err) },
+		nil)
+}
+
+func (t *repoVM) StashApply(name string) {
+	t.startCommand(
+		fmt.Sprintf("Applying stash:\n%s", name),
+		func() error { return t.api.StashApply(t.repoID, name) },
+		func(err error) string { return fmt.Sprintf("Failed to apply stash:\n%s\n%s", name, err) },
+		nil)
+}
+
+func (t *repoVM) StashPop(name string) {
+	t.startCommand(
+		fmt.Sprintf("Popping stash:\n%s", name),
+		func() error { return t.api.StashPop(t.repoID, name) },
+		func(err error) string { return fmt.Sprintf("Failed to pop stash:\n%s\n%s", name, err) },
+		nil)
+}
+
+func (t *repoVM) StashDrop(name string) {
+	text := fmt.Sprintf("Do you want to drop stash %q?", name)
+	msgBox := t.ui.MessageBox("Drop Stash", cui.Yellow(text))
+	msgBox.ShowCancel = true
+	msgBox.OnOK = func() {
+		async.RunE(func() error { return t.api.StashDrop(t.repoID, name) }).
+			Catch(func(err error) { t.ui.ShowErrorMessageBox("Failed to drop stash:\n%s\n%s", name, err) })
+	}
+	msgBox.Show()
+}
+
+func (t *repoVM) CreateTag(name, commitID, message string) {
+	t.startCommand(
+		fmt.Sprintf("Creating tag %s", name),
+		func() error {
+			return t.api.CreateTag(api.CreateTagReq{RepoID: t.repoID, Name: name, CommitID: commitID, Message: message})
+		},
+		func(err error) string { return fmt.Sprintf("Failed to create tag:\n%s\n%s", name, err) },
+		nil)
+}
+
+func (t *repoVM) PushTag(name string) {
+	t.startRemoteCommand(
+		fmt.Sprintf("Pushing tag %s", name),
+		func() error { return t.api.PushTag(t.repoID, name) },
+		func(err error) string { return fmt.Sprintf("Failed to push tag:\n%s\n%s", name, err) },
+		nil)
+}
+
+func (t *repoVM) DeleteTag(name string) {
+	text := fmt.Sprintf("Do you want to delete tag %q,\nboth local and remote?", name)
+	msgBox := t.ui.MessageBox("Delete Tag", cui.Yellow(text))
+	msgBox.ShowCancel = true
+	msgBox.OnOK = func() {
+		t.startCommand(
+			fmt.Sprintf("Deleting tag %s", name),
+			func() error { return t.api.DeleteTag(t.repoID, name) },
+			func(err error) string { return fmt.Sprintf("Failed to delete tag:\n%s\n%s", name, err) },
+			nil)
+	}
+	msgBox.Show()
+}
+
 func (t *repoVM) GetShownBranches(skipMaster bool) []api.Branch {
 	branches, _ := t.api.GetBranches(
 		api.GetBranchesReq{RepoID: t.repoID, IncludeOnlyShown: true, SkipMaster: skipMaster})
@@ -410,23 +622,59 @@ func (t *repoVM) UnsetAsParentBranch(name string) {
 	_ = t.api.UnsetAsParentBranch(api.BranchName{RepoID: t.repoID, BranchName: name})
 }
 
+func (t *repoVM) showBranchNameDialog(commitID string) {
+	branchView := newBranchNameDlg(t.ui, func(name string) { t.SetBranchName(commitID, name) })
+	branchView.Show()
+}
+
+// SetBranchName names the unnamed or ambiguous branch of the commit, or removes the name if empty
+func (t *repoVM) SetBranchName(commitID, name string) {
+	progressText := fmt.Sprintf("Naming Branch:\n%s", name)
+	if name == "" {
+		progressText = "Removing Branch Name"
+	}
+	// The name is pushed to the remote meta data, to be shared with other users
+	t.startRemoteCommand(
+		progressText,
+		func() error {
+			return t.api.SetBranchName(api.SetBranchNameReq{RepoID: t.repoID, CommitID: commitID, Name: name})
+		},
+		func(err error) string { return fmt.Sprintf("Failed to name branch:\n%s\n%s", name, err) },
+		nil)
+}
+
 func (t *repoVM) HideBranch(name string) {
 	_ = t.api.HideBranch(api.BranchName{RepoID: t.repoID, BranchName: name})
 }
 
 func (t *repoVM) SwitchToBranch(name string, displayName string) {
-	async.RunE(func() error {
-		return t.api.Checkout(t.repoID, name, displayName)
-	}).
-		Catch(func(err error) { t.ui.ShowErrorMessageBox("Failed to switch/checkout:\n%s\n%s", name, err) })
+	t.startCommand(
+		fmt.Sprintf("Switching to branch:\n%s", name),
+		func() error { return t.api.Checkout(t.repoID, name, displayName) },
+		func(err error) string { return fmt.Sprintf("Failed to switch/checkout:\n%s\n%s", name, err) },
+		nil)
 }
 
 func (t *repoVM) PushBranch(name string) {
-	p := t.ui.ShowProgress("")
+	progressText := fmt.Sprintf("Pushing branch:\n%s", name)
+	p := t.ui.ShowProgress(progressText)
+	p.SetCancel(t.cancelRemoteCommands)
+	t.setRemoteProgress(p, progressText)
 	async.RunE(func() error { return t.api.PushBranch(t.repoID, name) }).
-		Then(func(_ any) { p.Close() }).
+		Then(func(_ any) {
+			t.clearRemoteProgress(p)
+			p.Close()
+		}).
 		Catch(func(err error) {
+			t.clearRemoteProgress(p)
 			p.Close()
+			if errors.Is(err, git.ErrCanceled) {
+				return
+			}
+			if errors.Is(err, git.ErrNonFastForward) {
+				t.showPullFirst(git.DefaultRemote, name)
+				return
+			}
 			t.ui.ShowErrorMessageBox("Failed to push:\n%s\n%s", name, err)
 		})
 
@@ -437,10 +685,16 @@ func (t *repoVM) PushCurrentBranch() {
 	if !ok || !current.HasLocalOnly {
 		return
 	}
-	t.startCommand(
+	t.startRemoteCommand(
 		fmt.Sprintf("Pushing current branch:\n%s", current.Name),
 		func() error { return t.api.PushBranch(t.repoID, current.Name) },
-		func(err error) string { return fmt.Sprintf("Failed to push:\n%s\n%s", current.Name, err) },
+		func(err error) string {
+			if errors.Is(err, git.ErrNonFastForward) {
+				t.showPullFirst(git.DefaultRemote, current.Name)
+				return ""
+			}
+			return fmt.Sprintf("Failed to push:\n%s\n%s", current.Name, err)
+		},
 		nil)
 }
 
@@ -450,7 +704,7 @@ func (t *repoVM) PullCurrentBranch() {
 		return
 	}
 
-	t.startCommand(
+	t.startRemoteCommand(
 		fmt.Sprintf("Pull/Update current branch:\n%s", current.Name),
 		func() error { return t.api.PullCurrentBranch(t.repoID) },
 		func(err error) string { return fmt.Sprintf("Failed to pull/update:\n%s\n%s", current.Name, err) },
@@ -459,13 +713,63 @@ func (t *repoVM) PullCurrentBranch() {
 
 func (t *repoVM) PullBranch(name string) {
 	log.Infof("Pull branch %q", name)
-	t.startCommand(
+	t.startRemoteCommand(
 		fmt.Sprintf("Pull/Update branch:\n%s", name),
 		func() error { return t.api.PullBranch(api.BranchName{RepoID: t.repoID, BranchName: name}) },
 		func(err error) string { return fmt.Sprintf("Failed to pull/update:\n%s\n%s", name, err) },
 		nil)
 }
 
+func (t *repoVM) PushBranchTo(remote, name string) {
+	t.startRemoteCommand(
+		fmt.Sprintf("Pushing branch:\n%s to %s", name, remote),
+		func() error {
+			return t.api.PushBranchTo(api.RemoteBranchReq{RepoID: t.repoID, Remote: remote, BranchName: name})
+		},
+		func(err error) string {
+			if errors.Is(err, git.ErrNonFastForward) {
+				t.showPullFirst(remote, name)
+				return ""
+			}
+			return fmt.Sprintf("Failed to push:\n%s to %s\n%s", name, remote, err)
+		},
+		nil)
+}
+
+// showPullFirst offers to pull and then push the branch again, when a push was rejected, since
+// the remote branch has commits, which are not in the local branch
+func (t *repoVM) showPullFirst(remote, name string) {
+	text := fmt.Sprintf("Push of branch %q was rejected,\nsince %s has commits, which are not in the local branch.", name, remote)
+	msgBox := t.ui.MessageBox("Warning", cui.Yellow(text)+"\n\nPull first and then push again?")
+	msgBox.ShowCancel = true
+	msgBox.OnOK = func() {
+		t.startRemoteCommand(
+			fmt.Sprintf("Pull and push branch:\n%s", name),
+			func() error {
+				err := t.api.PullBranchFrom(api.RemoteBranchReq{RepoID: t.repoID, Remote: remote, BranchName: name})
+				if err != nil {
+					return err
+				}
+				return t.api.PushBranchTo(api.RemoteBranchReq{RepoID: t.repoID, Remote: remote, BranchName: name})
+			},
+			func(err error) string { return fmt.Sprintf("Failed to pull and push:\n%s\n%s", name, err) },
+			nil)
+	}
+	msgBox.Show()
+}
+
+func (t *repoVM) PullBranchFrom(remote, name string) {
+	t.startRemoteCommand(
+		fmt.Sprintf("Pull/Update branch:\n%s from %s", name, remote),
+		func() error {
+			return t.api.PullBranchFrom(api.RemoteBranchReq{RepoID: t.repoID, Remote: remote, BranchName: name})
+		},
+		func(err error) string {
+			return fmt.Sprintf("Failed to pull/update:\n%s from %s\n%s", name, remote, err)
+		},
+		nil)
+}
+
 func (t *repoVM) MergeFromBranch(name string) {
 	t.startCommand(
 		fmt.Sprintf("Merging to Branch:\n%s", name),
@@ -482,21 +786,180 @@ func (t *repoVM) MergeSquashFromBranch(name string) {
 		nil)
 }
 
+func (t *repoVM) CherryPick(ids []string) {
+	t.startCommand(
+		fmt.Sprintf("Cherry-picking %d commit(s) onto:\n%s", len(ids), t.repo.CurrentBranchName),
+		func() error { return t.api.CherryPick(api.CherryPickReq{RepoID: t.repoID, CommitIDs: ids}) },
+		t.cherryPickErrorText,
+		nil)
+}
+
+func (t *repoVM) CherryPickContinue() {
+	if t.repo.Conflicts > 0 {
+		t.ui.ShowErrorMessageBox("Conflicts must be resolved before continuing cherry-pick.")
+		return
+	}
+	t.startCommand(
+		"Continuing cherry-pick",
+		func() error { return t.api.CherryPickContinue(t.repoID) },
+		t.cherryPickErrorText,
+		nil)
+}
+
+func (t *repoVM) CherryPickAbort() {
+	t.startCommand(
+		"Aborting cherry-pick",
+		func() error { return t.api.CherryPickAbort(t.repoID) },
+		func(err error) string { return fmt.Sprintf("Failed to abort cherry-pick:\n%s", err) },
+		nil)
+}
+
+func (t *repoVM) RebaseBranch(name, onto string) {
+	text := fmt.Sprintf("Rebasing %s onto parent branch", name)
+	if onto != "" {
+		text = fmt.Sprintf("Rebasing %s onto:\n%s", name, onto)
+	}
+	t.startCommand(
+		text,
+		func() error {
+			return t.api.RebaseBranch(api.RebaseBranchReq{RepoID: t.repoID, BranchName: name, OntoName: onto})
+		},
+		t.rebaseErrorText,
+		nil)
+}
+
+func (t *repoVM) RebaseContinue() {
+	if t.repo.Conflicts > 0 {
+		t.ui.ShowErrorMessageBox("Conflicts must be resolved before continuing rebase.")
+		return
+	}
+	t.startCommand(
+		"Continuing rebase",
+		func() error { return t.api.RebaseContinue(t.repoID) },
+		t.rebaseErrorText,
+		nil)
+}
+
+func (t *repoVM) RebaseSkip() {
+	t.startCommand(
+		"Skipping commit in rebase",
+		func() error { return t.api.RebaseSkip(t.repoID) },
+		t.rebaseErrorText,
+		nil)
+}
+
+func (t *repoVM) RebaseAbort() {
+	t.startCommand(
+		"Aborting rebase",
+		func() error { return t.api.RebaseAbort(t.repoID) },
+		func(err error) string { return fmt.Sprintf("Failed to abort rebase:\n%s", err) },
+		nil)
+}
+
+func (t *repoVM) rebaseErrorText(err error) string {
+	if errors.Is(err, git.ErrConflicts) {
+		return "Rebase resulted in conflicts.\n\n" +
+			"Resolve the conflicts and then use Continue Rebase, Skip Commit in Rebase or Abort Rebase."
+	}
+	return fmt.Sprintf("Failed to rebase:\n%s", err)
+}
+
+func (t *repoVM) cherryPickErrorText(err error) string {
+	if errors.Is(err, git.ErrConflicts) {
+		return "Cherry-pick resulted in conflicts.\n\n" +
+			"Resolve the conflicts and then use Continue Cherry-pick, or Abort Cherry-pick."
+	}
+	return fmt.Sprintf("Failed to cherry-pick:\n%s", err)
+}
+
+// GetBranchCommitsUpTo returns the ids of the branch commits, from the first commit on the branch
+// up to and including the commit at the specified index (ordered oldest first). Merge commits
+// ends the range, since they cannot be cherry-picked.
+func (t *repoVM) GetBranchCommitsUpTo(index int) []string {
+	c := t.repo.Commits[index]
+	var ids []string
+	for i := index; i < len(t.repo.Commits); i++ {
+		cc := t.repo.Commits[i]
+		if cc.BranchIndex != c.BranchIndex || cc.IsStash || cc.IsUncommitted {
+			continue
+		}
+		if len(cc.ParentIDs) > 1 || cc.IsPartialLogCommit {
+			break
+		}
+		ids = append([]string{cc.ID}, ids...)
+		if len(cc.ParentIDs) == 0 || cc.ParentIDs[0] != t.nextBranchCommitID(i, c.BranchIndex) {
+			// Parent is not on this branch, i.e. this is the first commit on the branch
+			break
+		}
+	}
+	return ids
+}
+
+func (t *repoVM) nextBranchCommitID(index int, branchIndex int) string {
+	for i := index + 1; i < len(t.repo.Commits); i++ {
+		if t.repo.Commits[i].BranchIndex == branchIndex {
+			return t.repo.Commits[i].ID
+		}
+	}
+	return ""
+}
+
 func (t *repoVM) startCommand(
 	progressText string,
 	doFunc func() error,
 	errorFunc func(err error) string,
 	onRepoUpdatedFunc func(),
+) {
+	t.runCommand(progressText, false, doFunc, errorFunc, onRepoUpdatedFunc)
+}
+
+// startRemoteCommand starts a command, which runs remote git commands, e.g. push or pull, that
+// might take long, so the user can cancel the command in the progress
+func (t *repoVM) startRemoteCommand(
+	progressText string,
+	doFunc func() error,
+	errorFunc func(err error) string,
+	onRepoUpdatedFunc func(),
+) {
+	t.runCommand(progressText, true, doFunc, errorFunc, onRepoUpdatedFunc)
+}
+
+func (t *repoVM) runCommand(
+	progressText string,
+	isCancelable bool,
+	doFunc func() error,
+	errorFunc func(err error) string,
+	onRepoUpdatedFunc func(),
 ) {
 	progress := t.ui.ShowProgress(progressText)
+	if isCancelable {
+		progress.SetCancel(t.cancelRemoteCommands)
+		t.setRemoteProgress(progress, progressText)
+	}
 	t.onRepoUpdatedFunc = onRepoUpdatedFunc
 	go func() {
 		err := doFunc()
 		t.ui.Post(func() {
+			t.clearRemoteProgress(progress)
 			progress.Close()
+			if errors.Is(err, git.ErrCanceled) {
+				log.Infof("Canceled %q", progressText)
+				return
+			}
 			if err != nil {
 				msg := errorFunc(err)
-				if msg != "" {
+				if msg == "" {
+					return
+				}
+				retry := func(before func() error) {
+					t.runCommand(progressText, isCancelable, func() error {
+						if err := before(); err != nil {
+							return err
+						}
+						return doFunc()
+					}, errorFunc, onRepoUpdatedFunc)
+				}
+				if !t.showErrorFollowUp(msg, err, retry) {
 					t.ui.ShowErrorMessageBox(msg)
 				}
 			}
@@ -504,8 +967,92 @@ func (t *repoVM) startCommand(
 	}()
 }
 
+// showErrorFollowUp shows the error message with a follow-up for known git errors, e.g. to stash
+// local changes and retry, when a checkout was blocked by local changes. The retry function runs
+// the before function and then the failed command again. Returns false for other errors
+func (t *repoVM) showErrorFollowUp(msg string, err error, retry func(before func() error)) bool {
+	var question string
+	var before func() error
+	switch {
+	case errors.Is(err, git.ErrDirtyWorkingTree):
+		question = "Stash the local changes of tracked files and retry?\n(The changes can be applied again from the stash)"
+		before = func() error {
+			return t.api.StashPush(api.StashPushReq{RepoID: t.repoID, Message: "Stashed before retry"})
+		}
+	case errors.Is(err, git.ErrLockFile):
+		path, ok := git.LockFilePath(err)
+		if !ok {
+			return false
+		}
+		question = fmt.Sprintf("Remove stale %s and retry?\n(Only if no other git program is running)", filepath.Base(path))
+		before = func() error { return t.api.RemoveLockFile(t.repoID, path) }
+	case errors.Is(err, git.ErrNetwork):
+		question = "The remote could not be reached. Retry?"
+		before = func() error { return nil }
+	case errors.Is(err, git.ErrAuthentication):
+		t.ui.ShowErrorMessageBox("%s\n\n%s", msg,
+			"Authentication failed, please check the credentials for the remote,\ne.g. by running 'git fetch' in a terminal.")
+		return true
+	case errors.Is(err, git.ErrUnrelatedHistories):
+		t.ui.ShowErrorMessageBox("%s\n\n%s", msg,
+			"The branches have no common commit, e.g. if the remote was created with an initial commit.")
+		return true
+	default:
+		return false
+	}
+
+	msgBox := t.ui.MessageBox("Error !", cui.Red(msg)+"\n\n"+question)
+	msgBox.ShowCancel = true
+	msgBox.OnOK = func() { retry(before) }
+	msgBox.Show()
+	return true
+}
+
+// setRemoteProgress sets the progress, which shows the progress of a remote command, e.g. a push
+func (t *repoVM) setRemoteProgress(progress cui.Progress, text string) {
+	t.remoteProgress = progress
+	t.remoteProgressText = text
+}
+
+func (t *repoVM) clearRemoteProgress(progress cui.Progress) {
+	if t.remoteProgress == progress {
+		t.remoteProgress = nil
+	}
+}
+
+func (t *repoVM) showRemoteProgress(p api.RemoteProgress) {
+	if t.remoteProgress == nil {
+		return
+	}
+	t.remoteProgress.SetText(remoteProgressText(t.remoteProgressText, p))
+}
+
+// remoteProgressText returns the text with the remote progress phase and a progress bar, e.g.:
+// Receiving objects 45% (4500/10000)
+// █████████░░░░░░░░░░░ 1.20 MiB | 2.40 MiB/s
+func remoteProgressText(text string, p api.RemoteProgress) string {
+	const barWidth = 20
+	filled := p.Percent * barWidth / 100
+	if filled > barWidth {
+		filled = barWidth
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+	throughput := p.Transferred
+	if p.Throughput != "" {
+		throughput += " | " + p.Throughput
+	}
+	return fmt.Sprintf("%s\n\n%s %d%% (%d/%d)\n%s %s",
+		text, p.Phase, p.Percent, p.Current, p.Total, cui.Magenta(bar), throughput)
+}
+
+func (t *repoVM) cancelRemoteCommands() {
+	if err := t.api.CancelRemoteCommands(t.repoID); err != nil {
+		log.Warnf("Failed to cancel, %v", err)
+	}
+}
+
 func (t *repoVM) CreateBranch(name string) {
-	t.startCommand(
+	t.startRemoteCommand(
 		fmt.Sprintf("Creating Branch:\n%s", name),
 		func() error {
 			parent := t.repo.CurrentBranchName
@@ -524,16 +1071,95 @@ func (t *repoVM) CreateBranch(name string) {
 		func() { t.ShowBranch(name, "") })
 }
 
+func (t *repoVM) GetWorktrees() []api.Worktree {
+	worktrees, _ := t.api.GetWorktrees(t.repoID)
+	return worktrees
+}
+
+func (t *repoVM) GetSubmodules() []api.Submodule {
+	submodules, _ := t.api.GetSubmodules(t.repoID)
+	return submodules
+}
+
+// UpdateSubmodules initializes and updates all submodules to the commits recorded in the repo
+func (t *repoVM) UpdateSubmodules() {
+	t.startRemoteCommand(
+		"Updating submodules ...",
+		func() error { return t.api.UpdateSubmodules(t.repoID) },
+		func(err error) string { return fmt.Sprintf("Failed to update submodules:\n%s", err) },
+		nil)
+}
+
+// AddWorktree adds a worktree for the branch in a sibling folder and offers to open it
+func (t *repoVM) AddWorktree(branchName string) {
+	progress := t.ui.ShowProgress(fmt.Sprintf("Adding worktree for:\n%s", branchName))
+	async.RunRE(func() (string, error) {
+		return t.api.AddWorktree(api.AddWorktreeReq{RepoID: t.repoID, BranchName: branchName})
+	}).
+		Then(func(path string) {
+			progress.Close()
+			text := fmt.Sprintf("Added worktree for %s at:\n%s\n\nDo you want to open the worktree?", branchName, path)
+			msgBox := t.ui.MessageBox("Worktree Added", cui.Yellow(text))
+			msgBox.ShowCancel = true
+			msgBox.OnOK = func() { t.repoViewer.ShowRepo(path) }
+			msgBox.Show()
+		}).
+		Catch(func(err error) {
+			progress.Close()
+			t.ui.ShowErrorMessageBox("Failed to add worktree:\n%s\n%s", branchName, err)
+		})
+}
+
+func (t *repoVM) RemoveWorktree(w api.Worktree) {
+	text := fmt.Sprintf("Do you want to remove the worktree:\n%s?", w.Path)
+	msgBox := t.ui.MessageBox("Remove Worktree", cui.Yellow(text))
+	msgBox.ShowCancel = true
+	msgBox.OnOK = func() {
+		t.startCommand(
+			fmt.Sprintf("Removing worktree:\n%s", w.Path),
+			func() error { return t.api.RemoveWorktree(t.repoID, w.Path, false) },
+			func(err error) string { return fmt.Sprintf("Failed to remove worktree:\n%s\n%s", w.Path, err) },
+			nil)
+	}
+	msgBox.Show()
+}
+
+// CreateBranchAt creates a branch at the commit, e.g. to recover a deleted branch from the reflog
+func (t *repoVM) CreateBranchAt(name, commitID string) {
+	t.startCommand(
+		fmt.Sprintf("Creating Branch:\n%s at %s", name, git.ToSid(commitID)),
+		func() error { return t.api.CreateBranchAt(t.repoID, name, commitID) },
+		func(err error) string { return fmt.Sprintf("Failed to create branch:\n%s\n%s", name, err) },
+		func() { t.ShowBranch(name, commitID) })
+}
+
+// CheckoutCommit checks out the commit as a detached head
+func (t *repoVM) CheckoutCommit(commitID string) {
+	t.startCommand(
+		fmt.Sprintf("Checkout:\n%s", git.ToSid(commitID)),
+		func() error { return t.api.CheckoutCommit(t.repoID, commitID) },
+		func(err error) string { return fmt.Sprintf("Failed to checkout:\n%s\n%s", git.ToSid(commitID), err) },
+		func() { t.ScrollToBranch("", commitID) })
+}
+
 func (t *repoVM) Clone(uri, path string) {
-	progress := t.ui.ShowProgress(fmt.Sprintf("Cloning:\n%s\n%s", uri, path))
-	t.api.CloneRepo(uri, path).
+	progressText := fmt.Sprintf("Cloning:\n%s\n%s", uri, path)
+	progress := t.ui.ShowProgress(progressText)
+	progress.SetCancel(t.cancelRemoteCommands)
+	t.setRemoteProgress(progress, progressText)
+	t.api.CloneRepo(t.repoID, uri, path).
 		Then(func(_ any) {
+			t.clearRemoteProgress(progress)
 			progress.Close()
 			log.Infof("Cloned %s into %s", uri, path)
 			t.repoViewer.ShowRepo(path)
 		}).
 		Catch(func(err error) {
+			t.clearRemoteProgress(progress)
 			progress.Close()
+			if errors.Is(err, git.ErrCanceled) {
+				return
+			}
 			t.ui.ShowErrorMessageBox("Failed to clone:\n%q into: \n%q\n%v", uri, path, err)
 		})
 }
@@ -545,7 +1171,7 @@ func (t *repoVM) DeleteBranch(name string, isForced bool) {
 			return t.api.DeleteBranch(t.repoID, name, isForced)
 		},
 		func(err error) string {
-			if strings.Contains(err.Error(), "is not fully merged") {
+			if errors.Is(err, git.ErrNotFullyMerged) {
 				text := fmt.Sprintf("Branch %q is not fully merged.", name)
 				text2 := "\n\nDo our want to force delete the branch?"
 				msgBox := t.ui.MessageBox("Warning", cui.Yellow(text)+text2)
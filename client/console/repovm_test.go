@@ -2,11 +2,26 @@ package console
 
 import (
 	"fmt"
+	"strings"
+	"testing"
 
+	"github.com/michael-reichenauer/gmc/api"
 	"github.com/michael-reichenauer/gmc/utils/cui"
 	"github.com/michael-reichenauer/gmc/utils/log"
+	"github.com/stretchr/testify/assert"
 )
 
+func TestRemoteProgressText(t *testing.T) {
+	p := api.RemoteProgress{Phase: "Receiving objects", Percent: 45, Current: 45, Total: 100,
+		Transferred: "1.20 MiB", Throughput: "2.40 MiB/s"}
+	lines := strings.Split(remoteProgressText("Cloning:\nuri", p), "\n")
+	assert.Equal(t, 5, len(lines))
+	assert.Equal(t, "Receiving objects 45% (45/100)", lines[3])
+	assert.Equal(t, 9, strings.Count(lines[4], "█"))
+	assert.Equal(t, 11, strings.Count(lines[4], "░"))
+	assert.True(t, strings.HasSuffix(lines[4], " 1.20 MiB | 2.40 MiB/s"))
+}
+
 type progressMock struct {
 }
 
@@ -17,6 +32,12 @@ func newProgressMock() *progressMock {
 func (*progressMock) Close() {
 }
 
+func (*progressMock) SetCancel(cancel func()) {
+}
+
+func (*progressMock) SetText(text string) {
+}
+
 type viewerMock struct {
 	ui     *uiMock
 	notify func()
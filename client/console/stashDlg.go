@@ -0,0 +1,117 @@
+package console
+
+import (
+	"strings"
+
+	"github.com/jroimartin/gocui"
+	"github.com/michael-reichenauer/gmc/utils/cui"
+)
+
+type StashDlg interface {
+	Show()
+}
+
+func newStashDlg(ui cui.UI, includeUntracked bool, stash func(message string, includeUntracked bool)) StashDlg {
+	h := &stashDlg{ui: ui, includeUntracked: includeUntracked, stash: stash}
+	return h
+}
+
+type stashDlg struct {
+	ui               cui.UI
+	includeUntracked bool
+	stash            func(message string, includeUntracked bool)
+	boxView          cui.View
+	textView         cui.View
+	buttonsView      cui.View
+}
+
+func (t *stashDlg) Show() {
+	t.boxView = t.newStashView()
+	t.buttonsView = t.newButtonsView()
+	t.textView = t.newTextView()
+
+	bb, tb, bbb := t.getBounds()
+	t.boxView.Show(bb)
+	t.buttonsView.Show(bbb)
+	t.textView.Show(tb)
+
+	t.boxView.SetTop()
+	t.buttonsView.SetTop()
+	t.textView.SetTop()
+	t.textView.SetCurrentView()
+}
+
+func (t *stashDlg) newStashView() cui.View {
+	view := t.ui.NewView("\n\nMessage:")
+	view.Properties().Title = "Stash Changes"
+	if t.includeUntracked {
+		view.Properties().Title = "Stash Changes incl. Untracked"
+	}
+	view.Properties().Name = "StashDlg"
+	view.Properties().HideHorizontalScrollbar = true
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideCurrentLineMarker = true
+	return view
+}
+
+func (t *stashDlg) newButtonsView() cui.View {
+	view := t.ui.NewView(" [OK] [Cancel]")
+	view.Properties().OnMouseLeft = t.onButtonsClick
+	view.Properties().HideHorizontalScrollbar = true
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideCurrentLineMarker = true
+	return view
+}
+
+func (t *stashDlg) newTextView() cui.View {
+	view := t.ui.NewView("")
+	view.Properties().HasFrame = true
+	view.Properties().HideCurrentLineMarker = true
+	view.Properties().IsEditable = true
+	view.SetKey(gocui.KeyCtrlO, t.onOk)
+	view.SetKey(gocui.KeyEnter, t.onOk)
+	view.SetKey(gocui.KeyCtrlC, t.onCancel)
+	view.SetKey(gocui.KeyEsc, t.onCancel)
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideHorizontalScrollbar = true
+	return view
+}
+
+func (t *stashDlg) Close() {
+	t.textView.Close()
+	t.buttonsView.Close()
+	t.boxView.Close()
+}
+
+func (t *stashDlg) getBounds() (cui.BoundFunc, cui.BoundFunc, cui.BoundFunc) {
+	box := cui.CenterBounds(50, 5, 50, 5)
+	text := cui.Relative(box, func(b cui.Rect) cui.Rect {
+		return cui.Rect{X: b.X + 10, Y: b.Y + 1, W: b.W - 12, H: 1}
+	})
+	buttons := cui.Relative(box, func(b cui.Rect) cui.Rect {
+		return cui.Rect{X: b.X, Y: b.Y + b.H - 1, W: b.W, H: 1}
+	})
+	return box, text, buttons
+}
+
+func (t *stashDlg) onButtonsClick(x int, y int) {
+	if x > 0 && x < 5 {
+		t.onOk()
+	}
+	if x > 5 && x < 14 {
+		t.onCancel()
+	}
+}
+
+func (t *stashDlg) onCancel() {
+	t.Close()
+}
+
+func (t *stashDlg) onOk() {
+	// An empty message is allowed, git will then use a default "WIP on <branch>" message
+	message := t.textView.ReadLines()[0]
+	message = strings.TrimSpace(message)
+
+	t.stash(message, t.includeUntracked)
+	t.Close()
+}
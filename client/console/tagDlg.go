@@ -0,0 +1,155 @@
+package console
+
+import (
+	"strings"
+
+	"github.com/jroimartin/gocui"
+	"github.com/michael-reichenauer/gmc/utils/cui"
+)
+
+type TagDlg interface {
+	Show()
+}
+
+func newTagDlg(ui cui.UI, sid string, createTag func(name, message string)) TagDlg {
+	h := &tagDlg{ui: ui, sid: sid, createTag: createTag}
+	return h
+}
+
+type tagDlg struct {
+	ui          cui.UI
+	sid         string
+	createTag   func(name, message string)
+	boxView     cui.View
+	nameView    cui.View
+	messageView cui.View
+	buttonsView cui.View
+}
+
+func (t *tagDlg) Show() {
+	t.boxView = t.newTagView()
+	t.buttonsView = t.newButtonsView()
+	t.nameView = t.newNameView()
+	t.messageView = t.newMessageView()
+
+	bb, nb, mb, bbb := t.getBounds()
+	t.boxView.Show(bb)
+	t.buttonsView.Show(bbb)
+	t.nameView.Show(nb)
+	t.messageView.Show(mb)
+
+	t.boxView.SetTop()
+	t.buttonsView.SetTop()
+	t.nameView.SetTop()
+	t.messageView.SetTop()
+	t.nameView.SetCurrentView()
+}
+
+func (t *tagDlg) newTagView() cui.View {
+	view := t.ui.NewView("\n\nName:\n\n\nMessage:\n\n(empty message creates a lightweight tag)")
+	view.Properties().Title = "Create Tag on " + t.sid
+	view.Properties().Name = "TagDlg"
+	view.Properties().HideHorizontalScrollbar = true
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideCurrentLineMarker = true
+	return view
+}
+
+func (t *tagDlg) newButtonsView() cui.View {
+	view := t.ui.NewView(" [OK] [Cancel]")
+	view.Properties().OnMouseLeft = t.onButtonsClick
+	view.Properties().HideHorizontalScrollbar = true
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideCurrentLineMarker = true
+	return view
+}
+
+func (t *tagDlg) newNameView() cui.View {
+	view := t.ui.NewView("")
+	view.Properties().HasFrame = true
+	view.Properties().HideCurrentLineMarker = true
+	view.Properties().IsEditable = true
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideHorizontalScrollbar = true
+	view.Properties().OnMouseLeft = func(_, _ int) { t.goToName() }
+	view.SetKey(gocui.KeyCtrlO, t.onOk)
+	view.SetKey(gocui.KeyEnter, t.onOk)
+	view.SetKey(gocui.KeyCtrlC, t.onCancel)
+	view.SetKey(gocui.KeyEsc, t.onCancel)
+	view.SetKey(gocui.KeyTab, t.goToMessage)
+	view.SetKey(gocui.KeyArrowDown, t.goToMessage)
+	return view
+}
+
+func (t *tagDlg) newMessageView() cui.View {
+	view := t.ui.NewView("")
+	view.Properties().HasFrame = true
+	view.Properties().HideCurrentLineMarker = true
+	view.Properties().IsEditable = true
+	view.Properties().HideVerticalScrollbar = true
+	view.Properties().HideHorizontalScrollbar = true
+	view.Properties().OnMouseLeft = func(_, _ int) { t.goToMessage() }
+	view.SetKey(gocui.KeyCtrlO, t.onOk)
+	view.SetKey(gocui.KeyEnter, t.onOk)
+	view.SetKey(gocui.KeyCtrlC, t.onCancel)
+	view.SetKey(gocui.KeyEsc, t.onCancel)
+	view.SetKey(gocui.KeyTab, t.goToName)
+	view.SetKey(gocui.KeyArrowUp, t.goToName)
+	return view
+}
+
+func (t *tagDlg) Close() {
+	t.nameView.Close()
+	t.messageView.Close()
+	t.buttonsView.Close()
+	t.boxView.Close()
+}
+
+func (t *tagDlg) goToName() {
+	t.nameView.SetCurrentView()
+}
+
+func (t *tagDlg) goToMessage() {
+	t.messageView.SetCurrentView()
+}
+
+func (t *tagDlg) getBounds() (cui.BoundFunc, cui.BoundFunc, cui.BoundFunc, cui.BoundFunc) {
+	box := cui.CenterBounds(50, 9, 60, 9)
+	name := cui.Relative(box, func(b cui.Rect) cui.Rect {
+		return cui.Rect{X: b.X + 10, Y: b.Y + 1, W: b.W - 12, H: 1}
+	})
+	message := cui.Relative(box, func(b cui.Rect) cui.Rect {
+		return cui.Rect{X: b.X + 10, Y: b.Y + 4, W: b.W - 12, H: 1}
+	})
+	buttons := cui.Relative(box, func(b cui.Rect) cui.Rect {
+		return cui.Rect{X: b.X, Y: b.Y + b.H - 1, W: b.W, H: 1}
+	})
+	return box, name, message, buttons
+}
+
+func (t *tagDlg) onButtonsClick(x int, y int) {
+	if x > 0 && x < 5 {
+		t.onOk()
+	}
+	if x > 5 && x < 14 {
+		t.onCancel()
+	}
+}
+
+func (t *tagDlg) onCancel() {
+	t.Close()
+}
+
+func (t *tagDlg) onOk() {
+	name := strings.TrimSpace(t.nameView.ReadLines()[0])
+	name = strings.ReplaceAll(name, " ", "_")
+	message := strings.TrimSpace(t.messageView.ReadLines()[0])
+
+	if name == "" {
+		t.ui.ShowErrorMessageBox("Error", "Empty tag name is not allowed.")
+		return
+	}
+
+	t.createTag(name, message)
+	t.Close()
+}
@@ -12,11 +12,35 @@ import (
 const (
 	stateName  = ".gmcstate"
 	configName = ".gmcconfig"
+	cacheName  = ".gmccache"
 )
 
 type Config struct {
 	DisableAutoUpdate bool
 	AllowPreview      bool
+	UseObjectReader   bool            // Read log, branches and tags from the git object files, faster for large repos
+	LongLivedBranches []string        // Branch names or patterns of all repos, e.g. "develop" or "release/*"
+	Repos             []RepoConfig    // Branch config of specific repos
+	SubjectParsers    []SubjectParser // Merge subject parsers, tried before the built-in parsers
+}
+
+// SubjectParser is a named regexp, which parses the branch names of merge commit subjects, with a
+// named "from" group and an optional named "into" group, e.g. `^Merged (?P<from>\S+) to (?P<into>\S+)`.
+// NoNames parsers match merge subjects without branch names. A parser with the name of a built-in
+// parser replaces it, or disables it if the regexp is empty
+type SubjectParser struct {
+	Name    string
+	RegExp  string
+	NoNames bool
+}
+
+// RepoConfig is the branch config of a repo, e.g. with a "trunk" main branch. If no main branch is
+// set, the default branch of the origin remote, or main and master, is used. If long-lived branches
+// are set, they are used instead of the long-lived branches of all repos
+type RepoConfig struct {
+	Path              string
+	MainBranch        string
+	LongLivedBranches []string
 }
 
 type State struct {
@@ -33,6 +57,7 @@ type Repo struct {
 	Path             string
 	ShownBranches    []string
 	BranchesChildren map[string][]string
+	HistoryDepth     int // Number of commits to show, if more history of a large repo was loaded
 	// Branches      []Branch
 }
 
@@ -109,6 +134,23 @@ func (s *Service) GetConfig() Config {
 	return config
 }
 
+// GetRepoConfig returns the branch config of the repo, with the long-lived branches of all repos,
+// unless the repo has its own long-lived branches
+func (s *Service) GetRepoConfig(path string) RepoConfig {
+	config := s.GetConfig()
+	repo := RepoConfig{Path: path}
+	for _, r := range config.Repos {
+		if path == r.Path {
+			repo = r
+			break
+		}
+	}
+	if repo.LongLivedBranches == nil {
+		repo.LongLivedBranches = config.LongLivedBranches
+	}
+	return repo
+}
+
 func (s *Service) GetRepo(path string) Repo {
 	config := s.GetState()
 	log.Debugf("Config %#v", config)
@@ -177,6 +219,15 @@ func (s *Service) configPath() string {
 	return filepath.Join(dataFolder, configName)
 }
 
+// CacheFolder returns the folder of cached data, e.g. cached repos, which can be recreated
+func (s *Service) CacheFolder() string {
+	dataFolder := s.dataFolder
+	if dataFolder == "" {
+		dataFolder = utils.HomeDir()
+	}
+	return filepath.Join(dataFolder, cacheName)
+}
+
 func (s *Service) saveConfig(config Config) {
 	file, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
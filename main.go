@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	stdlog "log"
 	_ "net/http/pprof"
+	"os"
 
 	"github.com/michael-reichenauer/gmc/client/console"
 	"github.com/michael-reichenauer/gmc/common/config"
@@ -14,6 +15,7 @@ import (
 	"github.com/michael-reichenauer/gmc/server"
 	"github.com/michael-reichenauer/gmc/utils"
 	"github.com/michael-reichenauer/gmc/utils/cui"
+	"github.com/michael-reichenauer/gmc/utils/git"
 	"github.com/michael-reichenauer/gmc/utils/log"
 	"github.com/michael-reichenauer/gmc/utils/log/logger"
 	"github.com/michael-reichenauer/gmc/utils/one"
@@ -34,6 +36,10 @@ var (
 )
 
 func main() {
+	if git.IsAskpassHelper() {
+		// Started by git or ssh to get credentials, e.g. a password, from the gmc process running git
+		os.Exit(git.RunAskpassHelper(os.Args))
+	}
 	flag.Parse()
 
 	if *showVersionFlag {
@@ -28,10 +28,20 @@ type apiServer struct {
 	configService *config.Service
 	lock          sync.Mutex
 	repos         map[string]repoInfo
+	clones        map[git.Git]string // Running clones, which can be canceled, and their repo ids
+	credentials   *credentialPrompts
 }
 
 func NewApiServer(configService *config.Service) api.Api {
-	return &apiServer{configService: configService, repos: make(map[string]repoInfo)}
+	t := &apiServer{
+		configService: configService,
+		repos:         make(map[string]repoInfo),
+		clones:        make(map[git.Git]string),
+		credentials:   newCredentialPrompts(),
+	}
+	// Credential prompts of git commands, e.g. for a password, are answered by the client
+	git.SetCredentialPrompter(t.credentials.prompt)
+	return t
 }
 
 func (t *apiServer) GetRecentWorkingDirs() ([]string, error) {
@@ -80,13 +90,63 @@ func (t *apiServer) OpenRepo(path string) async.Task[string] {
 	})
 }
 
-func (t *apiServer) CloneRepo(uri, path string) async.Task[any] {
+// CloneRepo clones the repo uri into the path, where the progress is shown in the repo, which
+// started the clone, and the clone is canceled by canceling the remote commands of that repo
+func (t *apiServer) CloneRepo(repoID, uri, path string) async.Task[any] {
 	return async.RunE(func() error {
-		git := git.New("")
-		return git.Clone(uri, path)
+		g := git.New("")
+		g.SetProgressHandler(func(p git.RemoteProgress) { t.postCloneProgress(repoID, p) })
+		t.lock.Lock()
+		t.clones[g] = repoID
+		t.lock.Unlock()
+		defer func() {
+			t.lock.Lock()
+			delete(t.clones, g)
+			t.lock.Unlock()
+		}()
+
+		return g.Clone(uri, path)
 	})
 }
 
+func (t *apiServer) postCloneProgress(repoID string, p git.RemoteProgress) {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		// The repo was closed, while cloning
+		return
+	}
+	repo.PostRemoteProgress(p)
+}
+
+// CancelRemoteCommands cancels running remote git commands of the repo, e.g. a push or pull, and
+// running clones, which were started from the repo
+func (t *apiServer) CancelRemoteCommands(repoID string) error {
+	t.lock.Lock()
+	for g, id := range t.clones {
+		if id == repoID {
+			g.CancelRemoteCommands()
+		}
+	}
+	t.lock.Unlock()
+
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	repo.CancelRemoteCommands()
+	return nil
+}
+
+// GetCredentialPrompts waits for credential prompts of git commands, e.g. for a password, which
+// the client answers with AnswerCredentialPrompt
+func (t *apiServer) GetCredentialPrompts() ([]api.CredentialPrompt, error) {
+	return t.credentials.get(), nil
+}
+
+func (t *apiServer) AnswerCredentialPrompt(answer api.CredentialAnswer) error {
+	return t.credentials.answer(answer)
+}
+
 func (t *apiServer) CloseRepo(repoID string) error {
 	repo, err := t.repo(repoID)
 	if err != nil {
@@ -142,6 +202,14 @@ func (t *apiServer) TriggerRefreshRepo(repoID string) error {
 	return nil
 }
 
+func (t *apiServer) LoadMoreHistory(repoID string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.LoadMoreHistory()
+}
+
 func (t *apiServer) TriggerSearch(search api.Search) error {
 	repo, err := t.repo(search.RepoID)
 	if err != nil {
@@ -172,6 +240,62 @@ func (t *apiServer) GetFiles(args api.FilesReq) ([]string, error) {
 	return files, nil
 }
 
+func (t *apiServer) GetFileBlame(repoID, path, ref string) ([]api.BlameLine, error) {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetFileBlame(path, ref)
+}
+
+func (t *apiServer) GetReflog(repoID, ref string) ([]api.ReflogEntry, error) {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetReflog(ref)
+}
+
+func (t *apiServer) GetWorktrees(repoID string) ([]api.Worktree, error) {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetWorktrees()
+}
+
+func (t *apiServer) AddWorktree(req api.AddWorktreeReq) (string, error) {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return "", err
+	}
+	return repo.AddWorktree(req.Path, req.BranchName)
+}
+
+func (t *apiServer) RemoveWorktree(repoID, path string, isForced bool) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.RemoveWorktree(path, isForced)
+}
+
+func (t *apiServer) GetSubmodules(repoID string) ([]api.Submodule, error) {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetSubmodules()
+}
+
+func (t *apiServer) UpdateSubmodules(repoID string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.UpdateSubmodules()
+}
+
 func (t *apiServer) GetAmbiguousBranchBranches(args api.AmbiguousBranchBranchesReq) ([]api.Branch, error) {
 	repo, err := t.repo(args.RepoID)
 	if err != nil {
@@ -207,6 +331,14 @@ func (t *apiServer) Checkout(repoId, name, displayName string) error {
 	return repo.SwitchToBranch(name, displayName)
 }
 
+func (t *apiServer) CheckoutCommit(repoID, commitID string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.CheckoutCommit(commitID)
+}
+
 func (t *apiServer) PushBranch(repoID, branchName string) error {
 	repo, err := t.repo(repoID)
 	if err != nil {
@@ -231,6 +363,22 @@ func (t *apiServer) PullBranch(name api.BranchName) error {
 	return repo.PullBranch(name.BranchName)
 }
 
+func (t *apiServer) PushBranchTo(req api.RemoteBranchReq) error {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return err
+	}
+	return repo.PushBranchTo(req.Remote, req.BranchName)
+}
+
+func (t *apiServer) PullBranchFrom(req api.RemoteBranchReq) error {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return err
+	}
+	return repo.PullBranchFrom(req.Remote, req.BranchName)
+}
+
 func (t *apiServer) MergeBranch(name api.BranchName) error {
 	repo, err := t.repo(name.RepoID)
 	if err != nil {
@@ -247,6 +395,62 @@ func (t *apiServer) MergeSquashBranch(repoID, branchName string) error {
 	return repo.Git().MergeSquashBranch(branchName)
 }
 
+func (t *apiServer) CherryPick(req api.CherryPickReq) error {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return err
+	}
+	return repo.CherryPick(req.CommitIDs)
+}
+
+func (t *apiServer) RebaseBranch(req api.RebaseBranchReq) error {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return err
+	}
+	return repo.RebaseBranch(req.BranchName, req.OntoName)
+}
+
+func (t *apiServer) RebaseContinue(repoID string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.RebaseContinue()
+}
+
+func (t *apiServer) RebaseSkip(repoID string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.RebaseSkip()
+}
+
+func (t *apiServer) RebaseAbort(repoID string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.RebaseAbort()
+}
+
+func (t *apiServer) CherryPickContinue(repoID string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.CherryPickContinue()
+}
+
+func (t *apiServer) CherryPickAbort(repoID string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.CherryPickAbort()
+}
+
 func (t *apiServer) CreateBranch(name api.BranchName) error {
 	repo, err := t.repo(name.RepoID)
 	if err != nil {
@@ -255,6 +459,14 @@ func (t *apiServer) CreateBranch(name api.BranchName) error {
 	return repo.CreateBranch(name.BranchName, name.ParentName)
 }
 
+func (t *apiServer) CreateBranchAt(repoID, branchName, commitID string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.CreateBranchAt(branchName, commitID)
+}
+
 func (t *apiServer) DeleteBranch(repoID, branchName string, isForced bool) error {
 	repo, err := t.repo(repoID)
 	if err != nil {
@@ -296,9 +508,76 @@ func (t *apiServer) Commit(info api.CommitInfoReq) error {
 	if err != nil {
 		return err
 	}
+	if info.IsOnlyStaged {
+		return repo.CommitStaged(info.Message)
+	}
 	return repo.Commit(info.Message)
 }
 
+func (t *apiServer) GetUncommittedFiles(repoID string) ([]api.UncommittedFile, error) {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetUncommittedFiles()
+}
+
+func (t *apiServer) GetConflictFiles(repoID string) ([]string, error) {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetConflictFiles()
+}
+
+func (t *apiServer) GetConflictFile(repoID, path string) (api.ConflictFile, error) {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return api.ConflictFile{}, err
+	}
+	return repo.GetConflictFile(path)
+}
+
+func (t *apiServer) ResolveConflict(req api.ResolveConflictReq) error {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return err
+	}
+	return repo.ResolveConflict(req.Path, req.Choices)
+}
+
+func (t *apiServer) StageFile(repoID, path string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.StageFile(path)
+}
+
+func (t *apiServer) UnstageFile(repoID, path string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.UnstageFile(path)
+}
+
+func (t *apiServer) StageSection(req api.StageSectionReq) error {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return err
+	}
+	return repo.StageSection(req.Path, req.Section, req.FromLine, req.ToLine)
+}
+
+func (t *apiServer) UnstageSection(req api.StageSectionReq) error {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return err
+	}
+	return repo.UnstageSection(req.Path, req.Section, req.FromLine, req.ToLine)
+}
+
 func (t *apiServer) UndoCommit(repoId, id string) error {
 	repo, err := t.repo(repoId)
 	if err != nil {
@@ -323,6 +602,38 @@ func (t *apiServer) UncommitLastCommit(repoID string) error {
 	return repo.UncommitLastCommit()
 }
 
+func (t *apiServer) AmendCommit(repoID, message string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.AmendCommit(message)
+}
+
+func (t *apiServer) RewordCommit(req api.RewordCommitReq) error {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return err
+	}
+	return repo.RewordCommit(req.CommitID, req.Message)
+}
+
+func (t *apiServer) DropCommit(repoID, id string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.DropCommit(id)
+}
+
+func (t *apiServer) SquashCommits(req api.SquashCommitsReq) error {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return err
+	}
+	return repo.SquashCommits(req.FromID, req.ToID, req.Message)
+}
+
 func (t *apiServer) UndoAllUncommittedChanges(repoID string) error {
 	repo, err := t.repo(repoID)
 	if err != nil {
@@ -341,6 +652,78 @@ func (t *apiServer) CleanWorkingFolder(repoID string) error {
 	return repo.CleanWorkingFolder()
 }
 
+func (t *apiServer) CreateTag(req api.CreateTagReq) error {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return err
+	}
+	return repo.CreateTag(req.Name, req.CommitID, req.Message)
+}
+
+func (t *apiServer) DeleteTag(repoID, name string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.DeleteTag(name)
+}
+
+func (t *apiServer) PushTag(repoID, name string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.PushTag(name)
+}
+
+func (t *apiServer) GetStashes(repoID string) ([]api.Stash, error) {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return []api.Stash{}, err
+	}
+	return repo.GetStashes(), nil
+}
+
+func (t *apiServer) StashPush(req api.StashPushReq) error {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return err
+	}
+	return repo.StashPush(req.Message, req.IncludeUntracked)
+}
+
+func (t *apiServer) RemoveLockFile(repoID, path string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.RemoveLockFile(path)
+}
+
+func (t *apiServer) StashApply(repoID, name string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.StashApply(name)
+}
+
+func (t *apiServer) StashPop(repoID, name string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.StashPop(name)
+}
+
+func (t *apiServer) StashDrop(repoID, name string) error {
+	repo, err := t.repo(repoID)
+	if err != nil {
+		return err
+	}
+	return repo.StashDrop(name)
+}
+
 func (t *apiServer) SetAsParentBranch(name api.SetParentReq) error {
 	repo, err := t.repo(name.RepoID)
 	if err != nil {
@@ -360,6 +743,15 @@ func (t *apiServer) UnsetAsParentBranch(name api.BranchName) error {
 	return repo.UnsetAsParentBranch(name.BranchName)
 }
 
+func (t *apiServer) SetBranchName(req api.SetBranchNameReq) error {
+	repo, err := t.repo(req.RepoID)
+	if err != nil {
+		return err
+	}
+
+	return repo.SetBranchName(req.CommitID, req.Name)
+}
+
 func (t *apiServer) storeRepo(repo *viewrepo.ViewRepoService, stream observer.Stream) string {
 	t.lock.Lock()
 	defer t.lock.Unlock()
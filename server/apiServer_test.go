@@ -0,0 +1,31 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/git"
+	"github.com/stretchr/testify/assert"
+)
+
+// cancelGit is a git, which records if the remote commands were canceled
+type cancelGit struct {
+	git.Git
+	isCanceled bool
+}
+
+func (t *cancelGit) CancelRemoteCommands() {
+	t.isCanceled = true
+}
+
+func TestCancelClonesOfRepo(t *testing.T) {
+	server := &apiServer{repos: make(map[string]repoInfo), clones: make(map[git.Git]string)}
+	clone1 := &cancelGit{}
+	clone2 := &cancelGit{}
+	server.clones[clone1] = "repo1"
+	server.clones[clone2] = "repo2"
+
+	// Only the clone of the repo is canceled (the repo itself is not open in this test)
+	assert.Error(t, server.CancelRemoteCommands("repo1"))
+	assert.True(t, clone1.isCanceled)
+	assert.False(t, clone2.isCanceled)
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/michael-reichenauer/gmc/api"
+	"github.com/michael-reichenauer/gmc/utils/log"
+)
+
+const credentialPromptTimeout = 10 * time.Minute
+
+// credentialPrompts forwards credential prompts of git commands, e.g. a password for a push, to
+// the client, which polls for prompts and answers them
+type credentialPrompts struct {
+	prompts chan api.CredentialPrompt
+	lock    sync.Mutex
+	answers map[string]chan api.CredentialAnswer
+}
+
+func newCredentialPrompts() *credentialPrompts {
+	return &credentialPrompts{
+		prompts: make(chan api.CredentialPrompt),
+		answers: make(map[string]chan api.CredentialAnswer),
+	}
+}
+
+// prompt waits until the client has answered the prompt. Returns false if the prompt was canceled
+// or not answered in time
+func (t *credentialPrompts) prompt(prompt string, isSecret bool) (string, bool) {
+	id := uuid.New().String()
+	answers := make(chan api.CredentialAnswer, 1)
+	t.lock.Lock()
+	t.answers[id] = answers
+	t.lock.Unlock()
+	defer func() {
+		t.lock.Lock()
+		delete(t.answers, id)
+		t.lock.Unlock()
+	}()
+
+	timeout := time.After(credentialPromptTimeout)
+	select {
+	case t.prompts <- api.CredentialPrompt{ID: id, Prompt: prompt, IsSecret: isSecret}:
+	case <-timeout:
+		log.Warnf("Credential prompt was not shown")
+		return "", false
+	}
+
+	select {
+	case answer := <-answers:
+		return answer.Answer, !answer.IsCanceled
+	case <-timeout:
+		log.Warnf("Credential prompt was not answered")
+		return "", false
+	}
+}
+
+// get waits for prompts or timeout, in which case no prompts are returned and the client will retry
+func (t *credentialPrompts) get() []api.CredentialPrompt {
+	select {
+	case prompt := <-t.prompts:
+		return []api.CredentialPrompt{prompt}
+	case <-time.After(getChangesTimeout):
+		return []api.CredentialPrompt{}
+	}
+}
+
+// answer answers the prompt, where only the first answer is used and later answers of the same
+// prompt fail, e.g. if the client sent the answer twice
+func (t *credentialPrompts) answer(answer api.CredentialAnswer) error {
+	t.lock.Lock()
+	answers, ok := t.answers[answer.ID]
+	delete(t.answers, answer.ID)
+	t.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("no credential prompt %s", answer.ID)
+	}
+	answers <- answer // Never blocks, since the channel has room for the only answer
+	return nil
+}
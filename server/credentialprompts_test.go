@@ -0,0 +1,24 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialPromptAnsweredTwice(t *testing.T) {
+	prompts := newCredentialPrompts()
+	answered := make(chan string)
+	go func() {
+		answer, ok := prompts.prompt("Password:", true)
+		assert.True(t, ok)
+		answered <- answer
+	}()
+
+	prompt := prompts.get()[0]
+	assert.NoError(t, prompts.answer(api.CredentialAnswer{ID: prompt.ID, Answer: "secret"}))
+	// A second answer of the same prompt fails instead of blocking
+	assert.Error(t, prompts.answer(api.CredentialAnswer{ID: prompt.ID, Answer: "other"}))
+	assert.Equal(t, "secret", <-answered)
+}
@@ -2,6 +2,8 @@ package viewrepo
 
 import (
 	"github.com/michael-reichenauer/gmc/api"
+	"github.com/michael-reichenauer/gmc/server/viewrepo/augmented"
+	"github.com/michael-reichenauer/gmc/utils/cui"
 	"github.com/michael-reichenauer/gmc/utils/git"
 	"github.com/samber/lo"
 )
@@ -27,6 +29,7 @@ func toApiFileDiffs(gfd []git.FileDiff) []api.FileDiff {
 			PathBefore:   d.PathBefore,
 			PathAfter:    d.PathAfter,
 			IsRenamed:    d.IsRenamed,
+			IsSubmodule:  d.IsSubmodule,
 			DiffMode:     api.DiffMode(d.DiffMode),
 			SectionDiffs: toApiSectionDiffs(d.SectionDiffs),
 		}
@@ -49,6 +52,102 @@ func toApiSectionDiffs(gsd []git.SectionDiff) []api.SectionDiff {
 	return diffs
 }
 
+func toGitSectionDiff(sd api.SectionDiff) git.SectionDiff {
+	return git.SectionDiff{
+		ChangedIndexes: sd.ChangedIndexes,
+		LeftLine:       sd.LeftLine,
+		LeftCount:      sd.LeftCount,
+		RightLine:      sd.RightLine,
+		RightCount:     sd.RightCount,
+		LinesDiffs: lo.Map(sd.LinesDiffs, func(v api.LinesDiff, _ int) git.LinesDiff {
+			return git.LinesDiff{DiffMode: git.DiffMode(v.DiffMode), Line: v.Line}
+		}),
+	}
+}
+
+func toApiConflictFile(f git.ConflictFile) api.ConflictFile {
+	return api.ConflictFile{
+		Path:      f.Path,
+		Base:      f.Base,
+		Ours:      f.Ours,
+		Theirs:    f.Theirs,
+		HasBase:   f.HasBase,
+		HasOurs:   f.HasOurs,
+		HasTheirs: f.HasTheirs,
+		Chunks: lo.Map(f.Chunks, func(v git.ConflictChunk, _ int) api.ConflictChunk {
+			return api.ConflictChunk{
+				IsConflict: v.IsConflict,
+				Lines:      v.Lines,
+				Ours:       v.Ours,
+				Base:       v.Base,
+				Theirs:     v.Theirs,
+			}
+		}),
+	}
+}
+
+func toApiBlameLine(l git.BlameLine, branchName string, color cui.Color) api.BlameLine {
+	return api.BlameLine{
+		CommitID:    l.CommitID,
+		SID:         l.SID,
+		Author:      l.Author,
+		AuthorTime:  l.AuthorTime,
+		Subject:     l.Subject,
+		LineNumber:  l.LineNumber,
+		Text:        l.Text,
+		BranchName:  branchName,
+		BranchColor: api.Color(color),
+	}
+}
+
+func toApiReflogEntry(e git.ReflogEntry) api.ReflogEntry {
+	return api.ReflogEntry{
+		Ref:      e.Ref,
+		Index:    e.Index,
+		Selector: e.Selector,
+		CommitID: e.CommitID,
+		SID:      e.SID,
+		Time:     e.Time,
+		Action:   e.Action,
+		Message:  e.Message,
+		Subject:  e.Subject,
+	}
+}
+
+func toApiWorktree(w git.Worktree) api.Worktree {
+	return api.Worktree{
+		Path:       w.Path,
+		HeadID:     w.HeadID,
+		BranchName: w.BranchName,
+		IsMain:     w.IsMain,
+		IsCurrent:  w.IsCurrent,
+		IsDetached: w.IsDetached,
+		IsLocked:   w.IsLocked,
+		IsPrunable: w.IsPrunable,
+	}
+}
+
+func toApiSubmodule(s git.Submodule) api.Submodule {
+	return api.Submodule{
+		Path:          s.Path,
+		CommitID:      s.CommitID,
+		IsInitialized: s.IsInitialized,
+		IsChanged:     s.IsChanged,
+		HasConflicts:  s.HasConflicts,
+	}
+}
+
+func toApiRemoteProgress(p git.RemoteProgress) api.RemoteProgress {
+	return api.RemoteProgress{
+		Phase:       p.Phase,
+		Percent:     p.Percent,
+		Current:     p.Current,
+		Total:       p.Total,
+		Transferred: p.Transferred,
+		Throughput:  p.Throughput,
+	}
+}
+
 func toApiLineDiffs(gld []git.LinesDiff) []api.LinesDiff {
 	diffs := make([]api.LinesDiff, len(gld))
 	for i, d := range gld {
@@ -68,8 +167,13 @@ func toApiRepo(repo *repo) api.Repo {
 		CurrentBranchName:  repo.CurrentBranchName,
 		RepoPath:           repo.WorkingFolder,
 		UncommittedChanges: repo.UncommittedChanges,
+		StagedChanges:      repo.StagedChanges,
 		MergeMessage:       repo.MergeMessage,
+		IsCherryPicking:    repo.IsCherryPicking,
+		RebaseMessage:      repo.RebaseMessage,
 		Conflicts:          repo.Conflicts,
+		Submodules:         repo.Submodules,
+		Remotes:            repo.augmentedRepo.Remotes,
 		ConsoleGraph:       graph,
 	}
 }
@@ -119,9 +223,23 @@ func toApiCommit(c *commit) api.Commit {
 		Tags:               c.Tags,
 		IsAmbiguous:        c.IsAmbiguous,
 		IsAmbiguousTip:     c.IsAmbiguousTip,
+		IsStash:            c.IsStash,
 	}
 }
 
+func toApiStashes(stashes []augmented.Stash) []api.Stash {
+	return lo.Map(stashes, func(v augmented.Stash, _ int) api.Stash {
+		return api.Stash{
+			Id:      v.Id,
+			Sid:     v.Sid,
+			Name:    v.Name,
+			Branch:  v.Branch,
+			Message: v.Message,
+			Time:    v.Time,
+		}
+	})
+}
+
 func toApiBranch(b *branch) api.Branch {
 	return api.Branch{
 		Name:                 b.name,
@@ -129,18 +247,22 @@ func toApiBranch(b *branch) api.Branch {
 		Index:                b.index,
 		IsAmbiguousBranch:    b.isAmbiguousBranch,
 		RemoteName:           b.remoteName,
+		Remote:               b.remote,
 		LocalName:            b.localName,
 		IsRemote:             b.isRemote,
 		IsGitBranch:          b.isGitBranch,
-		IsMainBranch:         isMainBranch(b.name),
+		IsMainBranch:         b.isMainBranch,
 		TipID:                b.tipId,
 		IsCurrent:            b.isCurrent,
 		IsSetAsParent:        b.isSetAsParent,
+		IsSquashMerged:       b.isSquashMerged,
+		IsUserNamed:          b.isUserNamed,
 		HasRemoteOnly:        b.HasRemoteOnly,
 		HasLocalOnly:         b.HasLocalOnly,
 		Color:                api.Color(b.color),
 		X:                    b.x,
 		AmbiguousTipId:       b.AmbiguousTipId,
 		AmbiguousBranchNames: b.ambiguousBranchNames,
+		WorktreePath:         b.worktreePath,
 	}
 }
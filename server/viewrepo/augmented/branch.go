@@ -15,25 +15,31 @@ type Branch struct {
 	ParentBranch      *Branch
 	IsRemote          bool
 	RemoteName        string
+	Remote            string
 	LocalName         string
 	IsCurrent         bool
 	IsGitBranch       bool
+	IsMainBranch      bool // E.g. main or origin/main, see BranchPriority
 	IsAmbiguousBranch bool
 	IsNamedBranch     bool
 	IsSetAsParent     bool
+	IsUserNamed       bool // Named by a user, see MetaData.BranchNames
 	AmbiguousTipId    string
 	AmbiguousBranches []*Branch
+	WorktreePath      string // Path of the other worktree, where the branch is checked out
 }
 
 func newGitBranch(gb git.Branch) *Branch {
 	return &Branch{
-		Name:        gb.Name,
-		DisplayName: gb.DisplayName,
-		TipID:       gb.TipID,
-		IsCurrent:   gb.IsCurrent,
-		IsRemote:    gb.IsRemote,
-		RemoteName:  gb.RemoteName,
-		IsGitBranch: true,
+		Name:         gb.Name,
+		DisplayName:  gb.DisplayName,
+		TipID:        gb.TipID,
+		IsCurrent:    gb.IsCurrent,
+		IsRemote:     gb.IsRemote,
+		RemoteName:   gb.RemoteName,
+		Remote:       gb.Remote,
+		IsGitBranch:  true,
+		WorktreePath: gb.WorktreePath,
 	}
 }
 
@@ -0,0 +1,135 @@
+package augmented
+
+import (
+	"hash/fnv"
+	"strconv"
+
+	"github.com/samber/lo"
+)
+
+// branchDerivation is how the branch of a commit was determined by the rules, which is reused by
+// the next repo, if the commit has the same rule inputs, e.g. for most commits, when new commits
+// are added on top of the previous commits
+type branchDerivation struct {
+	input      uint64   // Hash of the rule inputs, see derivationInput
+	branch     string   // Name of the determined branch
+	rule       string   // The rule, which determined the branch
+	isLikely   bool     // The branch is likely, e.g. parsed from a subject
+	created    *Branch  // A branch, which was created for the commit, e.g. an ambiguous branch
+	reassigned []string // Commits (above), which were reassigned to the branch, see reassign
+}
+
+// deriveCommitBranch determines the branch of the commit by the rules and remembers how, to reuse
+// that for the next repo
+func (h *branchesService) deriveCommitBranch(
+	repo *Repo, c *Commit, input uint64, branchesChildren map[string][]string, branches map[string]*Branch,
+) (*Branch, string) {
+	branchCount := len(repo.Branches)
+	h.reassigned = nil
+	branch, rule := h.determineCommitBranch(repo, c, branchesChildren)
+
+	d := &branchDerivation{input: input, branch: branch.Name, rule: rule, isLikely: c.isLikely}
+	for _, rc := range h.reassigned {
+		d.reassigned = append(d.reassigned, rc.Id)
+	}
+	if len(repo.Branches) > branchCount {
+		// A new branch, e.g. a named, unnamed or ambiguous branch, copied before it is modified
+		created := repo.Branches[branchCount]
+		branches[created.Name] = created
+		createdCopy := *created
+		d.created = &createdCopy
+	}
+	c.derivation = d
+	return branch, rule
+}
+
+// reuseDerivation reuses the branch of the previous repo commit, if the commit has the same rule
+// inputs, i.e. the rules would determine the same branch. Returns false if not reused
+func (h *branchesService) reuseDerivation(
+	repo *Repo, c *Commit, input uint64, previous *Repo, branches map[string]*Branch,
+) (*Branch, string, bool) {
+	if previous == nil {
+		return nil, "", false
+	}
+	pc, ok := previous.TryGetCommitByID(c.Id)
+	if !ok || pc.derivation == nil || pc.derivation.input != input {
+		return nil, "", false
+	}
+	d := pc.derivation
+
+	// Ensure the branch and the reassigned commits exist, which they should for the same inputs
+	var reassigned []*Commit
+	for _, id := range d.reassigned {
+		rc, ok := repo.TryGetCommitByID(id)
+		if !ok {
+			return nil, "", false
+		}
+		reassigned = append(reassigned, rc)
+	}
+	branch, ok := branches[d.branch]
+	if !ok && d.created == nil {
+		return nil, "", false
+	}
+
+	// Same changes as when the branch was determined by the rules
+	if d.created != nil {
+		if d.created.IsAmbiguousBranch {
+			branch = repo.addAmbiguousBranch(c)
+		} else {
+			created := *d.created
+			branch = &created
+			repo.Branches = append(repo.Branches, branch)
+		}
+		branches[branch.Name] = branch
+	}
+	for _, rc := range reassigned {
+		h.reassign(rc, branch)
+	}
+	c.isLikely = d.isLikely
+	c.derivation = d
+	return branch, d.rule, true
+}
+
+// derivationInput returns a hash of the inputs, which the rules use to determine the commit branch,
+// i.e. the commit, the possible branches, the children, the merge children and the parsed branch
+// name. The meta data, branch priority and subject parsers are the same for the previous repo
+func (h *branchesService) derivationInput(c *Commit) uint64 {
+	hash := fnv.New64a()
+	write := func(values ...string) {
+		for _, v := range values {
+			_, _ = hash.Write([]byte(v))
+			_, _ = hash.Write([]byte{0})
+		}
+	}
+
+	name := h.branchNames.branchName(c.Id)
+	write(c.Id, name)
+	if c.FirstParent != nil {
+		write("parent", c.FirstParent.Id)
+	}
+
+	// Commits above on ambiguous branches, which a parsed branch name might reassign
+	ambiguous := []string{c.Id}
+	for current := c; len(current.Children) == 1 && current.Children[0].Branch.IsAmbiguousBranch; current = current.Children[0] {
+		ambiguous = append(ambiguous, current.Children[0].Id)
+	}
+	write(ambiguous...)
+
+	for _, b := range c.Branches {
+		write("branch", b.Name, b.DisplayName, b.RemoteName, strconv.FormatBool(b.IsRemote))
+		if name != "" {
+			// The tip and the bottom (lowest commit so far) are used, if a branch name was parsed
+			write(b.BottomID, strconv.Itoa(lo.IndexOf(ambiguous, b.TipID)))
+		}
+	}
+	for _, cc := range c.Children {
+		write("child", cc.Id, cc.Branch.Name, strconv.FormatBool(cc.isLikely))
+		for _, b := range cc.Branches {
+			write(b.Name)
+		}
+	}
+	for _, mc := range c.MergeChildren {
+		write("merge child", mc.Id, mc.Branch.Name, mc.Branch.DisplayName)
+	}
+	return hash.Sum64()
+}
@@ -24,34 +24,68 @@ import (
 // - Since the merge commit often has info of target and source branch names, the 2 parents commits
 //   branches can also be reasonable determined.
 // - Multiple branch tips can point to the same commit, that would make those
-// - Main or master (legacy) branch are treated specially since these are considered backbone of the graph
+// - The main branch (e.g. main, master or a configured name like trunk) and long-lived branches
+//   (e.g. develop and release/*) are treated specially since these are considered backbone of the graph
 // - Pull merge: Normally in git when a user does a pull merge, the remote branch is merged into
 //   the local branch, where the remote branch tip will be the second parent of the local commit.
 //   I think this is a bit strange and would confuse the branch graph. So if these commit messages
 //   are detected, the order of the parents are switch so it looks like the local branch was
 //   merged into the remote branch and not the reverse in normal git logs.
 
-// Default branch priority determines parent child branch relations.
-var DefaultBranchPriority = []string{"origin/main", "main", "origin/master", "master"}
+// The rules, which determine the branch of a commit, see determineCommitBranch
+const (
+	ruleUserBranchName               = "branch named by a user"
+	ruleOnlyOneBranch                = "only one possible branch"
+	ruleLocalRemoteBranch            = "local and remote branch, prefer remote"
+	ruleParentChildSetBranch         = "branch is set as parent of the other branches"
+	ruleChildrenPriorityBranch       = "child branch is set as parent of the other child branches"
+	ruleSameChildrenBranches         = "two children on the same branch"
+	ruleMergedDeletedRemoteBranchTip = "tip of a deleted branch with one merge child"
+	ruleMergedDeletedBranchTip       = "tip of a deleted branch"
+	ruleOneChildInDeletedBranch      = "only child is on a deleted branch"
+	ruleOneChildWithLikelyBranch     = "only child has a likely branch"
+	rulePriorityBranch               = "main or long-lived branch"
+	ruleBranchNameInSubject          = "branch name in commit or merge subject"
+	ruleOnlyOneChild                 = "same branches as the only child"
+	ruleChildAmbiguousBranch         = "child is on an ambiguous branch"
+	ruleAmbiguousBranch              = "ambiguous, no rule could determine the branch"
+	ruleAmbiguousOldestChild         = "ambiguous, branch of the oldest child"
+)
 
 type branchesService struct {
 	branchNames *branchNameParser
+	reassigned  []*Commit // Commits, which were reassigned while determining the current commit
+	derived     int       // Number of commits, which branches were determined by the rules
 }
 
 func newBranchesService() *branchesService {
 	return &branchesService{branchNames: newBranchNameParser()}
 }
 
-func (h *branchesService) setBranchForAllCommits(repo *Repo) {
+// setBranchForAllCommits determines the branches of all commits and the branch hierarchy. Commits,
+// which have the same rule inputs as in the previous repo (if any), reuse the previous commit
+// branch, i.e. only e.g. new commits, their affected ancestors and commits of changed branch tips
+// are determined by the rules. The previous repo must have the same meta data, branch priority
+// and merge subject parsers
+func (h *branchesService) setBranchForAllCommits(repo *Repo, previous *Repo) {
 	branchesChildren := repo.MetaData.BranchesChildren
 
+	h.branchNames.resetBranchNames()
+	h.setMainBranches(repo)
 	h.setGitBranchTips(repo)
 	h.setCommitBranchesAndChildren(repo)
-	h.determineCommitBranches(repo, branchesChildren)
+	h.determineCommitBranches(repo, branchesChildren, previous)
 	h.mergeAmbiguousBranches(repo)
 	h.determineBranchHierarchy(repo, branchesChildren)
 }
 
+// setMainBranches marks the main branches, e.g. main and origin/main, of the branch priority
+func (h *branchesService) setMainBranches(repo *Repo) {
+	for _, b := range repo.Branches {
+		b.IsMainBranch = repo.BranchPriority.IsMain(b.Name)
+	}
+}
+
 // setGitBranchTips iterates all branches and
 //   - add each branch to the branch tip commit branches,
 //     Thus all branch tip commit knows the list of branches it belongs to,
@@ -120,85 +154,121 @@ func (h *branchesService) setCommitBranchesAndChildren(repo *Repo) {
 }
 
 // determineCommitBranches iterates all commits and for each commit
-// - Determine the branch for the commit
+// - Determine the branch for the commit, or reuse the branch of the previous repo commit
 // - If the commit has a prioritized branch, the first parent inherits that as well
 // - Adjust the commit branch bottom id to know/forward last known commit of a branch
 func (h *branchesService) determineCommitBranches(
 	repo *Repo,
 	branchesChildren map[string][]string,
+	previous *Repo,
 ) {
+	branches := make(map[string]*Branch)
+	for _, b := range repo.Branches {
+		branches[b.Name] = b
+	}
+
+	h.derived = 0
 	for _, c := range repo.Commits {
-		branch := h.determineCommitBranch(repo, c, branchesChildren)
+		if len(c.Branches) > 1 {
+			// Remember the candidate branches, to explain the branch rule
+			c.BranchCandidates = linq.Map(c.Branches, func(v *Branch) string { return v.Name })
+		}
+
+		input := h.derivationInput(c)
+		branch, rule, ok := h.reuseDerivation(repo, c, input, previous, branches)
+		if !ok {
+			branch, rule = h.deriveCommitBranch(repo, c, input, branchesChildren, branches)
+			h.derived++
+		}
 		c.Branch = branch
+		c.BranchRule = rule
 		c.addBranch(c.Branch)
 
-		h.setMasterBackbone(c)
+		h.setMasterBackbone(repo, c)
 		c.Branch.BottomID = c.Id
 	}
 }
 
 // determineCommitBranch determines the branch of a commit by analyzing the most likely
-// candidate branch for a commit.
+// candidate branch for a commit. Returns the branch and the rule, which determined the branch
 func (h *branchesService) determineCommitBranch(
 	repo *Repo, c *Commit, branchesChildren map[string][]string,
-) *Branch {
+) (*Branch, string) {
 	// At this point, if a commit c has possible branches in c.Branches[], they will all be
 	// live git branches. However, on return the c.Branches[] may contain deleted or ambiguous
 	// branches as well
 
-	if branch := h.hasOnlyOneBranch(c); branch != nil {
+	if branch := h.hasUserBranchName(repo, c); branch != nil {
+		// The commit is the tip of an unnamed or ambiguous branch, which a user has named
+		return branch, ruleUserBranchName
+	} else if branch := h.hasOnlyOneBranch(c); branch != nil {
 		// Commit only has one branch, it must have been an actual branch tip originally, use that
-		return branch
+		return branch, ruleOnlyOneBranch
 	} else if branch := h.isLocalRemoteBranch(c); branch != nil {
 		// Commit has only local and its remote branch, prefer remote remote branch
-		return branch
+		return branch, ruleLocalRemoteBranch
 	} else if branch := h.hasParentChildSetBranch(c, branchesChildren); branch != nil {
 		// The commit has several possible branches, and one is set as parent of the others by the user
-		return branch
+		return branch, ruleParentChildSetBranch
 	} else if branch := h.hasChildrenPriorityBranch(c, branchesChildren); branch != nil {
 		// The commit has several possible branches, and one of the children's branches is set as the
 		// the parent branch of the other children's branches
-		return branch
+		return branch, ruleChildrenPriorityBranch
 	} else if branch := h.isSameChildrenBranches(c); branch != nil {
 		// Commit has no branch but has 2 children with same branch
-		return branch
+		return branch, ruleSameChildrenBranches
 	} else if branch := h.isMergedDeletedRemoteBranchTip(repo, c); branch != nil {
 		// Commit has no branch and no children, but has a merge child, the commit is a tip
 		// of a deleted branch. It might be a deleted remote branch. Lets try determine branch name
 		// based on merge child's subject or use a generic branch name based on commit id
-		return branch
+		return branch, ruleMergedDeletedRemoteBranchTip
 	} else if branch := h.isMergedDeletedBranchTip(repo, c); branch != nil {
 		// Commit has no branch and no children, but has a merge child, the commit is a tip
 		// of a deleted remote branch, lets try determine branch name based on merge child's
 		// subject or use a generic branch name based on commit id
-		return branch
+		return branch, ruleMergedDeletedBranchTip
 	} else if branch := h.hasOneChildInDeletedBranch(c); branch != nil {
 		// Commit is middle commit in a deleted branch with only one child above, use same branch
-		return branch
+		return branch, ruleOneChildInDeletedBranch
 	} else if branch := h.hasOneChildWithLikelyBranch(c); branch != nil {
 		// Commit multiple possible git branches but has one child, which has a likely known branch, use same branch
-		return branch
-	} else if branch := h.hasMainBranch(c); branch != nil {
-		// Commit, has several possible branches, and one is in the priority list, e.g. main, master, ...
-		return branch
+		return branch, ruleOneChildWithLikelyBranch
+	} else if branch := h.hasPriorityBranch(repo, c); branch != nil {
+		// Commit, has several possible branches, and one is in the priority list, e.g. main, develop, ...
+		return branch, rulePriorityBranch
 	} else if branch := h.hasBranchNameInSubject(repo, c); branch != nil {
 		// A branch name could be parsed form the commit subject or a child subject.
 		// The commit will be set to that branch and also if above (first child) commits have
 		// ambiguous branches, the will be reset to same branch as well. This will 'repair' branch
 		// when a parsable commit subjects are encountered.
-		return branch
+		return branch, ruleBranchNameInSubject
 	} else if branch := h.hasOnlyOneChild(c); branch != nil {
 		// Commit has one child commit and not merge commits, reuse that child commit branch
-		return branch
+		return branch, ruleOnlyOneChild
 	} else if branch := h.isChildAmbiguousBranch(c); branch != nil {
 		// one of the commit children is a ambiguous branch, reuse same ambiguous branch
-		return branch
+		return branch, ruleChildAmbiguousBranch
 	}
 
 	// Commit, has several possible branches, and we could not determine which branch is best,
 	// create a new ambiguous branch. Later commits may fix this by parsing subjects of later
 	// commits, or the user has to manually set the branch.
-	return repo.addAmbiguousBranch(c)
+	return repo.addAmbiguousBranch(c), ruleAmbiguousBranch
+}
+
+func (h *branchesService) hasUserBranchName(repo *Repo, c *Commit) *Branch {
+	name, ok := repo.MetaData.BranchNames[c.Id]
+	if !ok {
+		return nil
+	}
+
+	// A named branch, which the first parent commits (below) will continue, like a deleted branch.
+	// Names of live git branches are not allowed, but a branch might have been created later with
+	// the same name, which is not used, so the user name can still be removed
+	c.isLikely = true
+	branch := repo.addNamedBranch(c, name)
+	branch.IsUserNamed = true
+	return branch
 }
 
 func (h *branchesService) hasOnlyOneBranch(c *Commit) *Branch {
@@ -352,18 +422,17 @@ func (h *branchesService) hasOneChildWithLikelyBranch(c *Commit) *Branch {
 	return nil
 }
 
-func (h *branchesService) hasMainBranch(c *Commit) *Branch {
-	if len(c.Branches) < 1 {
-		return nil
-	}
-	for _, bp := range DefaultBranchPriority {
-		for _, cb := range c.Branches {
-			if bp == cb.Name {
-				return cb
-			}
+func (h *branchesService) hasPriorityBranch(repo *Repo, c *Commit) *Branch {
+	var branch *Branch
+	branchIndex := -1
+	for _, cb := range c.Branches {
+		index := repo.BranchPriority.Index(cb.Name)
+		if index != -1 && (branchIndex == -1 || index < branchIndex) {
+			branch = cb
+			branchIndex = index
 		}
 	}
-	return nil
+	return branch
 }
 
 func (h *branchesService) hasBranchNameInSubject(repo *Repo, c *Commit) *Branch {
@@ -375,9 +444,7 @@ func (h *branchesService) hasBranchNameInSubject(repo *Repo, c *Commit) *Branch
 		branch := h.tryGetBranchFromName(c, name)
 		if branch != nil && branch.TipID == c.Id {
 			// The commit is branch tip, we should not find higher/previous commit up, since tip would move up
-			c.Branch = branch
-			c.addBranch(branch)
-			c.isLikely = true
+			h.reassign(c, branch)
 			return branch
 		}
 		if branch != nil && branch.BottomID != "" {
@@ -399,9 +466,7 @@ func (h *branchesService) hasBranchNameInSubject(repo *Repo, c *Commit) *Branch
 
 		if branch != nil {
 			for ; current != nil && current != c.FirstParent; current = current.FirstParent {
-				current.Branch = branch
-				current.addBranch(branch)
-				current.isLikely = true
+				h.reassign(current, branch)
 			}
 
 			return branch
@@ -411,6 +476,16 @@ func (h *branchesService) hasBranchNameInSubject(repo *Repo, c *Commit) *Branch
 	return nil
 }
 
+// reassign sets the likely branch of the commit, where the commit might be above the current
+// commit, and remembers the commit to reassign it as well, if the current commit branch is reused
+func (h *branchesService) reassign(c *Commit, branch *Branch) {
+	c.Branch = branch
+	c.BranchRule = ruleBranchNameInSubject
+	c.addBranch(branch)
+	c.isLikely = true
+	h.reassigned = append(h.reassigned, c)
+}
+
 func (h *branchesService) hasOnlyOneChild(c *Commit) *Branch {
 	// This does not work, since a commit could have multiple branch tips
 	if len(c.Children) == 1 && len(c.MergeChildren) == 0 {
@@ -472,13 +547,13 @@ func (h *branchesService) tryGetBranchFromName(c *Commit, name string) *Branch {
 
 // setMasterBackbone, if the commit branch is one of the prioritized branches,
 // that branch is added to the parent commit branches as well (inherited)
-func (h *branchesService) setMasterBackbone(c *Commit) {
+func (h *branchesService) setMasterBackbone(repo *Repo, c *Commit) {
 	if c.FirstParent == nil {
 		// Reached the end of the repository
 		return
 	}
 
-	if lo.Contains(DefaultBranchPriority, c.Branch.Name) {
+	if repo.BranchPriority.IsPrioritized(c.Branch.Name) {
 		// main and develop are special and will make a "backbone" for other branches to depend on
 		c.FirstParent.addBranch(c.Branch)
 	}
@@ -522,6 +597,7 @@ func (h *branchesService) mergeAmbiguousBranches(repo *Repo) {
 				childBranches = append(childBranches, c.Branch)
 			}
 			c.Branch = oldestChild.Branch
+			c.BranchRule = ruleAmbiguousOldestChild
 			c.Branch.AmbiguousTipId = c.Id
 			c.Branch.AmbiguousBranches = childBranches
 			c.Branch.BottomID = c.Id
@@ -531,6 +607,7 @@ func (h *branchesService) mergeAmbiguousBranches(repo *Repo) {
 		// Set the branch of the rest of the ambiguous commits to same as the tip
 		for c := ambiguousSecond; c != nil && c.Id != otherId; c = c.FirstParent {
 			c.Branch = ambiguousTip.Branch
+			c.BranchRule = ruleAmbiguousOldestChild
 			c.Branch.BottomID = c.Id
 			c.IsAmbiguous = true
 		}
@@ -3,37 +3,56 @@ package augmented
 import (
 	"regexp"
 	"strings"
+
+	"github.com/michael-reichenauer/gmc/utils/git"
 )
 
+const gitSubjectParser = "git"
+
 var (
-	prefixes   = []string{"refs/remotes/origin/", "remotes/origin/", "origin/"}
-	nameRegExp = regexp.MustCompile( // parse subject like e.g. "Merge branch 'develop' into main"
+	remotePrefixes = []string{"refs/remotes/", "remotes/"} // followed by <remote>/
+	nameRegExp     = regexp.MustCompile(                   // parse subject like e.g. "Merge branch 'develop' into main"
 		`[Mm]erged?` + //                                     'Merge' or 'merged' word
-			`(\s+remote-tracking)?` + //                      'remote-tracking' optional word when merging remote branches
+			`(?P<remote>\s+remote-tracking)?` + //            'remote-tracking' optional word when merging remote branches
 			`(\s+(pull request #[0-9]+ from|from branch|branch|commit|from))?` + //     'branch'|'commit'|'from' word
 			`\s+'?(?P<from>[0-9A-Za-z_/-]+)'?` + //           the <from> branch name
 			`(?P<direction>\s+of\s+[^\s]+)?` + //             the optional 'of repo url'
 			`(\s+(into|to)\s+(?P<into>[0-9A-Za-z_/-]+))?`) // the <into> branch name
-	from, into, direction = nameRegExpIndexes()
+	from, into, direction, remote = nameRegExpIndexes()
 )
 
 type fromInto struct {
-	from string
-	into string
+	from   string
+	into   string
+	parser string // Name of the parser, which matched the subject
 }
 
 type branchNameParser struct {
+	parsers       []subjectParser // Tried before the git merge subject regexp
 	parsedCommits map[string]fromInto
 	branchNames   map[string]string
 }
 
 func newBranchNameParser() *branchNameParser {
+	parsers, _ := newSubjectParsers(nil)
 	return &branchNameParser{
+		parsers:       parsers,
 		parsedCommits: make(map[string]fromInto),
 		branchNames:   make(map[string]string),
 	}
 }
 
+// setSubjectParsers sets the parsers, e.g. with user defined parsers, and clears parsed subjects
+func (h *branchNameParser) setSubjectParsers(parsers []subjectParser) {
+	h.parsers = parsers
+	h.parsedCommits = make(map[string]fromInto)
+}
+
+// resetBranchNames clears the branch names of a previous repo, but keeps parsed subjects
+func (h *branchNameParser) resetBranchNames() {
+	h.branchNames = make(map[string]string)
+}
+
 func (h *branchNameParser) isPullMerge(c *Commit) bool {
 	fi := h.parseCommit(c)
 	return h.isPullMergeCommit(fi)
@@ -43,16 +62,16 @@ func (h *branchNameParser) parseCommit(c *Commit) fromInto {
 	if len(c.ParentIDs) != 2 {
 		return fromInto{}
 	}
-	if fi, ok := h.parsedCommits[c.Id]; ok {
-		// Already parsed this commit
-		return fi
+	fi, ok := h.parsedCommits[c.Id]
+	if !ok {
+		fi = h.parseMergeBranchNames(c.Subject)
+		h.parsedCommits[c.Id] = fi
 	}
 
-	fi := h.parseMergeBranchNames(c.Subject)
-
 	// set the branch name of the commit and merge parent.
-	// could actually be several names, but lets ignore that
-	h.parsedCommits[c.Id] = fi
+	// could actually be several names, but lets ignore that.
+	// Names are set also if parsed in a previous repo, since a later (lower) merge commit in
+	// the repo overwrites the name of a shared merge parent
 	h.branchNames[c.Id] = fi.into
 	if !h.isPullMergeCommit(fi) {
 		h.branchNames[c.ParentIDs[1]] = fi.from
@@ -72,24 +91,41 @@ func (h *branchNameParser) branchName(id string) string {
 	return h.branchNames[id]
 }
 
+// parseMergeBranchNames parses the subject with the first matching subject parser, e.g. of a
+// hosting provider, or as a git merge subject
 func (h *branchNameParser) parseMergeBranchNames(subject string) fromInto {
 	subject = strings.TrimSpace(subject)
+	for _, p := range h.parsers {
+		if fi, ok := p.parse(subject); ok {
+			fi.from = h.trimBranchName(strings.TrimPrefix(fi.from, "refs/heads/"))
+			fi.into = h.trimBranchName(strings.TrimPrefix(fi.into, "refs/heads/"))
+			return fi
+		}
+	}
+
+	return h.parseGitMergeBranchNames(subject)
+}
+
+func (h *branchNameParser) parseGitMergeBranchNames(subject string) fromInto {
 	matches := nameRegExp.FindAllStringSubmatch(subject, -1)
 	if len(matches) == 0 {
 		return fromInto{}
 	}
 	match := matches[0]
+	isRemoteTracking := match[remote] != ""
 
 	if h.isMatchPullMerge(match) {
 		// Subject is a pull merge same branch from remote repo (same remote source and target branch)
 		return fromInto{
-			from: h.trimBranchName(match[from]),
-			into: h.trimBranchName(match[from])}
+			from:   h.trimRemoteBranchName(match[from], isRemoteTracking),
+			into:   h.trimRemoteBranchName(match[from], isRemoteTracking),
+			parser: gitSubjectParser}
 	}
 
 	return fromInto{
-		from: h.trimBranchName(match[from]),
-		into: h.trimBranchName(match[into])}
+		from:   h.trimRemoteBranchName(match[from], isRemoteTracking),
+		into:   h.trimBranchName(match[into]),
+		parser: gitSubjectParser}
 }
 
 func (h *branchNameParser) isMatchPullMerge(match []string) bool {
@@ -99,24 +135,45 @@ func (h *branchNameParser) isMatchPullMerge(match []string) bool {
 	}
 
 	if match[from] != "" && match[into] != "" &&
-		h.trimBranchName(match[from]) == h.trimBranchName(match[into]) {
+		h.trimRemoteBranchName(match[from], match[remote] != "") == h.trimBranchName(match[into]) {
 		return true
 	}
 
 	return false
 }
 
+// trimRemoteBranchName trims the remote prefix of a remote-tracking branch name, like e.g.
+// 'upstream/main', where the first part is the remote name (which might not be origin)
+func (h *branchNameParser) trimRemoteBranchName(name string, isRemoteTracking bool) string {
+	trimmed := h.trimBranchName(name)
+	if trimmed != name || !isRemoteTracking {
+		return trimmed
+	}
+
+	if i := strings.Index(name, "/"); i != -1 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// trimBranchName trims remote prefixes like e.g. 'refs/remotes/upstream/' or 'origin/'
 func (h *branchNameParser) trimBranchName(name string) string {
-	for _, prefix := range prefixes {
+	for _, prefix := range remotePrefixes {
 		if strings.HasPrefix(name, prefix) {
-			return name[len(prefix):]
+			name = name[len(prefix):]
+			if i := strings.Index(name, "/"); i != -1 {
+				// Trim the remote name as well
+				return name[i+1:]
+			}
+			return name
 		}
 	}
-	return name
+
+	return strings.TrimPrefix(name, git.DefaultRemote+"/")
 }
 
 // nameRegExpIndexes returns the named group indexes to be used in parse
-func nameRegExpIndexes() (fromIndex, intoIndex, directionIndex int) {
+func nameRegExpIndexes() (fromIndex, intoIndex, directionIndex, remoteIndex int) {
 	n1 := nameRegExp.SubexpNames()
 	for i, v := range n1 {
 		if v == "from" {
@@ -128,6 +185,9 @@ func nameRegExpIndexes() (fromIndex, intoIndex, directionIndex int) {
 		if v == "direction" {
 			directionIndex = i
 		}
+		if v == "remote" {
+			remoteIndex = i
+		}
 	}
 	return
 }
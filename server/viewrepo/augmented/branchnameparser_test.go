@@ -17,58 +17,109 @@ func TestParseBranchNames(t *testing.T) {
 func TestParseSubject(t *testing.T) {
 	h := newBranchNameParser()
 
-	fi := h.parseMergeBranchNames("Merge branch 'develop' into master")
-	assert.Equal(t, "develop", fi.from)
-	assert.Equal(t, "master", fi.into)
-
-	fi = h.parseMergeBranchNames("Merge from branch 'develop' into master")
-	assert.Equal(t, "develop", fi.from)
-	assert.Equal(t, "master", fi.into)
-
-	fi = h.parseMergeBranchNames("Merged branch 'develop' into master")
-	assert.Equal(t, "develop", fi.from)
-	assert.Equal(t, "master", fi.into)
-
-	fi = h.parseMergeBranchNames("Merged commit 'develop' into master")
-	assert.Equal(t, "develop", fi.from)
-	assert.Equal(t, "master", fi.into)
-
-	fi = h.parseMergeBranchNames("Merged 'develop' into master")
-	assert.Equal(t, "develop", fi.from)
-	assert.Equal(t, "master", fi.into)
-
-	fi = h.parseMergeBranchNames("Merge remote-tracking branch 'refs/remotes/origin/branches/fetch' into branches/fetch")
-	assert.Equal(t, "branches/fetch", fi.from)
-	assert.Equal(t, "branches/fetch", fi.into)
-
-	fi = h.parseMergeBranchNames("Merge branch 'develop'")
-	assert.Equal(t, "develop", fi.from)
-	assert.Equal(t, "", fi.into)
-
-	fi = h.parseMergeBranchNames("Merge branch develop")
-	assert.Equal(t, "develop", fi.from)
-	assert.Equal(t, "", fi.into)
-
-	// Optional source repo
-	fi = h.parseMergeBranchNames("Merge branch 'develop' of https://github.com/michael-reichenauer/gmc into branches/fetch")
-	assert.Equal(t, "develop", fi.from)
-	assert.Equal(t, "branches/fetch", fi.into)
-
-	// Pull merge without target
-	fi = h.parseMergeBranchNames("Merge branch 'branches/fetch' of https://github.com/michael-reichenauer/gmc")
-	assert.Equal(t, "branches/fetch", fi.from)
-	assert.Equal(t, "branches/fetch", fi.into)
-
-	// Pull merge with target
-	fi = h.parseMergeBranchNames("Merge branch 'branches/fetch' of https://github.com/michael-reichenauer/gmc into branches/fetch")
-	assert.Equal(t, "branches/fetch", fi.from)
-	assert.Equal(t, "branches/fetch", fi.into)
+	cases := []struct {
+		subject string
+		from    string
+		into    string
+		parser  string
+	}{
+		{"Merge branch 'develop' into master", "develop", "master", "git"},
+		{"Merge from branch 'develop' into master", "develop", "master", "git"},
+		{"Merged branch 'develop' into master", "develop", "master", "git"},
+		{"Merged commit 'develop' into master", "develop", "master", "git"},
+		{"Merged 'develop' into master", "develop", "master", "git"},
+		{"Merge remote-tracking branch 'refs/remotes/origin/branches/fetch' into branches/fetch", "branches/fetch", "branches/fetch", "git"},
+		{"Merge branch 'develop'", "develop", "", "git"},
+		{"Merge branch develop", "develop", "", "git"},
+		// Optional source repo
+		{"Merge branch 'develop' of https://github.com/michael-reichenauer/gmc into branches/fetch", "develop", "branches/fetch", "git"},
+		// Pull merge without target
+		{"Merge branch 'branches/fetch' of https://github.com/michael-reichenauer/gmc", "branches/fetch", "branches/fetch", "git"},
+		// Pull merge with target
+		{"Merge branch 'branches/fetch' of https://github.com/michael-reichenauer/gmc into branches/fetch", "branches/fetch", "branches/fetch", "git"},
+		// Pull merge with target
+		{"Merge remote-tracking branch 'refs/remotes/origin/branches/abb' into branches/abb", "branches/abb", "branches/abb", "git"},
+		// Pull merge from other remote than origin
+		{"Merge remote-tracking branch 'refs/remotes/upstream/branches/abb' into branches/abb", "branches/abb", "branches/abb", "git"},
+		{"Merge remote-tracking branch 'upstream/main' into main", "main", "main", "git"},
+		// Not remote-tracking, the first part is part of the branch name
+		{"Merge branch 'feature/abc' into main", "feature/abc", "main", "git"},
+
+		// Hosting providers
+		{"Merge pull request #12 from michael-reichenauer/feature/abc", "feature/abc", "", "GitHub"},
+		{"Merge branch 'release/1.0' into 'main'", "release/1.0", "main", "GitLab"},
+		{"Merged in feature/abc (pull request #12)", "feature/abc", "", "Bitbucket"},
+		{"Merge pull request #12 in PROJ/repo from feature/abc to main", "feature/abc", "main", "Bitbucket Server"},
+		{"Merge pull request #12 in PROJ/repo from refs/heads/feature/abc to main", "feature/abc", "main", "Bitbucket Server"},
+		{"Merge pull request 12 from feature/abc into main", "feature/abc", "main", "Azure DevOps"},
+		{"Merged PR 1234: Add some feature", "", "", "Azure DevOps PR"},
+
+		// Not merge subjects
+		{"Add some feature", "", "", ""},
+	}
+
+	for _, c := range cases {
+		fi := h.parseMergeBranchNames(c.subject)
+		assert.Equal(t, fromInto{from: c.from, into: c.into, parser: c.parser}, fi, c.subject)
+	}
+}
 
-	// Pull merge with target
-	fi = h.parseMergeBranchNames("Merge remote-tracking branch 'refs/remotes/origin/branches/abb' into branches/abb")
-	assert.Equal(t, "branches/abb", fi.from)
-	assert.Equal(t, "branches/abb", fi.into)
+func TestOverrideBuiltInSubjectParsers(t *testing.T) {
+	h := newBranchNameParser()
+	names := func() []string {
+		var names []string
+		for _, p := range h.parsers {
+			names = append(names, p.name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"GitHub", "GitLab", "Bitbucket", "Bitbucket Server", "Azure DevOps", "Azure DevOps PR"}, names())
+
+	// Replace the Azure DevOps PR parser, e.g. with subjects, which have branch names
+	parsers, err := newSubjectParsers([]SubjectParser{
+		{Name: "Azure DevOps PR", RegExp: `^Merged PR \d+: Merge (?P<from>\S+) into (?P<into>\S+)`},
+	})
+	assert.NoError(t, err)
+	h.setSubjectParsers(parsers)
+	assert.Equal(t, []string{"Azure DevOps PR", "GitHub", "GitLab", "Bitbucket", "Bitbucket Server", "Azure DevOps"}, names())
+	fi := h.parseMergeBranchNames("Merged PR 1234: Merge feature/abc into main")
+	assert.Equal(t, fromInto{from: "feature/abc", into: "main", parser: "Azure DevOps PR"}, fi)
+
+	// Disable the Azure DevOps PR parser, then the subject is parsed as a git merge subject
+	parsers, err = newSubjectParsers([]SubjectParser{{Name: "Azure DevOps PR"}})
+	assert.NoError(t, err)
+	h.setSubjectParsers(parsers)
+	assert.Equal(t, []string{"GitHub", "GitLab", "Bitbucket", "Bitbucket Server", "Azure DevOps"}, names())
+	fi = h.parseMergeBranchNames("Merged PR 1234: Add some feature")
+	assert.Equal(t, gitSubjectParser, fi.parser)
+}
 
+func TestUserSubjectParsers(t *testing.T) {
+	h := newBranchNameParser()
+	parsers, err := newSubjectParsers([]SubjectParser{
+		{Name: "Custom", RegExp: `^Integrated (?P<from>\S+) to (?P<into>\S+)`},
+		{Name: "Custom PR", RegExp: `^Merge pull request #\d+ from (?P<from>\S+)`},
+		{Name: "Custom Azure", RegExp: `^Merged PR \d+: Merge (?P<from>\S+) into (?P<into>\S+)`},
+	})
+	assert.NoError(t, err)
+	h.setSubjectParsers(parsers)
+
+	// User defined parsers are tried before the built-in parsers
+	fi := h.parseMergeBranchNames("Integrated origin/feature/abc to develop")
+	assert.Equal(t, fromInto{from: "feature/abc", into: "develop", parser: "Custom"}, fi)
+	fi = h.parseMergeBranchNames("Merge pull request #12 from feature/abc")
+	assert.Equal(t, fromInto{from: "feature/abc", parser: "Custom PR"}, fi)
+	fi = h.parseMergeBranchNames("Merged PR 1234: Merge feature/abc into main")
+	assert.Equal(t, fromInto{from: "feature/abc", into: "main", parser: "Custom Azure"}, fi)
+	fi = h.parseMergeBranchNames("Merged in feature/abc (pull request #12)")
+	assert.Equal(t, fromInto{from: "feature/abc", parser: "Bitbucket"}, fi)
+
+	_, err = newSubjectParsers([]SubjectParser{{Name: "No from", RegExp: `^Integrated (?P<into>\S+)`}})
+	assert.Error(t, err)
+	_, err = newSubjectParsers([]SubjectParser{{Name: "No names", RegExp: `^Integrated PR \d+`, NoNames: true}})
+	assert.NoError(t, err)
+	_, err = newSubjectParsers([]SubjectParser{{Name: "Invalid", RegExp: `^Integrated (?P<from>\S+`}})
+	assert.Error(t, err)
 }
 
 func c(id, subject string, parents ...string) *Commit {
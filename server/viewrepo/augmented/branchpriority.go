@@ -0,0 +1,96 @@
+package augmented
+
+import (
+	"path"
+	"strings"
+
+	"github.com/michael-reichenauer/gmc/utils/git"
+)
+
+// Default main branch names, if no main branch is configured and the origin remote has no default
+// branch (refs/remotes/origin/HEAD)
+var defaultMainBranches = []string{"main", "master"}
+
+// BranchPriority is the main branch and the long-lived branches, e.g. develop and release/*, which
+// are the backbone of the graph. When a commit has several possible branches, the prioritized
+// branch is preferred and prioritized branches keep stable lanes to the left in the graph.
+// The main branches have the highest priority and a remote branch has higher priority than its
+// local branch, e.g. origin/main, main, origin/develop, develop, ...
+type BranchPriority struct {
+	Main      []string // Main branch names, e.g. "trunk", or main and master by default
+	LongLived []string // Branch names or patterns, e.g. "develop" or "release/*"
+	Remotes   []string // Remote names, the default remote first, e.g. origin and upstream
+}
+
+// NewBranchPriority returns the priority of the main branch, or of main and master if no main
+// branch, and of the long-lived branch names or patterns, where the branches of the remotes have
+// higher priority than the local branches. The default remote is used, if there are no remotes
+func NewBranchPriority(mainBranch string, longLived []string, remotes []string) BranchPriority {
+	main := defaultMainBranches
+	if mainBranch != "" {
+		main = []string{mainBranch}
+	}
+	sortedRemotes := []string{git.DefaultRemote}
+	for _, remote := range remotes {
+		if remote != git.DefaultRemote {
+			sortedRemotes = append(sortedRemotes, remote)
+		}
+	}
+	return BranchPriority{Main: main, LongLived: longLived, Remotes: sortedRemotes}
+}
+
+// Index returns the priority index of the branch, where a lower index has higher priority,
+// or -1 if the branch is not prioritized
+func (t BranchPriority) Index(name string) int {
+	localName := name
+	offset := 1
+	for _, remote := range t.Remotes {
+		if strings.HasPrefix(name, remote+"/") {
+			localName = strings.TrimPrefix(name, remote+"/")
+			offset = 0
+			break
+		}
+	}
+
+	for i, pattern := range t.patterns() {
+		if isMatchBranch(pattern, localName) {
+			return 2*i + offset
+		}
+	}
+	return -1
+}
+
+// IsPrioritized returns true if the branch is a main or a long-lived branch
+func (t BranchPriority) IsPrioritized(name string) bool {
+	return t.Index(name) != -1
+}
+
+// IsMain returns true if the branch is a main branch (local or origin)
+func (t BranchPriority) IsMain(name string) bool {
+	index := t.Index(name)
+	return index != -1 && index < 2*len(t.Main)
+}
+
+// MainNames returns the main branch names in priority order, e.g. origin/main, upstream/main, main
+func (t BranchPriority) MainNames() []string {
+	var names []string
+	for _, name := range t.Main {
+		for _, remote := range t.Remotes {
+			names = append(names, remote+"/"+name)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func (t BranchPriority) patterns() []string {
+	return append(append([]string{}, t.Main...), t.LongLived...)
+}
+
+func isMatchBranch(pattern, name string) bool {
+	if pattern == name {
+		return true
+	}
+	isMatch, err := path.Match(pattern, name)
+	return err == nil && isMatch
+}
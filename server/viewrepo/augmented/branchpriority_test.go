@@ -0,0 +1,150 @@
+package augmented
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/git"
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBranchPriority(t *testing.T) {
+	defaults := NewBranchPriority("", nil, nil)
+	gitFlow := NewBranchPriority("trunk", []string{"develop", "release/*"}, []string{"origin"})
+	fork := NewBranchPriority("", nil, []string{"upstream", "origin"})
+	cases := []struct {
+		priority BranchPriority
+		name     string
+		index    int
+		isMain   bool
+	}{
+		{defaults, "origin/main", 0, true},
+		{defaults, "main", 1, true},
+		{defaults, "origin/master", 2, true},
+		{defaults, "master", 3, true},
+		{defaults, "develop", -1, false},
+		{gitFlow, "origin/trunk", 0, true},
+		{gitFlow, "trunk", 1, true},
+		{gitFlow, "main", -1, false},
+		{gitFlow, "origin/develop", 2, false},
+		{gitFlow, "develop", 3, false},
+		{gitFlow, "origin/release/1.0", 4, false},
+		{gitFlow, "release/2.0", 5, false},
+		{gitFlow, "release/2.0/fix", -1, false},
+		{gitFlow, "feature/release/2.0", -1, false},
+		{defaults, "upstream/main", -1, false},
+		{fork, "origin/main", 0, true},
+		{fork, "upstream/main", 0, true},
+		{fork, "main", 1, true},
+		{fork, "other/main", -1, false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.index, c.priority.Index(c.name), c.name)
+		assert.Equal(t, c.isMain, c.priority.IsMain(c.name), c.name)
+	}
+	assert.Equal(t, []string{"origin/main", "main", "origin/master", "master"}, defaults.MainNames())
+	assert.Equal(t, []string{"origin/trunk", "trunk"}, gitFlow.MainNames())
+	assert.Equal(t, []string{"origin/main", "upstream/main", "main", "origin/master", "upstream/master", "master"},
+		fork.MainNames())
+}
+
+func TestBranchPriorityOfOtherRemote(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	remotePath := wf.MkDir("remote").Path()
+	assert.NoError(t, git.New(remotePath).InitRepo())
+	tm := int64(1600000000)
+	commit := func(path, subject string) {
+		tm += 60
+		wf.File(path, "a.txt").Write(subject)
+		runGitAt(t, wf.Path(path), tm, "add", ".")
+		runGitAt(t, wf.Path(path), tm, "-c", "user.name=test", "-c", "user.email=test@test.com",
+			"commit", "-q", "-m", subject)
+	}
+	commit("remote", "initial")
+	runGitAt(t, remotePath, tm, "branch", "-M", "main")
+	runGitAt(t, remotePath, tm, "checkout", "-q", "-b", "feature")
+	commit("remote", "feature 1")
+	runGitAt(t, remotePath, tm, "checkout", "-q", "main")
+	commit("remote", "main 1")
+
+	// A clone, where the remote is not named origin
+	runGitAt(t, wf.Path(), tm, "clone", "-q", "-o", "upstream", remotePath, wf.Path("local"))
+	repo, err := NewRepoService(wf.Path("local")).GetFreshRepo()
+	assert.NoError(t, err)
+
+	branches := make(map[string]string)
+	for _, c := range repo.Commits {
+		branches[c.Subject] = c.Branch.Name
+	}
+	assert.Equal(t, map[string]string{
+		"initial":   "upstream/main",
+		"main 1":    "upstream/main",
+		"feature 1": "upstream/feature",
+	}, branches)
+	main, _ := repo.BranchByName("upstream/main")
+	assert.True(t, main.IsMainBranch)
+	feature, _ := repo.BranchByName("upstream/feature")
+	assert.Equal(t, "upstream/main", feature.ParentBranch.Name)
+}
+
+func TestLongLivedBranches(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	path := wf.MkDir("repo").Path()
+	g := git.New(path)
+	assert.NoError(t, g.InitRepo())
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	tm := int64(1600000000)
+	commit := func(subject string) {
+		tm += 60
+		wf.File("repo", "a.txt").Write(subject)
+		runGitAt(t, path, tm, "add", ".")
+		runGitAt(t, path, tm, "commit", "-q", "-m", subject)
+	}
+
+	// A trunk, a develop branch with a release branch and a feature branch
+	commit("initial")
+	runGitAt(t, path, tm, "branch", "-M", "trunk")
+	runGitAt(t, path, tm, "checkout", "-q", "-b", "develop")
+	commit("develop 1")
+	runGitAt(t, path, tm, "branch", "release/1.0")
+	commit("develop 2")
+	runGitAt(t, path, tm, "checkout", "-q", "-b", "feature")
+	commit("feature 1")
+	runGitAt(t, path, tm, "checkout", "-q", "release/1.0")
+	commit("release 1")
+
+	s := NewRepoService(path)
+	s.SetBranchPriority("trunk", []string{"develop", "release/*"})
+	repo, err := s.GetFreshRepo()
+	assert.NoError(t, err)
+
+	branches := make(map[string]string)
+	commits := make(map[string]*Commit)
+	for _, c := range repo.Commits {
+		branches[c.Subject] = c.Branch.Name
+		commits[c.Subject] = c
+	}
+	assert.Equal(t, map[string]string{
+		"initial":   "trunk",
+		"develop 1": "develop",
+		"develop 2": "develop",
+		"feature 1": "feature",
+		"release 1": "release/1.0",
+	}, branches, fmt.Sprintf("%v", repo.Commits))
+
+	trunk, _ := repo.BranchByName("trunk")
+	assert.True(t, trunk.IsMainBranch)
+	develop, _ := repo.BranchByName("develop")
+	assert.False(t, develop.IsMainBranch)
+	assert.Equal(t, "trunk", develop.ParentBranch.Name)
+
+	// The rules, which determined the commit branches, and the candidate branches
+	assert.Equal(t, rulePriorityBranch, commits["develop 2"].BranchRule)
+	assert.Equal(t, []string{"develop", "feature"}, commits["develop 2"].BranchCandidates)
+	assert.Equal(t, ruleOnlyOneBranch, commits["feature 1"].BranchRule)
+	assert.Nil(t, commits["feature 1"].BranchCandidates)
+}
@@ -29,6 +29,10 @@ type Commit struct {
 	isLikely       bool
 	IsAmbiguous    bool
 	IsAmbiguousTip bool
+
+	BranchRule       string   // The rule, which determined the branch, see determineCommitBranch
+	BranchCandidates []string // The possible branches, if more than one, when the branch was determined
+	derivation       *branchDerivation
 }
 
 func newGitCommit(gc git.Commit) *Commit {
@@ -48,7 +52,7 @@ func newPartialLogCommit() *Commit {
 		Id:         git.PartialLogCommitID,
 		Sid:        git.ToSid(git.PartialLogCommitID),
 		ParentIDs:  []string{},
-		Subject:    "...    (more commits)",
+		Subject:    "...    (more commits, press Enter to load more history)",
 		Message:    "...    (more commits)",
 		Author:     "",
 		AuthorTime: time.Date(2000, 1, 1, 1, 1, 0, 0, time.UTC),
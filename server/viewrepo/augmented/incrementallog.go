@@ -0,0 +1,150 @@
+package augmented
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/michael-reichenauer/gmc/utils/git"
+	"github.com/michael-reichenauer/gmc/utils/linq"
+	"github.com/michael-reichenauer/gmc/utils/log"
+	"github.com/samber/lo"
+)
+
+const maxKnownTips = 500 // Max number of known tips passed to git when reading new commits
+
+// previousRepo is the last fresh repo and the git data, it was created from, used when the next
+// fresh repo is created to only read new commits and to reuse the commit branches if possible
+type previousRepo struct {
+	gitRepo gitRepo
+	repo    Repo
+}
+
+// isSameCommitBranches returns true if the commit branches of the previous repo can be reused for
+// the git repo, i.e. branches are determined from the same commits, branches, meta data, branch
+// priority and merge subject parsers
+func (p *previousRepo) isSameCommitBranches(gr gitRepo) bool {
+	if p == nil || len(p.gitRepo.Commits) != len(gr.Commits) ||
+		!reflect.DeepEqual(p.gitRepo.Branches, gr.Branches) ||
+		!reflect.DeepEqual(p.gitRepo.MetaData, gr.MetaData) ||
+		!reflect.DeepEqual(p.gitRepo.BranchPriority, gr.BranchPriority) ||
+		!reflect.DeepEqual(p.gitRepo.SubjectParsers, gr.SubjectParsers) {
+		return false
+	}
+	for i := range gr.Commits {
+		// The commit id is a hash of the commit content, including the parent ids
+		if p.gitRepo.Commits[i].ID != gr.Commits[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+// isSameBranchRules returns true if the previous repo commit branches were determined with the same
+// meta data, branch priority and merge subject parsers, i.e. unchanged commits can reuse branches
+func (p *previousRepo) isSameBranchRules(gr gitRepo) bool {
+	return p != nil &&
+		reflect.DeepEqual(p.gitRepo.MetaData, gr.MetaData) &&
+		reflect.DeepEqual(p.gitRepo.BranchPriority, gr.BranchPriority) &&
+		reflect.DeepEqual(p.gitRepo.SubjectParsers, gr.SubjectParsers)
+}
+
+// getCommits returns the commits of the log. If there are previous commits, only new commits,
+// which are not reachable from the previous log tips, are read and added on top of the previous
+// commits, if the full log would have the same order. Otherwise, the full log is read.
+// Returns true if the commits were read incrementally.
+func (s *repoService) getCommits(previous []git.Commit, refTips []string) ([]git.Commit, bool, error) {
+	if len(previous) == 0 || len(previous) >= s.historyDepth {
+		// No previous commits or a partial log, where new commits would truncate older commits
+		commits, err := s.git.GetLogMax(s.historyDepth)
+		return commits, false, err
+	}
+
+	tips := logTips(previous)
+	if len(tips) > maxKnownTips {
+		commits, err := s.git.GetLogMax(s.historyDepth)
+		return commits, false, err
+	}
+
+	newCommits, err := s.git.GetNewLog(linq.Map(tips, func(v git.Commit) string { return v.ID }))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(previous)+len(newCommits) >= s.historyDepth || !isNewCommitsOnTop(newCommits, tips, refTips) {
+		log.Infof("Reading full log, since %d new commits are not on top of previous log", len(newCommits))
+		commits, err := s.git.GetLogMax(s.historyDepth)
+		return commits, false, err
+	}
+
+	commits := make([]git.Commit, 0, len(newCommits)+len(previous))
+	commits = append(commits, newCommits...)
+	commits = append(commits, previous...)
+	return commits, true, nil
+}
+
+// isNewCommitsOnTop returns true if a full log (git log --all --date-order) would list the new
+// commits in the same order followed by the previous commits. Git lists commits, which have no
+// unlisted children, in commit time order, i.e. the order is the same if:
+//   - All previous commits are still reachable, i.e. all previous tips are either ref tips or
+//     parents of new commits (otherwise, e.g. a deleted branch or an amended commit)
+//   - All new commits are newer than all previous tips, which are listed after new commits
+//   - Tips have different commit times, since the order of tips with the same time depends on
+//     the order of refs (or parents of new commits) when git starts listing commits
+func isNewCommitsOnTop(newCommits []git.Commit, previousTips []git.Commit, refTips []string) bool {
+	reachable := make(map[string]bool)
+	for _, id := range refTips {
+		reachable[id] = true
+	}
+	for _, c := range newCommits {
+		for _, id := range c.ParentIDs {
+			reachable[id] = true
+		}
+	}
+	if !lo.EveryBy(previousTips, func(v git.Commit) bool { return reachable[v.ID] }) {
+		return false
+	}
+
+	if hasSameCommitTime(previousTips) || hasSameCommitTime(logTips(newCommits)) {
+		return false
+	}
+
+	var previousMaxTime time.Time
+	for _, c := range previousTips {
+		if c.CommitTime.After(previousMaxTime) {
+			previousMaxTime = c.CommitTime
+		}
+	}
+	return lo.EveryBy(newCommits, func(v git.Commit) bool { return v.CommitTime.After(previousMaxTime) })
+}
+
+// logTips returns the commits, which have no children in the commits
+func logTips(commits []git.Commit) []git.Commit {
+	parents := make(map[string]bool)
+	for _, c := range commits {
+		for _, id := range c.ParentIDs {
+			parents[id] = true
+		}
+	}
+	return linq.Filter(commits, func(v git.Commit) bool { return !parents[v.ID] })
+}
+
+func hasSameCommitTime(commits []git.Commit) bool {
+	times := make(map[int64]bool)
+	for _, c := range commits {
+		if times[c.CommitTime.Unix()] {
+			return true
+		}
+		times[c.CommitTime.Unix()] = true
+	}
+	return false
+}
+
+// refTips returns the commit ids of branches, tags and the latest stash (refs/stash), i.e. the
+// refs, which the log (git log --all) starts from
+func refTips(branches []git.Branch, tags []git.Tag, stashes []git.Stash) []string {
+	tips := linq.Map(branches, func(v git.Branch) string { return v.TipID })
+	tips = append(tips, linq.Map(tags, func(v git.Tag) string { return v.CommitID })...)
+	if stash, ok := linq.Find(stashes, func(v git.Stash) bool { return v.Index == 0 }); ok {
+		tips = append(tips, stash.ID)
+	}
+	return tips
+}
@@ -0,0 +1,167 @@
+package augmented
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/git"
+	"github.com/michael-reichenauer/gmc/utils/linq"
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncrementalRepoSameAsFullRepo(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	path := wf.MkDir("repo").Path()
+	g := git.New(path)
+	assert.NoError(t, g.InitRepo())
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	tm := int64(1600000000)
+	commit := func(message string) {
+		// Commits on different branches change different files to avoid merge conflicts
+		tm += 60
+		wf.File("repo", strings.Fields(message)[0]+".txt").Write(message)
+		runGitAt(t, path, tm, "add", ".")
+		runGitAt(t, path, tm, "commit", "-q", "-m", message)
+	}
+
+	s := NewRepoService(path).(*repoService)
+	commit("initial")
+	runGitAt(t, path, tm, "branch", "-M", "main")
+	commit("main 1")
+	assertSameAsFullRepo(t, s, false)
+
+	// A new commit on the current branch, where only the new commit and the previous tip are
+	// determined by the rules, other commits reuse the previous branches
+	commit("main 2")
+	assertSameAsFullRepo(t, s, true)
+	assert.Equal(t, 2, s.branchesService.derived)
+
+	// A new branch with commits, which is then merged
+	assert.NoError(t, g.CreateBranch("feature"))
+	commit("feature 1")
+	commit("feature 2")
+	assertSameAsFullRepo(t, s, true)
+	assert.NoError(t, g.Checkout("main"))
+	commit("main 3")
+	runGitAt(t, path, tm+60, "merge", "--no-ff", "-q", "-m", "Merge branch 'feature' into main", "feature")
+	tm += 60
+	assertSameAsFullRepo(t, s, true)
+
+	// Two branches from the same commit, i.e. an ambiguous commit, and then new commits on top
+	assert.NoError(t, g.CreateBranch("x"))
+	commit("x 1")
+	assert.NoError(t, g.CreateBranch("y"))
+	commit("y 1")
+	assert.NoError(t, g.Checkout("x"))
+	commit("x 2")
+	repo := assertSameAsFullRepo(t, s, true)
+	assert.True(t, repo.SearchCommits("x 1")[0].IsAmbiguous)
+	commit("x 3")
+	assertSameAsFullRepo(t, s, true)
+	assert.Equal(t, 2, s.branchesService.derived)
+	assert.NoError(t, g.Checkout("y"))
+	commit("y 2")
+	runGitAt(t, path, tm+60, "merge", "--no-ff", "-q", "-m", "Merge branch 'x' into y", "x")
+	tm += 60
+	assertSameAsFullRepo(t, s, true)
+	assert.Less(t, s.branchesService.derived, len(s.previous.repo.Commits)/2)
+	assert.NoError(t, g.Checkout("main"))
+	assertSameAsFullRepo(t, s, true)
+
+	// Only a new tag, reuses the previous commits and branches
+	previous := s.previous.repo
+	runGitAt(t, path, tm, "tag", "v1")
+	repo = assertSameAsFullRepo(t, s, true)
+	assert.Same(t, previous.Commits[0], repo.Commits[0])
+
+	// Deleted (merged) branch, a branch on an older commit and a checkout
+	assert.NoError(t, g.DeleteLocalBranch("feature", true))
+	runGitAt(t, path, tm, "branch", "old", "HEAD~2")
+	assertSameAsFullRepo(t, s, true)
+	assert.NoError(t, g.Checkout("old"))
+	commit("old 1")
+	assertSameAsFullRepo(t, s, true)
+
+	// A stash, which is then dropped, i.e. the stash commits are no longer reachable
+	tm += 60
+	wf.File("repo", "old.txt").Write("changed")
+	runGitAt(t, path, tm, "stash", "push", "-q")
+	assertSameAsFullRepo(t, s, true)
+	runGitAt(t, path, tm, "stash", "drop", "-q")
+	assertSameAsFullRepo(t, s, false)
+
+	// Amended commit, where the previous commit is no longer reachable
+	tm += 60
+	runGitAt(t, path, tm, "commit", "-q", "--amend", "-m", "old 1 amended")
+	assertSameAsFullRepo(t, s, false)
+
+	// A new commit, which is older than previous commits (e.g. fetched from another computer)
+	assert.NoError(t, g.Checkout("main"))
+	tm -= 3600
+	commit("main 4")
+	assertSameAsFullRepo(t, s, false)
+}
+
+// assertSameAsFullRepo asserts that the next fresh repo is the same as a repo created by a new
+// repo service (without previous repo) and that only new commits were read (if incremental)
+func assertSameAsFullRepo(t *testing.T, s *repoService, isIncremental bool) Repo {
+	branches, err := s.git.GetBranches()
+	assert.NoError(t, err)
+	tags, err := s.git.GetTags()
+	assert.NoError(t, err)
+	stashes, err := s.git.GetStashes()
+	assert.NoError(t, err)
+	var previousCommits []git.Commit
+	if s.previous != nil {
+		previousCommits = s.previous.gitRepo.Commits
+	}
+	_, isRead, err := s.getCommits(previousCommits, refTips(branches, tags, stashes))
+	assert.NoError(t, err)
+	assert.Equal(t, isIncremental, isRead)
+
+	repo, err := s.GetFreshRepo()
+	assert.NoError(t, err)
+	fullRepo, err := NewRepoService(s.git.RepoPath()).GetFreshRepo()
+	assert.NoError(t, err)
+	assert.Equal(t, repoText(fullRepo), repoText(repo))
+	return repo
+}
+
+// repoText returns a text with commits, commit branches and branches to compare repos
+func repoText(repo Repo) string {
+	var sb strings.Builder
+	names := func(branches []*Branch) []string {
+		return linq.Map(branches, func(v *Branch) string { return v.Name })
+	}
+	for _, c := range repo.Commits {
+		fmt.Fprintf(&sb, "%s %q %v branch: %s %v tips: %v current: %v ambiguous: %v %v children: %v rule: %s %v\n",
+			c.Sid, c.Subject, c.ParentIDs, c.Branch.Name, names(c.Branches), c.BranchTipNames,
+			c.IsCurrent, c.IsAmbiguous, c.IsAmbiguousTip, c.ChildIDs, c.BranchRule, c.BranchCandidates)
+	}
+	for _, b := range repo.Branches {
+		parent := ""
+		if b.ParentBranch != nil {
+			parent = b.ParentBranch.Name
+		}
+		fmt.Fprintf(&sb, "%s %s-%s parent: %s local: %s current: %v ambiguous: %v %v\n",
+			b.Name, b.TipID, b.BottomID, parent, b.LocalName, b.IsCurrent, b.AmbiguousTipId,
+			names(b.AmbiguousBranches))
+	}
+	fmt.Fprintf(&sb, "tags: %v, stashes: %v", repo.Tags, repo.Stashes)
+	return sb.String()
+}
+
+// runGitAt runs git with the commit time (and author time) set to the time
+func runGitAt(t *testing.T, path string, tm int64, args ...string) {
+	date := fmt.Sprintf("%d +0100", tm)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = path
+	cmd.Env = append(cmd.Environ(), "GIT_COMMITTER_DATE="+date, "GIT_AUTHOR_DATE="+date)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed, %v\n%s", args, err, out)
+	}
+}
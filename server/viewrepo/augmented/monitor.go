@@ -8,6 +8,7 @@ import (
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/michael-reichenauer/gmc/utils"
+	"github.com/michael-reichenauer/gmc/utils/git"
 	"github.com/michael-reichenauer/gmc/utils/log"
 )
 
@@ -28,6 +29,8 @@ type monitor struct {
 
 	watcher        *fsnotify.Watcher
 	rootFolderPath string
+	gitPath        string // The git dir, which for a linked worktree is outside the root folder
+	commonGitPath  string // The git dir with refs and objects, shared by all worktrees
 	ignorer        Ignorer
 }
 
@@ -36,10 +39,13 @@ func newMonitor(rootFolderPath string, ignorer Ignorer) *monitor {
 	if err != nil {
 		panic(log.Fatal(err))
 	}
+	gitPath := git.GitDir(rootFolderPath)
 	return &monitor{
 		Changes:        make(chan changeType),
 		watcher:        watcher,
 		rootFolderPath: rootFolderPath,
+		gitPath:        gitPath,
+		commonGitPath:  git.CommonGitDir(gitPath),
 		ignorer:        ignorer,
 	}
 }
@@ -51,6 +57,15 @@ func (h *monitor) Start(ctx context.Context) error {
 	}()
 	go h.monitorFolderRoutine(ctx)
 	go h.addWatchFoldersRecursively(ctx, h.rootFolderPath)
+	if !h.isInRootFolder(h.gitPath) {
+		// A linked worktree, where HEAD and e.g. MERGE_HEAD are in a git dir in the main repo
+		go h.addWatchFoldersRecursively(ctx, h.gitPath)
+	}
+	if h.commonGitPath != h.gitPath && !h.isInRootFolder(h.commonGitPath) {
+		// Refs are shared by all worktrees, but the other worktrees git dirs are not needed
+		go h.addWatchFoldersRecursively(ctx, filepath.Join(h.commonGitPath, "refs"))
+		go h.addWatchFolder(h.commonGitPath)
+	}
 	return nil
 }
 
@@ -71,13 +86,23 @@ func (h *monitor) addWatchFoldersRecursively(ctx context.Context, path string) {
 	})
 }
 
+func (h *monitor) addWatchFolder(path string) {
+	if err := h.watcher.Add(path); err != nil {
+		log.Warnf("Failed to watch %q, %v", path, err)
+	}
+}
+
+func (h *monitor) isInRootFolder(path string) bool {
+	return strings.HasPrefix(path, h.rootFolderPath+string(filepath.Separator))
+}
+
 func (h *monitor) monitorFolderRoutine(ctx context.Context) {
-	gitPath := filepath.Join(h.rootFolderPath, ".git")
-	gitFolderPath := gitPath + "/"
-	refsPath := filepath.Join(gitPath, "refs")
-	headPath := filepath.Join(gitPath, "HEAD")
-	objectPath := filepath.Join(gitPath, "objects")
-	fetchHeadPath := filepath.Join(gitPath, "FETCH_HEAD")
+	gitFolderPath := h.gitPath + string(filepath.Separator)
+	commonGitFolderPath := h.commonGitPath + string(filepath.Separator)
+	refsPath := filepath.Join(h.commonGitPath, "refs")
+	headPath := filepath.Join(h.gitPath, "HEAD")
+	objectPath := filepath.Join(h.commonGitPath, "objects")
+	fetchHeadPath := filepath.Join(h.commonGitPath, "FETCH_HEAD")
 	defer close(h.Changes)
 
 	for event := range h.watcher.Events {
@@ -101,7 +126,7 @@ func (h *monitor) monitorFolderRoutine(ctx context.Context) {
 			case <-ctx.Done():
 				return
 			}
-		} else if h.isStatusChange(event.Name, gitFolderPath) {
+		} else if h.isStatusChange(event.Name, gitFolderPath, commonGitFolderPath) {
 			// log.Infof("Status change: %s", event.Name)
 			select {
 			case h.Changes <- statusChange:
@@ -118,14 +143,14 @@ func (h *monitor) isIgnored(path string) bool {
 	if utils.DirExists(path) {
 		return true
 	}
-	if h.ignorer != nil && h.ignorer.IsIgnored(path) {
+	if h.ignorer != nil && h.isInRootFolder(path) && h.ignorer.IsIgnored(path) {
 		return true
 	}
 	return false
 }
 
-func (h *monitor) isStatusChange(path, gitFolderPath string) bool {
-	return !strings.HasPrefix(path, gitFolderPath)
+func (h *monitor) isStatusChange(path, gitFolderPath, commonGitFolderPath string) bool {
+	return !strings.HasPrefix(path, gitFolderPath) && !strings.HasPrefix(path, commonGitFolderPath)
 }
 
 func (h *monitor) isRepoChange(path, fetchHeadPath, headPath, refsPath string) bool {
@@ -12,16 +12,21 @@ import (
 
 type MetaData struct {
 	BranchesChildren map[string][]string
+	BranchNames      map[string]string // Branch names set by users, by tip commit id
 }
 
 type Repo struct {
-	Commits    []*Commit
-	commitById map[string]*Commit
-	Branches   []*Branch
-	Status     Status
-	Tags       []Tag
-	RepoPath   string
-	MetaData   MetaData
+	Commits        []*Commit
+	commitById     map[string]*Commit
+	Branches       []*Branch
+	Status         Status
+	Tags           []Tag
+	Stashes        []Stash
+	Remotes        []string
+	RepoPath       string
+	MetaData       MetaData
+	BranchPriority BranchPriority
+	SquashMerged   map[string]bool // Names of branches, which were squash merged or rebased
 }
 
 // augmented
@@ -69,6 +74,22 @@ func (r *Repo) TryGetCommitByID(id string) (*Commit, bool) {
 	return c, ok
 }
 
+// isPartialLog returns true if the repo has more commits than the history depth, i.e. older
+// commits are in the partial log commit
+func (r *Repo) isPartialLog() bool {
+	_, ok := r.commitById[git.PartialLogCommitID]
+	return ok
+}
+
+func (r *Repo) StashByID(id string) (Stash, bool) {
+	for _, s := range r.Stashes {
+		if s.Id == id {
+			return s, true
+		}
+	}
+	return Stash{}, false
+}
+
 func (r *Repo) BranchByName(name string) (*Branch, bool) {
 	for _, br := range r.Branches {
 		if br.Name == name {
@@ -94,10 +115,10 @@ func (r *Repo) CurrentBranch() (*Branch, bool) {
 	return nil, false
 }
 
-func (r *Repo) setGitCommits(gitCommits []git.Commit) {
-	// For repositories with a lot of commits, only the latest 'partialMax' number of commits
+func (r *Repo) setGitCommits(gitCommits []git.Commit, historyDepth int) {
+	// For repositories with a lot of commits, only the latest 'historyDepth' number of commits
 	// are used, i.w. partial commits, which should have parents, but they are unknown
-	isPartialPossible := len(gitCommits) >= partialMax
+	isPartialPossible := len(gitCommits) >= historyDepth
 	isPartialNeeded := false
 	commits := make([]*Commit, len(gitCommits), len(gitCommits)+10)
 
@@ -170,6 +191,15 @@ func (r *Repo) setGitBranches(gitBranches []git.Branch) {
 	}
 }
 
+// setGitStatusAndRefs sets the status, tags, stashes and remotes, which do not affect the commit
+// branches
+func (r *Repo) setGitStatusAndRefs(gr gitRepo) {
+	r.Status = newStatus(gr.Status)
+	r.Tags = toTags(gr.Tags)
+	r.Stashes = toStashes(gr.Stashes)
+	r.Remotes = linq.Map(gr.Remotes, func(v git.Remote) string { return v.Name })
+}
+
 func (r *Repo) addAmbiguousBranch(c *Commit) *Branch {
 	b := newAmbiguousBranch(c.Id)
 	for _, cc := range c.Children {
@@ -206,9 +236,12 @@ func toMetaData(metaDataText string) MetaData {
 	if metaData.BranchesChildren == nil {
 		metaData.BranchesChildren = make(map[string][]string)
 	}
+	if metaData.BranchNames == nil {
+		metaData.BranchNames = make(map[string]string)
+	}
 	return metaData
 }
 
 func defaultMetaData() MetaData {
-	return MetaData{BranchesChildren: make(map[string][]string)}
+	return MetaData{BranchesChildren: make(map[string][]string), BranchNames: make(map[string]string)}
 }
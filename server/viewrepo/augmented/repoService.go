@@ -3,7 +3,9 @@ package augmented
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/michael-reichenauer/gmc/utils"
@@ -24,22 +26,69 @@ type RepoService interface {
 	GetCommitDiff(id string) (git.CommitDiff, error)
 	GetFileDiff(path string) ([]git.CommitDiff, error)
 	GetFiles(ref string) ([]string, error)
+	GetStashDiff(name string) (git.CommitDiff, error)
 
 	SwitchToBranch(name string) error
+	CheckoutCommit(id string) error
+	GetReflog(ref string) ([]git.ReflogEntry, error)
+	GetWorktrees() ([]git.Worktree, error)
+	GetSubmodules() ([]git.Submodule, error)
+	UpdateSubmodules() error
+	AddWorktree(path, branchName string) error
+	RemoveWorktree(path string, isForced bool) error
 	Commit(commit string) error
+	CommitStaged(commit string) error
+	GetStatus() (Status, error)
+	StageFile(path string) error
+	GetConflictFile(path string) (git.ConflictFile, error)
+	GetFileBlame(path, ref string) ([]git.BlameLine, error)
+	ResolveConflict(path string, choices []git.ConflictChoice) error
+	UnstageFile(path string) error
+	StageSection(path string, section git.SectionDiff, fromLine, toLine int) error
+	UnstageSection(path string, section git.SectionDiff, fromLine, toLine int) error
 	PushBranch(name string) error
+	PushBranchTo(remote, name string) error
 	CreateBranch(name string, parentBranch *Branch) error
+	CreateBranchAt(name, id string) error
 	MergeBranch(name string) error
-	DeleteRemoteBranch(name string) error
+	CherryPick(ids []string) error
+	CherryPickContinue() error
+	CherryPickAbort() error
+	RebaseBranch(name, onto string) error
+	RebaseContinue() error
+	RebaseSkip() error
+	RebaseAbort() error
+	CreateTag(name, commitID, message string) error
+	DeleteTag(name string) error
+	DeleteRemoteTag(name string) error
+	PushTag(name string) error
+	DeleteRemoteBranch(remote, name string) error
 	DeleteLocalBranch(name string, isForced bool) error
 	PullCurrentBranch() error
+	PullCurrentBranchFrom(remote, name string) error
 	PullBranch(name string) error
+	PullBranchFrom(remote, name string) error
+	StashPush(message string, includeUntracked bool) error
+	StashApply(name string) error
+	StashPop(name string) error
+	StashDrop(name string) error
+	RemoveLockFile(path string) error
 
 	GetFreshRepo() (Repo, error)
+	SetHistoryDepth(commitCount int)
+	SetBranchPriority(mainBranch string, longLivedBranches []string)
+	SetSubjectParsers(parsers []SubjectParser) error
+	EnableCache(folder, programVersion string)
+	LoadMoreHistory() (int, bool)
 	SetAsParentBranch(b *Branch, pb *Branch) error
 	UnsetAsParentBranch(name string) error
+	SetBranchName(tipID, name string) error
 	UndoCommit(id string) error
 	UncommitLastCommit() error
+	AmendCommit(message string) error
+	RewordCommit(id, message string) error
+	DropCommit(id string) error
+	SquashCommits(fromID, toID, message string) error
 	UndoAllUncommittedChanges() error
 	UndoUncommittedFileChanges(path string) error
 	CleanWorkingFolder() error
@@ -54,40 +103,60 @@ type RepoChange struct {
 var metaDataKey = "data"
 
 type gitRepo struct {
-	RootPath string
-	Commits  []git.Commit
-	Branches []git.Branch
-	Status   git.Status
-	Tags     []git.Tag
-	MetaData MetaData
+	RootPath       string
+	Commits        []git.Commit
+	Branches       []git.Branch
+	Status         git.Status
+	Tags           []git.Tag
+	Stashes        []git.Stash
+	Remotes        []git.Remote
+	MetaData       MetaData
+	BranchPriority BranchPriority
+	SubjectParsers []SubjectParser // User defined merge subject parsers
 }
 
 type repoService struct {
 	branchesService *branchesService
+	squashMerged    *squashMergedService
 	folderMonitor   *monitor
 
 	repoChanges   chan RepoChange
 	git           git.Git
 	repo          chan Repo
 	manualRefresh chan struct{}
+
+	freshMutex   sync.Mutex // Serializes fresh repos, which are based on the previous repo
+	previous     *previousRepo
+	historyDepth int // Max number of commits in the repo, older commits are in a partial log commit
+	cache        *repoCache
+
+	mainBranch        string   // Configured main branch, e.g. "trunk", or "" for the origin default branch
+	longLivedBranches []string // Configured long-lived branch names or patterns, e.g. "release/*"
+	subjectParsers    []SubjectParser
 }
 
 const (
 	fetchInterval = 10 * time.Minute
 	batchInterval = 1 * time.Second
-	partialMax    = 30000 // Max number of commits to handle
+	partialMax    = 30000 // Default max number of commits to handle and number of more commits to load
 )
 
 func NewRepoService(rootPath string) RepoService {
-	g := git.New(rootPath)
+	return NewRepoServiceWithGit(git.New(rootPath))
+}
 
+// NewRepoServiceWithGit returns a repo service for the repo of the git, e.g. a git, which reads
+// the repo objects directly
+func NewRepoServiceWithGit(g git.Git) RepoService {
 	return &repoService{
 		branchesService: newBranchesService(),
+		squashMerged:    newSquashMergedService(g),
 		git:             g,
-		folderMonitor:   newMonitor(rootPath, g),
+		folderMonitor:   newMonitor(g.RepoPath(), g),
 		repoChanges:     make(chan RepoChange, 1),
 		repo:            make(chan Repo, 1),
 		manualRefresh:   make(chan struct{}, 1),
+		historyDepth:    partialMax,
 	}
 }
 
@@ -116,6 +185,10 @@ func (s *repoService) GetFileDiff(path string) ([]git.CommitDiff, error) {
 	return s.git.FileDiff(path)
 }
 
+func (s *repoService) GetStashDiff(name string) (git.CommitDiff, error) {
+	return s.git.StashDiff(name)
+}
+
 func (s *repoService) SwitchToBranch(name string) error {
 	return s.git.Checkout(name)
 }
@@ -124,6 +197,78 @@ func (s *repoService) Commit(message string) error {
 	return s.git.Commit(message)
 }
 
+func (s *repoService) CommitStaged(message string) error {
+	return s.git.CommitStaged(message)
+}
+
+func (s *repoService) GetStatus() (Status, error) {
+	gitStatus, err := s.git.GetStatus()
+	if err != nil {
+		return Status{}, err
+	}
+	return newStatus(gitStatus), nil
+}
+
+func (s *repoService) GetConflictFile(path string) (git.ConflictFile, error) {
+	return s.git.GetConflictFile(path)
+}
+
+func (s *repoService) ResolveConflict(path string, choices []git.ConflictChoice) error {
+	return s.git.ResolveConflict(path, choices)
+}
+
+func (s *repoService) CheckoutCommit(id string) error {
+	return s.git.Checkout(id)
+}
+
+func (s *repoService) GetReflog(ref string) ([]git.ReflogEntry, error) {
+	return s.git.GetReflog(ref)
+}
+
+func (s *repoService) GetWorktrees() ([]git.Worktree, error) {
+	return s.git.GetWorktrees()
+}
+
+func (s *repoService) AddWorktree(path, branchName string) error {
+	return s.git.AddWorktree(path, branchName)
+}
+
+func (s *repoService) RemoveWorktree(path string, isForced bool) error {
+	return s.git.RemoveWorktree(path, isForced)
+}
+
+func (s *repoService) GetSubmodules() ([]git.Submodule, error) {
+	return s.git.GetSubmodules()
+}
+
+func (s *repoService) UpdateSubmodules() error {
+	return s.git.UpdateSubmodules()
+}
+
+func (s *repoService) CreateBranchAt(name, id string) error {
+	return s.git.CreateBranchAt(name, id)
+}
+
+func (s *repoService) GetFileBlame(path, ref string) ([]git.BlameLine, error) {
+	return s.git.GetFileBlame(path, ref)
+}
+
+func (s *repoService) StageFile(path string) error {
+	return s.git.StageFile(path)
+}
+
+func (s *repoService) UnstageFile(path string) error {
+	return s.git.UnstageFile(path)
+}
+
+func (s *repoService) StageSection(path string, section git.SectionDiff, fromLine, toLine int) error {
+	return s.git.StageSection(path, section, fromLine, toLine)
+}
+
+func (s *repoService) UnstageSection(path string, section git.SectionDiff, fromLine, toLine int) error {
+	return s.git.UnstageSection(path, section, fromLine, toLine)
+}
+
 func (s *repoService) UndoCommit(id string) error {
 	return s.git.UndoCommit(id)
 }
@@ -132,6 +277,22 @@ func (s *repoService) UncommitLastCommit() error {
 	return s.git.UncommitLastCommit()
 }
 
+func (s *repoService) AmendCommit(message string) error {
+	return s.git.AmendCommit(message)
+}
+
+func (s *repoService) RewordCommit(id, message string) error {
+	return s.git.RewordCommit(id, message)
+}
+
+func (s *repoService) DropCommit(id string) error {
+	return s.git.DropCommit(id)
+}
+
+func (s *repoService) SquashCommits(fromID, toID, message string) error {
+	return s.git.SquashCommits(fromID, toID, message)
+}
+
 func (s *repoService) UndoAllUncommittedChanges() error {
 	return s.git.UndoAllUncommittedChanges()
 }
@@ -148,18 +309,94 @@ func (s *repoService) PushBranch(name string) error {
 	return s.git.PushBranch(name)
 }
 
+func (s *repoService) PushBranchTo(remote, name string) error {
+	return s.git.PushBranchTo(remote, name)
+}
+
 func (s *repoService) PullCurrentBranch() error {
 	return s.git.PullCurrentBranch()
 }
 
+func (s *repoService) PullCurrentBranchFrom(remote, name string) error {
+	return s.git.PullCurrentBranchFrom(remote, name)
+}
+
 func (s *repoService) PullBranch(name string) error {
 	return s.git.PullBranch(name)
 }
 
+func (s *repoService) PullBranchFrom(remote, name string) error {
+	return s.git.PullBranchFrom(remote, name)
+}
+
 func (s *repoService) MergeBranch(name string) error {
 	return s.git.MergeBranch(name)
 }
 
+func (s *repoService) CherryPick(ids []string) error {
+	return s.git.CherryPick(ids)
+}
+
+func (s *repoService) RebaseBranch(name, onto string) error {
+	return s.git.RebaseBranch(name, onto)
+}
+
+func (s *repoService) RebaseContinue() error {
+	return s.git.RebaseContinue()
+}
+
+func (s *repoService) RebaseSkip() error {
+	return s.git.RebaseSkip()
+}
+
+func (s *repoService) RebaseAbort() error {
+	return s.git.RebaseAbort()
+}
+
+func (s *repoService) CherryPickContinue() error {
+	return s.git.CherryPickContinue()
+}
+
+func (s *repoService) CherryPickAbort() error {
+	return s.git.CherryPickAbort()
+}
+
+func (s *repoService) CreateTag(name, commitID, message string) error {
+	return s.git.CreateTag(name, commitID, message)
+}
+
+func (s *repoService) DeleteTag(name string) error {
+	return s.git.DeleteTag(name)
+}
+
+func (s *repoService) DeleteRemoteTag(name string) error {
+	return s.git.DeleteRemoteTag(name)
+}
+
+func (s *repoService) PushTag(name string) error {
+	return s.git.PushTag(name)
+}
+
+func (s *repoService) StashPush(message string, includeUntracked bool) error {
+	return s.git.StashPush(message, includeUntracked)
+}
+
+func (s *repoService) RemoveLockFile(path string) error {
+	return s.git.RemoveLockFile(path)
+}
+
+func (s *repoService) StashApply(name string) error {
+	return s.git.StashApply(name)
+}
+
+func (s *repoService) StashPop(name string) error {
+	return s.git.StashPop(name)
+}
+
+func (s *repoService) StashDrop(name string) error {
+	return s.git.StashDrop(name)
+}
+
 func (t *repoService) CreateBranch(name string, parentBranch *Branch) error {
 	err := t.git.CreateBranch(name)
 	if err != nil {
@@ -188,8 +425,8 @@ func (s *repoService) GetFiles(ref string) ([]string, error) {
 	return s.git.GetFiles(ref)
 }
 
-func (s *repoService) DeleteRemoteBranch(name string) error {
-	return s.git.DeleteRemoteBranch(name)
+func (s *repoService) DeleteRemoteBranch(remote, name string) error {
+	return s.git.DeleteRemoteBranch(remote, name)
 }
 
 func (s *repoService) DeleteLocalBranch(name string, isForced bool) error {
@@ -212,6 +449,16 @@ func (s *repoService) monitorRoutine(ctx context.Context) {
 
 	hasRepo := false
 	var repo Repo
+	if cachedRepo, ok := s.getCachedRepo(); ok {
+		// Show the cached repo immediately, the initial refresh will then post the fresh repo
+		hasRepo = true
+		repo = cachedRepo
+		select {
+		case s.repoChanges <- RepoChange{Repo: repo}:
+		case <-ctx.Done():
+			return
+		}
+	}
 	var wait = time.After(batchInterval)
 	change := noChange
 
@@ -262,6 +509,7 @@ func (s *repoService) monitorRoutine(ctx context.Context) {
 			// Received new repo, notify listeners
 			log.Debugf("Received repo")
 			hasRepo = true
+			repo.SquashMerged = s.squashMerged.knownMergedBranches(&repo)
 			select {
 			case s.repoChanges <- RepoChange{Repo: repo}:
 				log.Debugf("posted repo")
@@ -269,6 +517,22 @@ func (s *repoService) monitorRoutine(ctx context.Context) {
 				return
 			}
 			change = noChange
+		case <-s.squashMerged.changes:
+			// Squash merged branches were detected in the background, notify listeners if changed
+			if !hasRepo {
+				break
+			}
+			merged := s.squashMerged.knownMergedBranches(&repo)
+			if reflect.DeepEqual(merged, repo.SquashMerged) {
+				break
+			}
+			log.Infof("Squash merged branches changed")
+			repo.SquashMerged = merged
+			select {
+			case s.repoChanges <- RepoChange{Repo: repo}:
+			case <-ctx.Done():
+				return
+			}
 		case <-s.manualRefresh:
 			// A refresh repo request, trigger repo change immediately
 			log.Infof("refresh repo request")
@@ -333,6 +597,7 @@ func (s *repoService) gitFetch() error {
 	// if error is remote error, the fetch will handle that
 	_ = s.pullMetaData()
 
+	// Fetch all remotes (e.g. both origin and upstream in a fork)
 	return s.git.Fetch()
 }
 
@@ -356,30 +621,159 @@ func (s *repoService) getFreshStatus(repo Repo) (Repo, error) {
 	return repo, nil
 }
 
+// GetFreshRepo returns a repo with the current commits and branches. Only commits, which are
+// new since the previous repo, are read if possible and the commit branches of the previous repo
+// are reused, if commits, branches and meta data are unchanged (e.g. only new tags or stashes)
 func (s *repoService) GetFreshRepo() (Repo, error) {
 	log.Infof("Getting fresh repo for %s", s.git.RepoPath())
 	st := timer.Start()
+	s.freshMutex.Lock()
+	defer s.freshMutex.Unlock()
 
-	repo := newRepo()
-	repo.RepoPath = s.git.RepoPath()
-	gitRepo, err := s.getGitRepo(partialMax)
+	gitRepo, err := s.getGitRepo(s.previous)
 	if err != nil {
 		return Repo{}, err
 	}
 
-	repo.MetaData = gitRepo.MetaData
-	repo.Status = newStatus(gitRepo.Status)
-	repo.Tags = toTags(gitRepo.Tags)
-	repo.setGitBranches(gitRepo.Branches)
-	repo.setGitCommits(gitRepo.Commits)
+	var repo *Repo
+	if s.previous.isSameCommitBranches(gitRepo) {
+		// Reuse the previous commits and branches (shared with previous repo, which is read only)
+		previous := s.previous.repo
+		repo = &previous
+	} else {
+		repo = newRepo()
+		repo.RepoPath = s.git.RepoPath()
+		repo.MetaData = gitRepo.MetaData
+		repo.BranchPriority = gitRepo.BranchPriority
+		repo.setGitBranches(gitRepo.Branches)
+		repo.setGitCommits(gitRepo.Commits, s.historyDepth)
+
+		// Determine branch for all commits and determine branch hierarchy, where unchanged commits
+		// reuse the branches of the previous repo
+		var previous *Repo
+		if s.previous.isSameBranchRules(gitRepo) {
+			previous = &s.previous.repo
+		}
+		s.branchesService.setBranchForAllCommits(repo, previous)
+		log.Infof("Determined branches of %d of %d commits", s.branchesService.derived, len(repo.Commits))
+	}
 
-	// Determine branch for all commits and determine branch hierarchy
-	s.branchesService.setBranchForAllCommits(repo)
+	repo.setGitStatusAndRefs(gitRepo)
+	repo.SquashMerged = s.squashMerged.squashMergedBranches(repo)
+	s.previous = &previousRepo{gitRepo: gitRepo, repo: *repo}
+	if s.cache != nil {
+		s.cache.write(s.previous, s.historyDepth)
+	}
 
 	log.Infof("Repo %v: %d commits, %d branches, %d tags, status: %q (%q)", st, len(gitRepo.Commits), len(gitRepo.Branches), len(gitRepo.Tags), &gitRepo.Status, gitRepo.RootPath)
 	return *repo, nil
 }
 
+// EnableCache enables caching of the latest fresh repo in the cache folder, which is used to show
+// the repo immediately, when the repo is opened by the same program version the next time
+func (s *repoService) EnableCache(folder, programVersion string) {
+	s.freshMutex.Lock()
+	defer s.freshMutex.Unlock()
+	s.cache = newRepoCache(folder, s.git.RepoPath(), programVersion)
+}
+
+// getCachedRepo returns the cached repo, if the repo still has the same branches, tags, stashes
+// and meta data. The cached repo is used as previous repo in any case, so the next fresh repo
+// only needs to read new commits and can reuse the commit branches, if unchanged
+func (s *repoService) getCachedRepo() (Repo, bool) {
+	s.freshMutex.Lock()
+	defer s.freshMutex.Unlock()
+	if s.cache == nil || s.previous != nil {
+		return Repo{}, false
+	}
+	cached, err := s.cache.read(s.git.RepoPath(), s.historyDepth)
+	if err != nil {
+		log.Infof("No cached repo, %v", err)
+		return Repo{}, false
+	}
+	s.previous = cached
+
+	branches, err := s.git.GetBranches()
+	if err != nil {
+		return Repo{}, false
+	}
+	tags, err := s.git.GetTags()
+	if err != nil {
+		return Repo{}, false
+	}
+	stashes, err := s.git.GetStashes()
+	if err != nil {
+		return Repo{}, false
+	}
+	remotes, err := s.git.GetRemotes()
+	if err != nil {
+		return Repo{}, false
+	}
+	current := gitRepo{Branches: branches, Tags: tags, Stashes: stashes}
+	if !reflect.DeepEqual(current.Branches, cached.gitRepo.Branches) ||
+		!isSameTagsAndStashes(current, cached.gitRepo) ||
+		!reflect.DeepEqual(s.getMetaData(), cached.gitRepo.MetaData) ||
+		!reflect.DeepEqual(s.getBranchPriority(remotes), cached.gitRepo.BranchPriority) ||
+		!reflect.DeepEqual(s.subjectParsers, cached.gitRepo.SubjectParsers) {
+		log.Infof("Cached repo is not the current repo")
+		return Repo{}, false
+	}
+	return cached.repo, true
+}
+
+// SetHistoryDepth sets the max number of commits in the repo, e.g. a depth stored for the repo,
+// where 0 is the default depth
+func (s *repoService) SetHistoryDepth(commitCount int) {
+	if commitCount <= 0 {
+		commitCount = partialMax
+	}
+	s.freshMutex.Lock()
+	defer s.freshMutex.Unlock()
+	s.historyDepth = commitCount
+	s.previous = nil
+}
+
+// SetBranchPriority sets the main branch, e.g. "trunk", and the long-lived branch names or patterns,
+// e.g. "develop" and "release/*", which are prioritized when determining the commit branches.
+// If no main branch is set, the default branch of the origin remote, or main and master, is used
+func (s *repoService) SetBranchPriority(mainBranch string, longLivedBranches []string) {
+	s.freshMutex.Lock()
+	defer s.freshMutex.Unlock()
+	s.mainBranch = mainBranch
+	s.longLivedBranches = longLivedBranches
+}
+
+// SetSubjectParsers sets the user defined merge subject parsers, which are tried before the
+// built-in parsers of hosting providers, e.g. GitHub, and the git merge subjects
+func (s *repoService) SetSubjectParsers(parsers []SubjectParser) error {
+	subjectParsers, err := newSubjectParsers(parsers)
+	if err != nil {
+		return err
+	}
+	s.freshMutex.Lock()
+	defer s.freshMutex.Unlock()
+	s.subjectParsers = parsers
+	s.branchesService.branchNames.setSubjectParsers(subjectParsers)
+	return nil
+}
+
+// LoadMoreHistory increases the history depth to load the next chunk of older commits of a
+// partial log and triggers a refresh. Returns the new depth or false if the log is not partial
+func (s *repoService) LoadMoreHistory() (int, bool) {
+	s.freshMutex.Lock()
+	if s.previous == nil || !s.previous.repo.isPartialLog() {
+		s.freshMutex.Unlock()
+		return 0, false
+	}
+	s.historyDepth += partialMax
+	s.previous = nil
+	depth := s.historyDepth
+	s.freshMutex.Unlock()
+
+	s.TriggerManualRefresh()
+	return depth, true
+}
+
 func (s *repoService) fetchRoutine(ctx context.Context) {
 	fetchTicker := time.NewTicker(fetchInterval)
 	go func() {
@@ -394,11 +788,8 @@ func (s *repoService) fetchRoutine(ctx context.Context) {
 	}
 }
 
-func (t *repoService) getGitRepo(maxCommitCount int) (gitRepo, error) {
-	commits, err := t.git.GetLogMax(maxCommitCount)
-	if err != nil {
-		return gitRepo{}, err
-	}
+// getGitRepo returns the git repo, where only new commits are read, if there is a previous repo
+func (t *repoService) getGitRepo(previous *previousRepo) (gitRepo, error) {
 	branches, err := t.git.GetBranches()
 	if err != nil {
 		return gitRepo{}, err
@@ -411,18 +802,54 @@ func (t *repoService) getGitRepo(maxCommitCount int) (gitRepo, error) {
 	if err != nil {
 		return gitRepo{}, err
 	}
+	stashes, err := t.git.GetStashes()
+	if err != nil {
+		return gitRepo{}, err
+	}
+	remotes, err := t.git.GetRemotes()
+	if err != nil {
+		return gitRepo{}, err
+	}
+
+	var previousCommits []git.Commit
+	if previous != nil {
+		previousCommits = previous.gitRepo.Commits
+	}
+	commits, _, err := t.getCommits(previousCommits, refTips(branches, tags, stashes))
+	if err != nil {
+		return gitRepo{}, err
+	}
 	metaData := t.getMetaData()
 
 	return gitRepo{
-		RootPath: t.git.RepoPath(),
-		Commits:  commits,
-		Branches: branches,
-		Status:   status,
-		Tags:     tags,
-		MetaData: metaData,
+		RootPath:       t.git.RepoPath(),
+		Commits:        commits,
+		Branches:       branches,
+		Status:         status,
+		Tags:           tags,
+		Stashes:        stashes,
+		Remotes:        remotes,
+		MetaData:       metaData,
+		BranchPriority: t.getBranchPriority(remotes),
+		SubjectParsers: t.subjectParsers,
 	}, nil
 }
 
+// getBranchPriority returns the branch priority, where the main branch is the configured main
+// branch or the default branch of the origin remote (refs/remotes/origin/HEAD), if not configured
+func (t *repoService) getBranchPriority(remotes []git.Remote) BranchPriority {
+	mainBranch := t.mainBranch
+	if mainBranch == "" {
+		name, err := t.git.GetDefaultBranch()
+		if err != nil {
+			log.Warnf("Failed to get default branch, %v", err)
+		}
+		mainBranch = name
+	}
+	remoteNames := lo.Map(remotes, func(v git.Remote, _ int) string { return v.Name })
+	return NewBranchPriority(mainBranch, t.longLivedBranches, remoteNames)
+}
+
 func (t *repoService) getMetaData() MetaData {
 	metaDataText, err := t.git.GetKeyValue(metaDataKey)
 	if err != nil {
@@ -520,3 +947,31 @@ func (t *repoService) UnsetAsParentBranch(name string) error {
 
 	return t.setMetaData(metaData)
 }
+
+// SetBranchName names the unnamed or ambiguous branch with the tip commit in the shared meta data,
+// or removes the name, if the name is empty. Names of existing git branches are not allowed
+func (t *repoService) SetBranchName(tipID, name string) error {
+	if name != "" {
+		branches, err := t.git.GetBranches()
+		if err != nil {
+			return err
+		}
+		for _, b := range branches {
+			if b.Name == name || b.DisplayName == name {
+				return fmt.Errorf("branch %q already exists", name)
+			}
+		}
+	}
+
+	metaData := t.getMetaData()
+	if name == "" {
+		if _, ok := metaData.BranchNames[tipID]; !ok {
+			return nil
+		}
+		delete(metaData.BranchNames, tipID)
+	} else {
+		metaData.BranchNames[tipID] = name
+	}
+
+	return t.setMetaData(metaData)
+}
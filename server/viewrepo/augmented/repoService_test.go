@@ -2,7 +2,9 @@ package augmented
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/michael-reichenauer/gmc/utils"
 	"github.com/michael-reichenauer/gmc/utils/git"
@@ -55,3 +57,240 @@ func CurrentRoot() string {
 	}
 	return root
 }
+
+func TestLoadMoreHistory(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	path := wf.MkDir("repo").Path()
+	g := git.New(path)
+	assert.NoError(t, g.InitRepo())
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	for i := 0; i < 8; i++ {
+		wf.File("repo", "a.txt").Write(fmt.Sprintf("%d", i))
+		runGitAt(t, path, int64(1600000000+i*60), "add", ".")
+		runGitAt(t, path, int64(1600000000+i*60), "commit", "-q", "-m", fmt.Sprintf("commit %d", i))
+		if i == 3 {
+			runGitAt(t, path, int64(1600000000+i*60), "branch", "-M", "main")
+			assert.NoError(t, g.CreateBranch("feature"))
+		}
+	}
+
+	// Partial log with 5 commits and the partial log commit
+	s := NewRepoService(path).(*repoService)
+	s.SetHistoryDepth(5)
+	partialRepo, err := s.GetFreshRepo()
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(partialRepo.Commits))
+	assert.Equal(t, git.PartialLogCommitID, partialRepo.Commits[5].Id)
+
+	// All commits, where the previously shown commits have same branches
+	depth, ok := s.LoadMoreHistory()
+	assert.True(t, ok)
+	assert.Equal(t, 5+partialMax, depth)
+	repo, err := s.GetFreshRepo()
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(repo.Commits))
+	for i, c := range partialRepo.Commits[:5] {
+		assert.Equal(t, c.Id, repo.Commits[i].Id)
+		assert.Equal(t, c.Branch.Name, repo.Commits[i].Branch.Name)
+	}
+
+	// No more history, when the log is not partial
+	_, ok = s.LoadMoreHistory()
+	assert.False(t, ok)
+	assert.Equal(t, 5+partialMax, s.historyDepth)
+}
+
+func TestLoadMoreHistoryWithBranches(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	path := wf.MkDir("repo").Path()
+	g := git.New(path)
+	assert.NoError(t, g.InitRepo())
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	tm := int64(1600000000)
+	commit := func(file, subject string) {
+		tm += 60
+		wf.File("repo", file).Write(subject)
+		runGitAt(t, path, tm, "add", ".")
+		runGitAt(t, path, tm, "commit", "-q", "-m", subject)
+	}
+
+	// A merged feature branch and an open branch, which start below the partial log
+	commit("a.txt", "initial")
+	commit("a.txt", "main 1")
+	runGitAt(t, path, tm, "branch", "feature")
+	runGitAt(t, path, tm, "branch", "open")
+	commit("a.txt", "main 2")
+	runGitAt(t, path, tm, "checkout", "-q", "feature")
+	commit("feature.txt", "feature 1")
+	commit("feature.txt", "feature 2")
+	runGitAt(t, path, tm, "checkout", "-q", "open")
+	commit("open.txt", "open 1")
+	runGitAt(t, path, tm, "checkout", "-q", "master")
+	commit("a.txt", "main 3")
+	runGitAt(t, path, tm, "merge", "-q", "--no-ff", "-m", "Merge branch 'feature'", "feature")
+	commit("a.txt", "main 4")
+
+	s := NewRepoService(path).(*repoService)
+	s.SetHistoryDepth(5)
+	partialRepo, err := s.GetFreshRepo()
+	assert.NoError(t, err)
+	partial := partialRepo.Commits[len(partialRepo.Commits)-1]
+	assert.Equal(t, git.PartialLogCommitID, partial.Id)
+	assert.Equal(t, "master", partial.Branch.Name)
+
+	depth, ok := s.LoadMoreHistory()
+	assert.True(t, ok)
+	assert.Equal(t, 5+partialMax, depth)
+	repo, err := s.GetFreshRepo()
+	assert.NoError(t, err)
+	assert.Equal(t, 9, len(repo.Commits))
+	_, ok = repo.TryGetCommitByID(git.PartialLogCommitID)
+	assert.False(t, ok)
+
+	// The previously shown commits keep their branches and parent branches
+	for _, pc := range partialRepo.Commits[:len(partialRepo.Commits)-1] {
+		c, ok := repo.TryGetCommitByID(pc.Id)
+		assert.True(t, ok)
+		assert.Equal(t, pc.Branch.Name, c.Branch.Name, pc.Subject)
+		if pc.Branch.ParentBranch != nil {
+			assert.Equal(t, pc.Branch.ParentBranch.Name, c.Branch.ParentBranch.Name, pc.Subject)
+		}
+	}
+	for _, c := range repo.Commits {
+		switch c.Subject {
+		case "feature 1", "feature 2":
+			assert.Equal(t, "feature", c.Branch.Name, c.Subject)
+		case "open 1":
+			assert.Equal(t, "open", c.Branch.Name, c.Subject)
+		default:
+			assert.Equal(t, "master", c.Branch.Name, c.Subject)
+		}
+	}
+}
+
+func TestSquashMergedBranches(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	path := wf.MkDir("repo").Path()
+	g := git.New(path)
+	assert.NoError(t, g.InitRepo())
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	tm := int64(1600000000)
+	commit := func(file, subject string) {
+		tm += 60
+		wf.File("repo", file).Write(subject)
+		runGitAt(t, path, tm, "add", ".")
+		runGitAt(t, path, tm, "commit", "-q", "-m", subject)
+	}
+
+	// A feature branch, which is squash merged into master, and an open branch
+	commit("a.txt", "initial")
+	runGitAt(t, path, tm, "checkout", "-q", "-b", "feature")
+	commit("feature.txt", "feature 1")
+	commit("feature.txt", "feature 2")
+	runGitAt(t, path, tm, "checkout", "-q", "-b", "open", "master")
+	commit("open.txt", "open 1")
+	runGitAt(t, path, tm, "checkout", "-q", "master")
+	runGitAt(t, path, tm, "merge", "-q", "--squash", "feature")
+	runGitAt(t, path, tm, "commit", "-q", "-m", "Feature (#1)")
+
+	// Detected in the background after the first fresh repo
+	s := NewRepoService(path).(*repoService)
+	repo, err := s.GetFreshRepo()
+	assert.NoError(t, err)
+	assert.Empty(t, repo.SquashMerged)
+	waitSquashMerged(t, s)
+	repo, err = s.GetFreshRepo()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"feature": true}, repo.SquashMerged)
+
+	// Still merged, when master has new commits
+	commit("a.txt", "later")
+	repo, err = s.GetFreshRepo()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"feature": true}, repo.SquashMerged)
+}
+
+// waitSquashMerged waits for the background detection of squash merged branches
+func waitSquashMerged(t *testing.T, s *repoService) {
+	select {
+	case <-s.squashMerged.changes:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "no squash merged branches detected")
+	}
+}
+
+func TestUserBranchName(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	serverPath := wf.MkDir("server").Path()
+	runGitAt(t, serverPath, 0, "init", "-q", "--bare", "-b", "master")
+	path := wf.Path("repo")
+	assert.NoError(t, git.New(wf.Path()).Clone(serverPath, path))
+	g := git.New(path)
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	tm := int64(1600000000)
+	commit := func(file, subject string) {
+		tm += 60
+		wf.File("repo", file).Write(subject)
+		runGitAt(t, path, tm, "add", ".")
+		runGitAt(t, path, tm, "commit", "-q", "-m", subject)
+	}
+
+	// A deleted branch, which was merged with a subject without a branch name
+	commit("a.txt", "initial")
+	runGitAt(t, path, tm, "checkout", "-q", "-b", "x")
+	commit("x.txt", "x 1")
+	commit("x.txt", "x 2")
+	runGitAt(t, path, tm, "checkout", "-q", "master")
+	commit("a.txt", "master 1")
+	runGitAt(t, path, tm, "merge", "-q", "--no-ff", "-m", "Integrate", "x")
+	runGitAt(t, path, tm, "branch", "-q", "-D", "x")
+
+	s := NewRepoService(path)
+	repo, err := s.GetFreshRepo()
+	assert.NoError(t, err)
+	tip := repo.SearchCommits("x 2")[0]
+	assert.Equal(t, "branch@"+tip.Sid, tip.Branch.DisplayName)
+
+	// Names of existing branches are not allowed
+	assert.Error(t, s.SetBranchName(tip.Id, "master"))
+
+	// Name the unnamed branch
+	assert.NoError(t, s.SetBranchName(tip.Id, "feature/x"))
+	repo, err = s.GetFreshRepo()
+	assert.NoError(t, err)
+	tip = repo.SearchCommits("x 2")[0]
+	assert.Equal(t, "feature/x", tip.Branch.DisplayName)
+	assert.True(t, tip.Branch.IsUserNamed)
+	assert.Equal(t, ruleUserBranchName, tip.BranchRule)
+	bottom := repo.SearchCommits("x 1")[0]
+	assert.Equal(t, tip.Branch, bottom.Branch)
+
+	// The name is shared with other clones of the repo
+	otherPath := wf.Path("other")
+	assert.NoError(t, git.New(wf.Path()).Clone(serverPath, otherPath))
+	other := git.New(otherPath)
+	assert.NoError(t, other.PullKeyValue(metaDataKey))
+	metaDataText, err := other.GetKeyValue(metaDataKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "feature/x", toMetaData(metaDataText).BranchNames[tip.Id])
+
+	// Still user named, when a branch with the same name is created later, so it can be removed
+	runGitAt(t, path, tm, "branch", "feature/x", tip.Id)
+	repo, err = s.GetFreshRepo()
+	assert.NoError(t, err)
+	tip = repo.SearchCommits("x 2")[0]
+	assert.True(t, tip.Branch.IsUserNamed)
+	runGitAt(t, path, tm, "branch", "-q", "-D", "feature/x")
+
+	// Remove the name
+	assert.NoError(t, s.SetBranchName(tip.Id, ""))
+	repo, err = s.GetFreshRepo()
+	assert.NoError(t, err)
+	tip = repo.SearchCommits("x 2")[0]
+	assert.Equal(t, "branch@"+tip.Sid, tip.Branch.DisplayName)
+	assert.False(t, tip.Branch.IsUserNamed)
+}
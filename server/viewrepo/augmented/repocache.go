@@ -0,0 +1,344 @@
+package augmented
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/michael-reichenauer/gmc/utils"
+	"github.com/michael-reichenauer/gmc/utils/git"
+	"github.com/michael-reichenauer/gmc/utils/linq"
+	"github.com/michael-reichenauer/gmc/utils/log"
+	"github.com/michael-reichenauer/gmc/utils/timer"
+)
+
+// repoCache stores the latest fresh repo of a repo path in a cache file, which is used to show the
+// repo immediately, when the repo is opened the next time, while the fresh repo is read
+type repoCache struct {
+	path           string // Cache file path, named by a hash of the repo path
+	programVersion string // Cached repos of other program versions are ignored
+
+	mutex     sync.Mutex
+	written   *previousRepo  // The latest serialized repo, which is written or about to be written
+	pending   []byte         // Serialized repo to write, when the running write is done
+	isWriting bool           // If the write routine is running
+	writes    sync.WaitGroup // Running write routine
+}
+
+// cachedRepoFile is the cache file content, where the git repo is used as previous repo and the
+// commit branches and branches are used to recreate the augmented repo without determining the
+// branches again. Branches are referenced by index, since commits and branches refer to each other
+type cachedRepoFile struct {
+	ProgramVersion string
+	HistoryDepth   int
+	GitRepo        gitRepo
+	Commits        []cachedCommit // Same order as git commits, possibly with a partial commit last
+	Branches       []cachedBranch // The repo branches first, then e.g. merged ambiguous branches
+	RepoBranches   int            // Number of repo branches
+}
+
+type cachedCommit struct {
+	ParentIDs      []string `json:",omitempty"` // If not same as the git commit, e.g. a pull merge
+	Branch         int
+	Branches       []int
+	BranchTipNames []string `json:",omitempty"`
+	IsCurrent      bool     `json:",omitempty"`
+	IsLikely       bool     `json:",omitempty"`
+	IsAmbiguous    bool     `json:",omitempty"`
+	IsAmbiguousTip bool     `json:",omitempty"`
+	BranchRule     string
+	Candidates     []string `json:",omitempty"`
+}
+
+type cachedBranch struct {
+	Branch
+	ParentBranch      int // Shadows the Branch.ParentBranch pointer, -1 if no parent
+	AmbiguousBranches []int
+}
+
+func newRepoCache(folder, repoPath, programVersion string) *repoCache {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(repoPath))
+	return &repoCache{
+		path:           filepath.Join(folder, fmt.Sprintf("%x.json", h.Sum64())),
+		programVersion: programVersion,
+	}
+}
+
+// read returns the cached repo of the repo path, if it was cached by the same program version and
+// with the same history depth
+func (c *repoCache) read(repoPath string, historyDepth int) (*previousRepo, error) {
+	st := timer.Start()
+	if !utils.FileExists(c.path) {
+		return nil, fmt.Errorf("no cached repo for %s", repoPath)
+	}
+	bytes, err := utils.FileRead(c.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached repo %s, %v", c.path, err)
+	}
+	var file cachedRepoFile
+	if err := json.Unmarshal(bytes, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse cached repo %s, %v", c.path, err)
+	}
+	if file.ProgramVersion != c.programVersion || file.GitRepo.RootPath != repoPath ||
+		file.HistoryDepth != historyDepth {
+		return nil, fmt.Errorf("cached repo %s is for %s (%s)", c.path, file.GitRepo.RootPath, file.ProgramVersion)
+	}
+
+	repo, err := file.toRepo()
+	if err != nil {
+		return nil, fmt.Errorf("invalid cached repo %s, %v", c.path, err)
+	}
+	log.Infof("Read cached repo %v: %d commits, %d branches", st, len(repo.Commits), len(repo.Branches))
+	cached := &previousRepo{gitRepo: file.GitRepo, repo: repo}
+	c.mutex.Lock()
+	c.written = cached
+	c.mutex.Unlock()
+	return cached, nil
+}
+
+// write serializes the repo and writes it to the cache file in the background, unless the same
+// commits, branches and refs were written. The repo is serialized before returning, since the next
+// fresh repo might reuse and modify the commits and branches
+func (c *repoCache) write(p *previousRepo, historyDepth int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.written.isSameCommitBranches(p.gitRepo) && isSameTagsAndStashes(c.written.gitRepo, p.gitRepo) {
+		return
+	}
+
+	bytes, err := json.Marshal(toCachedRepoFile(p, historyDepth, c.programVersion))
+	if err != nil {
+		log.Warnf("Failed to serialize cached repo, %v", err)
+		return
+	}
+	c.written = p
+	c.pending = bytes
+	if c.isWriting {
+		return
+	}
+	c.isWriting = true
+	c.writes.Add(1)
+	go c.writeRoutine()
+}
+
+// wait waits until the pending writes are done
+func (c *repoCache) wait() {
+	c.writes.Wait()
+}
+
+// writeRoutine writes the pending serialized repos, where only the latest pending repo is written
+// if several repos were serialized, while a write was running
+func (c *repoCache) writeRoutine() {
+	defer c.writes.Done()
+	for {
+		c.mutex.Lock()
+		bytes := c.pending
+		c.pending = nil
+		if bytes == nil {
+			c.isWriting = false
+			c.mutex.Unlock()
+			return
+		}
+		c.mutex.Unlock()
+
+		c.writeFile(bytes)
+	}
+}
+
+func (c *repoCache) writeFile(bytes []byte) {
+	st := timer.Start()
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		log.Warnf("Failed to create cache folder, %v", err)
+		return
+	}
+	// Write a temp file, which is then renamed, to never leave a partially written cache file
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, bytes, 0600); err != nil {
+		log.Warnf("Failed to write cached repo %s, %v", tmpPath, err)
+		return
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		log.Warnf("Failed to write cached repo %s, %v", c.path, err)
+		return
+	}
+	log.Infof("Wrote cached repo %v: %d bytes to %s", st, len(bytes), c.path)
+}
+
+// isSameTagsAndStashes returns true if the git repos have the same tags and stashes (stash ids),
+// i.e. the cached status may be older, but it is refreshed as well when the repo is opened
+func isSameTagsAndStashes(gr1, gr2 gitRepo) bool {
+	stashIDs := func(stashes []git.Stash) []string {
+		return linq.Map(stashes, func(v git.Stash) string { return v.ID })
+	}
+	return reflect.DeepEqual(gr1.Tags, gr2.Tags) && reflect.DeepEqual(stashIDs(gr1.Stashes), stashIDs(gr2.Stashes))
+}
+
+func toCachedRepoFile(p *previousRepo, historyDepth int, programVersion string) cachedRepoFile {
+	// Index the repo branches first and then other referenced branches
+	branches := make([]*Branch, 0, len(p.repo.Branches))
+	indexes := make(map[*Branch]int)
+	index := func(b *Branch) int {
+		if b == nil {
+			return -1
+		}
+		i, ok := indexes[b]
+		if !ok {
+			i = len(branches)
+			indexes[b] = i
+			branches = append(branches, b)
+		}
+		return i
+	}
+	toIndexes := func(bs []*Branch) []int {
+		is := make([]int, len(bs))
+		for i, b := range bs {
+			is[i] = index(b)
+		}
+		return is
+	}
+	for _, b := range p.repo.Branches {
+		index(b)
+	}
+
+	commits := make([]cachedCommit, len(p.repo.Commits))
+	for i, c := range p.repo.Commits {
+		commits[i] = cachedCommit{
+			Branch:         index(c.Branch),
+			Branches:       toIndexes(c.Branches),
+			BranchTipNames: c.BranchTipNames,
+			IsCurrent:      c.IsCurrent,
+			IsLikely:       c.isLikely,
+			IsAmbiguous:    c.IsAmbiguous,
+			IsAmbiguousTip: c.IsAmbiguousTip,
+			BranchRule:     c.BranchRule,
+			Candidates:     c.BranchCandidates,
+		}
+		if i < len(p.gitRepo.Commits) && !reflect.DeepEqual(c.ParentIDs, p.gitRepo.Commits[i].ParentIDs) {
+			commits[i].ParentIDs = c.ParentIDs
+		}
+	}
+
+	// Branches may be appended while indexing, e.g. ambiguous branches of other branches
+	cachedBranches := make([]cachedBranch, 0, len(branches))
+	for i := 0; i < len(branches); i++ {
+		b := branches[i]
+		cb := cachedBranch{Branch: *b, ParentBranch: index(b.ParentBranch), AmbiguousBranches: toIndexes(b.AmbiguousBranches)}
+		cb.Branch.ParentBranch = nil
+		cb.Branch.AmbiguousBranches = nil
+		cachedBranches = append(cachedBranches, cb)
+	}
+
+	return cachedRepoFile{
+		ProgramVersion: programVersion,
+		HistoryDepth:   historyDepth,
+		GitRepo:        p.gitRepo,
+		Commits:        commits,
+		Branches:       cachedBranches,
+		RepoBranches:   len(p.repo.Branches),
+	}
+}
+
+// toRepo recreates the repo, with the same commits and branches as the cached repo
+func (f cachedRepoFile) toRepo() (Repo, error) {
+	if len(f.Commits) != len(f.GitRepo.Commits) && len(f.Commits) != len(f.GitRepo.Commits)+1 ||
+		f.RepoBranches > len(f.Branches) {
+		return Repo{}, fmt.Errorf("%d commits, %d git commits, %d branches", len(f.Commits), len(f.GitRepo.Commits), len(f.Branches))
+	}
+
+	branches := make([]*Branch, len(f.Branches))
+	for i := range f.Branches {
+		b := f.Branches[i].Branch
+		branches[i] = &b
+	}
+	branch := func(i int) (*Branch, error) {
+		if i == -1 {
+			return nil, nil
+		}
+		if i < 0 || i >= len(branches) {
+			return nil, fmt.Errorf("invalid branch index %d", i)
+		}
+		return branches[i], nil
+	}
+	toBranches := func(is []int) ([]*Branch, error) {
+		var bs []*Branch
+		for _, i := range is {
+			b, err := branch(i)
+			if err != nil || b == nil {
+				return nil, fmt.Errorf("invalid branch index %d", i)
+			}
+			bs = append(bs, b)
+		}
+		return bs, nil
+	}
+
+	var err error
+	for i, cb := range f.Branches {
+		if branches[i].ParentBranch, err = branch(cb.ParentBranch); err != nil {
+			return Repo{}, err
+		}
+		if branches[i].AmbiguousBranches, err = toBranches(cb.AmbiguousBranches); err != nil {
+			return Repo{}, err
+		}
+	}
+
+	repo := newRepo()
+	repo.RepoPath = f.GitRepo.RootPath
+	repo.MetaData = f.GitRepo.MetaData
+	repo.BranchPriority = f.GitRepo.BranchPriority
+	repo.Branches = branches[:f.RepoBranches]
+	repo.Commits = make([]*Commit, len(f.Commits))
+	for i, cc := range f.Commits {
+		var c *Commit
+		if i < len(f.GitRepo.Commits) {
+			c = newGitCommit(f.GitRepo.Commits[i])
+		} else {
+			c = newPartialLogCommit()
+		}
+		if cc.ParentIDs != nil {
+			c.ParentIDs = cc.ParentIDs
+		}
+		if c.Branch, err = branch(cc.Branch); err != nil {
+			return Repo{}, err
+		}
+		if c.Branches, err = toBranches(cc.Branches); err != nil {
+			return Repo{}, err
+		}
+		c.BranchTipNames = cc.BranchTipNames
+		c.IsCurrent = cc.IsCurrent
+		c.isLikely = cc.IsLikely
+		c.IsAmbiguous = cc.IsAmbiguous
+		c.IsAmbiguousTip = cc.IsAmbiguousTip
+		c.BranchRule = cc.BranchRule
+		c.BranchCandidates = cc.Candidates
+		repo.Commits[i] = c
+		repo.commitById[c.Id] = c
+	}
+
+	// Set parents and children in the same order as when branches were determined
+	for _, c := range repo.Commits {
+		for i, id := range c.ParentIDs {
+			parent, ok := repo.TryGetCommitByID(id)
+			if !ok {
+				return Repo{}, fmt.Errorf("no parent %s of %s", id, c.Id)
+			}
+			if i == 0 {
+				c.FirstParent = parent
+				parent.Children = append(parent.Children, c)
+			} else if i == 1 {
+				c.MergeParent = parent
+				parent.MergeChildren = append(parent.MergeChildren, c)
+			} else {
+				continue
+			}
+			parent.ChildIDs = append(parent.ChildIDs, c.Id)
+		}
+	}
+
+	repo.setGitStatusAndRefs(f.GitRepo)
+	return *repo, nil
+}
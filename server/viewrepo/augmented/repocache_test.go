@@ -0,0 +1,108 @@
+package augmented
+
+import (
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/git"
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedRepoSameAsFreshRepo(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	path := wf.MkDir("repo").Path()
+	cacheFolder := wf.Path("cache")
+	g := git.New(path)
+	assert.NoError(t, g.InitRepo())
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	tm := int64(1600000000)
+	commit := func(name string) {
+		tm += 60
+		wf.File("repo", name+".txt").Write(name)
+		runGitAt(t, path, tm, "add", ".")
+		runGitAt(t, path, tm, "commit", "-q", "-m", name)
+	}
+
+	// A repo with a merged feature branch, a branch, a tag and a pull merge
+	commit("initial")
+	runGitAt(t, path, tm, "branch", "-M", "main")
+	assert.NoError(t, g.CreateBranch("feature"))
+	commit("feature1")
+	assert.NoError(t, g.Checkout("main"))
+	commit("main1")
+	runGitAt(t, path, tm+60, "merge", "--no-ff", "-q", "-m", "Merge branch 'feature' into main", "feature")
+	tm += 60
+	runGitAt(t, path, tm, "branch", "other", "HEAD~1")
+	runGitAt(t, path, tm, "tag", "v1")
+	runGitAt(t, path, tm, "checkout", "-q", "other")
+	commit("other1")
+	runGitAt(t, path, tm+60, "merge", "--no-ff", "-q", "-m", "Merge branch 'other' of https://host/repo into other", "main")
+	tm += 60
+
+	// Cache the fresh repo and read the cached repo, when the repo is opened again
+	s := newCachedRepoService(path, cacheFolder, "1.0")
+	freshRepo, err := s.GetFreshRepo()
+	assert.NoError(t, err)
+	s.cache.wait()
+
+	s2 := newCachedRepoService(path, cacheFolder, "1.0")
+	cachedRepo, ok := s2.getCachedRepo()
+	assert.True(t, ok)
+	assert.Equal(t, repoText(freshRepo), repoText(cachedRepo))
+	assert.Equal(t, freshRepo.Status, cachedRepo.Status)
+	assert.Equal(t, freshRepo.MetaData, cachedRepo.MetaData)
+
+	// The fresh repo reuses the cached commits and branches, when nothing has changed
+	repo, err := s2.GetFreshRepo()
+	assert.NoError(t, err)
+	assert.Same(t, cachedRepo.Commits[0], repo.Commits[0])
+
+	// A partial log, where the cached repo has a partial log commit
+	s = newCachedRepoService(path, cacheFolder, "1.0")
+	s.SetHistoryDepth(3)
+	freshRepo, err = s.GetFreshRepo()
+	assert.NoError(t, err)
+	assert.Equal(t, git.PartialLogCommitID, freshRepo.Commits[len(freshRepo.Commits)-1].Id)
+	s.cache.wait()
+	s2 = newCachedRepoService(path, cacheFolder, "1.0")
+	s2.SetHistoryDepth(3)
+	cachedRepo, ok = s2.getCachedRepo()
+	assert.True(t, ok)
+	assert.Equal(t, repoText(freshRepo), repoText(cachedRepo))
+
+	// Not cached for another history depth or program version
+	_, ok = newCachedRepoService(path, cacheFolder, "1.0").getCachedRepo()
+	assert.False(t, ok)
+	s2 = newCachedRepoService(path, cacheFolder, "2.0")
+	s2.SetHistoryDepth(3)
+	_, ok = s2.getCachedRepo()
+	assert.False(t, ok)
+
+	// The cached repo is not shown, when branches or meta data have changed, but it is used as the
+	// previous repo, so only new commits are read
+	s = newCachedRepoService(path, cacheFolder, "1.0")
+	_, err = s.GetFreshRepo()
+	assert.NoError(t, err)
+	s.cache.wait()
+	commit("other2")
+	s2 = newCachedRepoService(path, cacheFolder, "1.0")
+	_, ok = s2.getCachedRepo()
+	assert.False(t, ok)
+	assertSameAsFullRepo(t, s2, true)
+
+	_, err = s.GetFreshRepo()
+	assert.NoError(t, err)
+	s.cache.wait()
+	_, ok = newCachedRepoService(path, cacheFolder, "1.0").getCachedRepo()
+	assert.True(t, ok)
+	assert.NoError(t, g.SetKeyValue(metaDataKey, `{"BranchesChildren":{"main":["other"]}}`))
+	_, ok = newCachedRepoService(path, cacheFolder, "1.0").getCachedRepo()
+	assert.False(t, ok)
+}
+
+func newCachedRepoService(path, cacheFolder, programVersion string) *repoService {
+	s := NewRepoService(path).(*repoService)
+	s.EnableCache(cacheFolder, programVersion)
+	return s
+}
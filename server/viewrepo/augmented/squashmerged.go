@@ -0,0 +1,250 @@
+package augmented
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/michael-reichenauer/gmc/utils/git"
+	"github.com/michael-reichenauer/gmc/utils/log"
+)
+
+// squashMergedService detects branches, which were squash merged or rebased into their target
+// branch, e.g. a pull request on the server, where the branch commits are not part of the target
+// branch, but the changes are (compared by git patch ids). The detection runs in the background,
+// since it runs several git commands for each branch, and signals changes when it is done
+type squashMergedService struct {
+	git     git.Git
+	changes chan struct{} // Signaled, when the background detection found merged branches
+
+	lock      sync.Mutex
+	bases     map[string]string             // Merge bases by branch tip id and target tip id
+	results   map[string]squashMergedResult // Results by branch tip id and merge base
+	pending   []squashMergedBranch          // Branches to detect, when the running detection is done
+	isRunning bool
+}
+
+// squashMergedBranch is a branch, which might be squash merged into the target branch
+type squashMergedBranch struct {
+	name     string
+	tipID    string
+	target   string
+	targetID string
+}
+
+type squashMergedResult struct {
+	isMerged bool
+	targetID string // The target tip, which was compared, newer target commits are compared later
+}
+
+func newSquashMergedService(g git.Git) *squashMergedService {
+	return &squashMergedService{
+		git:     g,
+		changes: make(chan struct{}, 1),
+		bases:   make(map[string]string),
+		results: make(map[string]squashMergedResult),
+	}
+}
+
+// squashMergedBranches returns the names of the branches, which are known to be squash merged,
+// and starts the background detection of the branches, which are not yet known
+func (t *squashMergedService) squashMergedBranches(repo *Repo) map[string]bool {
+	branches := t.candidates(repo)
+	t.detectInBackground(branches)
+	return t.knownMerged(branches)
+}
+
+// knownMergedBranches returns the names of the branches, which are known to be squash merged,
+// e.g. when the background detection is done
+func (t *squashMergedService) knownMergedBranches(repo *Repo) map[string]bool {
+	return t.knownMerged(t.candidates(repo))
+}
+
+func (t *squashMergedService) knownMerged(branches []squashMergedBranch) map[string]bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	merged := make(map[string]bool)
+	for _, b := range branches {
+		base, ok := t.bases[b.tipID+":"+b.targetID]
+		if !ok {
+			// The target has changed, e.g. after a fetch, a merged branch is still merged
+			if t.isMergedTip(b.tipID) {
+				merged[b.name] = true
+			}
+			continue
+		}
+		if result, ok := t.results[b.tipID+":"+base]; ok && result.isMerged {
+			merged[b.name] = true
+		}
+	}
+	return merged
+}
+
+func (t *squashMergedService) isMergedTip(tipID string) bool {
+	for key, result := range t.results {
+		if result.isMerged && strings.HasPrefix(key, tipID+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// candidates returns git branches, which are not main or long-lived branches and whose tip commit
+// is not on a main or long-lived branch (e.g. a normal merge)
+func (t *squashMergedService) candidates(repo *Repo) []squashMergedBranch {
+	branches := []squashMergedBranch{}
+	for _, b := range repo.Branches {
+		if !b.IsGitBranch || repo.BranchPriority.IsPrioritized(b.Name) {
+			continue
+		}
+		tip, ok := repo.TryGetCommitByID(b.TipID)
+		if !ok || repo.BranchPriority.IsPrioritized(tip.Branch.Name) {
+			continue
+		}
+		target, ok := t.targetBranch(repo, b)
+		if !ok {
+			continue
+		}
+		branches = append(branches, squashMergedBranch{
+			name: b.Name, tipID: b.TipID, target: target.Name, targetID: target.TipID})
+	}
+	return branches
+}
+
+// detectInBackground starts the detection of the branches, or if a detection is running, the
+// branches are detected, when the running detection is done
+func (t *squashMergedService) detectInBackground(branches []squashMergedBranch) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.pending = branches
+	if t.isRunning {
+		return
+	}
+	t.isRunning = true
+	go t.detectRoutine()
+}
+
+func (t *squashMergedService) detectRoutine() {
+	for {
+		t.lock.Lock()
+		branches := t.pending
+		t.pending = nil
+		if branches == nil {
+			t.isRunning = false
+			t.lock.Unlock()
+			return
+		}
+		t.lock.Unlock()
+
+		if t.detect(branches) {
+			select {
+			case t.changes <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// detect detects if the branches are squash merged, where only target commits, which are newer
+// than the previously compared target commits are compared. Returns true if a branch is merged
+func (t *squashMergedService) detect(branches []squashMergedBranch) bool {
+	bases := make(map[string]string)
+	results := make(map[string]squashMergedResult)
+	isChanged := false
+	for _, b := range branches {
+		baseKey := b.tipID + ":" + b.targetID
+		base, ok := t.getBase(baseKey)
+		if !ok {
+			var err error
+			base, err = t.git.MergeBase(b.name, b.target)
+			if err != nil {
+				log.Warnf("Failed to check if %q is squash merged into %q, %v", b.name, b.target, err)
+				continue
+			}
+		}
+		bases[baseKey] = base
+
+		key := b.tipID + ":" + base
+		result, ok := t.getResult(key)
+		if ok && (result.isMerged || result.targetID == b.targetID) {
+			results[key] = result
+			continue
+		}
+		since := base
+		if ok {
+			since = result.targetID
+		}
+		isMerged, err := t.git.IsSquashMerged(b.name, b.target, since)
+		if err != nil && since != base {
+			// The previously compared target commit might no longer exist, e.g. after a force push
+			isMerged, err = t.git.IsSquashMerged(b.name, b.target, base)
+		}
+		if err != nil {
+			log.Warnf("Failed to check if %q is squash merged into %q, %v", b.name, b.target, err)
+			continue
+		}
+		results[key] = squashMergedResult{isMerged: isMerged, targetID: b.targetID}
+		isChanged = isChanged || isMerged
+	}
+
+	// Keep only results of current branches
+	t.lock.Lock()
+	t.bases = bases
+	t.results = results
+	t.lock.Unlock()
+	return isChanged
+}
+
+func (t *squashMergedService) getBase(key string) (string, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	base, ok := t.bases[key]
+	return base, ok
+}
+
+func (t *squashMergedService) getResult(key string) (squashMergedResult, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	result, ok := t.results[key]
+	return result, ok
+}
+
+// targetBranch returns the closest main or long-lived ancestor branch, or the main branch, where
+// the remote branch of the branch remote is preferred (e.g. "upstream/main" for a branch of a
+// fork), since pull requests are merged on the server
+func (t *squashMergedService) targetBranch(repo *Repo, b *Branch) (*Branch, bool) {
+	var target *Branch
+	for _, ab := range b.GetAncestors() {
+		if ab.IsGitBranch && repo.BranchPriority.IsPrioritized(ab.Name) {
+			target = ab
+			break
+		}
+	}
+	if target == nil {
+		for _, name := range repo.BranchPriority.MainNames() {
+			if mb, ok := repo.BranchByName(name); ok {
+				target = mb
+				break
+			}
+		}
+	}
+	if target == nil {
+		return nil, false
+	}
+
+	if b.Remote != "" {
+		localName := target.Name
+		if target.IsRemote {
+			localName = strings.TrimPrefix(target.Name, target.Remote+"/")
+		}
+		if rb, ok := repo.BranchByName(b.Remote + "/" + localName); ok {
+			return rb, true
+		}
+	}
+	if !target.IsRemote && target.RemoteName != "" {
+		if rb, ok := repo.BranchByName(target.RemoteName); ok {
+			return rb, true
+		}
+	}
+	return target, true
+}
@@ -0,0 +1,35 @@
+package augmented
+
+import (
+	"time"
+
+	"github.com/michael-reichenauer/gmc/utils/git"
+)
+
+type Stash struct {
+	Id       string
+	Sid      string
+	Name     string
+	Index    int
+	Branch   string
+	Message  string
+	ParentID string
+	Time     time.Time
+}
+
+func toStashes(gitStashes []git.Stash) []Stash {
+	stashes := make([]Stash, len(gitStashes))
+	for i, s := range gitStashes {
+		stashes[i] = Stash{
+			Id:       s.ID,
+			Sid:      s.SID,
+			Name:     s.Name,
+			Index:    s.Index,
+			Branch:   s.Branch,
+			Message:  s.Message,
+			ParentID: s.ParentID,
+			Time:     s.Time,
+		}
+	}
+	return stashes
+}
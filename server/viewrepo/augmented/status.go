@@ -7,31 +7,47 @@ import (
 )
 
 type Status struct {
-	Modified     int
-	Added        int
-	Deleted      int
-	Conflicted   int
-	IsMerging    bool
-	MergeMessage string
+	Modified        int
+	Added           int
+	Deleted         int
+	Conflicted      int
+	IsMerging       bool
+	IsCherryPicking bool
+	IsRebasing      bool
+	MergeMessage    string
+	RebaseMessage   string
+	Staged          int
+	StagedFiles     []string
+	UnstagedFiles   []string
+	ConflictsFiles  []string
+	Submodules      int
 }
 
 func newStatus(gs git.Status) Status {
 	return Status{
-		Modified:     gs.Modified,
-		Added:        gs.Added,
-		Deleted:      gs.Deleted,
-		Conflicted:   gs.Conflicted,
-		IsMerging:    gs.IsMerging,
-		MergeMessage: gs.MergeMessage,
+		Modified:        gs.Modified,
+		Added:           gs.Added,
+		Deleted:         gs.Deleted,
+		Conflicted:      gs.Conflicted,
+		IsMerging:       gs.IsMerging,
+		IsCherryPicking: gs.IsCherryPicking,
+		IsRebasing:      gs.IsRebasing,
+		MergeMessage:    gs.MergeMessage,
+		RebaseMessage:   gs.RebaseMessage,
+		Staged:          gs.Staged,
+		StagedFiles:     gs.StagedFiles,
+		UnstagedFiles:   gs.UnstagedFiles,
+		ConflictsFiles:  gs.ConflictsFiles,
+		Submodules:      gs.Submodules,
 	}
 }
 
 func (s Status) OK() bool {
-	return s.AllChanges() == 0 && !s.IsMerging
+	return s.AllChanges() == 0 && !s.IsMerging && !s.IsCherryPicking && !s.IsRebasing
 }
 
 func (s Status) AllChanges() int {
-	return s.Modified + s.Added + s.Deleted + s.Conflicted
+	return s.Modified + s.Added + s.Deleted + s.Conflicted + s.Submodules
 }
 
 func (s *Status) String() string {
@@ -0,0 +1,108 @@
+package augmented
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// SubjectParser is a named regexp, which parses the branch names of a merge commit subject, where
+// the regexp has a named "from" group and an optional named "into" group, e.g.
+// `^Merged (?P<from>\S+) into (?P<into>\S+)`. A NoNames parser matches merge subjects without
+// branch names, e.g. "Merged PR 1234: Some title", which are then not parsed as git merge subjects
+type SubjectParser struct {
+	Name    string
+	RegExp  string
+	NoNames bool
+}
+
+// Merge subjects of hosting providers, which are tried before the git merge subjects. A user
+// defined parser with the same name replaces a built-in parser, or disables it if the regexp is empty
+var builtInSubjectParsers = []SubjectParser{
+	// "Merge pull request #12 from owner/feature/abc", where the owner is not part of the branch
+	{Name: "GitHub", RegExp: `^Merge pull request #\d+ from [^/\s]+/(?P<from>\S+)`},
+	// "Merge branch 'feature/abc' into 'main'", with "See merge request group/repo!12" in the body
+	{Name: "GitLab", RegExp: `^Merge branch '(?P<from>[^'\s]+)' into '(?P<into>[^'\s]+)'`},
+	// "Merged in feature/abc (pull request #12)" (Bitbucket Cloud)
+	{Name: "Bitbucket", RegExp: `^Merged in (?P<from>\S+) \(pull request #\d+\)`},
+	// "Merge pull request #12 in PROJ/repo from feature/abc to main" (Bitbucket Server)
+	{Name: "Bitbucket Server", RegExp: `^Merge pull request #\d+ in \S+ from (?P<from>\S+) to (?P<into>\S+)`},
+	// "Merge pull request 12 from feature/abc into main" (older Azure DevOps and TFS)
+	{Name: "Azure DevOps", RegExp: `^Merge pull request \d+ from (?P<from>\S+) into (?P<into>\S+)`},
+	// "Merged PR 1234: Some title", which has no branch names, e.g. "PR" is not a branch name
+	{Name: "Azure DevOps PR", RegExp: `^Merged PR \d+:`, NoNames: true},
+}
+
+type subjectParser struct {
+	name    string
+	regExp  *regexp.Regexp
+	from    int
+	into    int // -1 if the regexp has no "into" group
+	noNames bool
+}
+
+// newSubjectParsers returns the user defined parsers, which must have a named "from" group (unless
+// no names), followed by the built-in parsers, which are not replaced or disabled by user parsers
+func newSubjectParsers(userParsers []SubjectParser) ([]subjectParser, error) {
+	var parsers []subjectParser
+	userNames := make(map[string]bool)
+	for _, p := range userParsers {
+		userNames[p.Name] = true
+		if p.RegExp == "" {
+			// Disables the built-in parser with the same name
+			continue
+		}
+		parser, err := newSubjectParser(p)
+		if err != nil {
+			return nil, err
+		}
+		if parser.from == -1 && !parser.noNames {
+			return nil, fmt.Errorf("invalid merge subject parser %q, no named \"from\" group", p.Name)
+		}
+		parsers = append(parsers, parser)
+	}
+	for _, p := range builtInSubjectParsers {
+		if userNames[p.Name] {
+			continue
+		}
+		parsers = append(parsers, mustNewSubjectParser(p))
+	}
+	return parsers, nil
+}
+
+func newSubjectParser(p SubjectParser) (subjectParser, error) {
+	regExp, err := regexp.Compile(p.RegExp)
+	if err != nil {
+		return subjectParser{}, fmt.Errorf("invalid merge subject parser %q, %v", p.Name, err)
+	}
+	return subjectParser{name: p.Name, regExp: regExp, from: regExp.SubexpIndex("from"),
+		into: regExp.SubexpIndex("into"), noNames: p.NoNames}, nil
+}
+
+func mustNewSubjectParser(p SubjectParser) subjectParser {
+	parser, err := newSubjectParser(p)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}
+
+// parse returns the branch names of the subject and true, if the subject is matched, where a no
+// names parser returns no branch names
+func (t subjectParser) parse(subject string) (fromInto, bool) {
+	match := t.regExp.FindStringSubmatch(subject)
+	if match == nil {
+		return fromInto{}, false
+	}
+
+	fi := fromInto{parser: t.name}
+	if t.noNames {
+		return fi, true
+	}
+	if t.from != -1 {
+		fi.from = match[t.from]
+	}
+	if t.into != -1 {
+		fi.into = match[t.into]
+	}
+	return fi, true
+}
@@ -12,20 +12,25 @@ type branch struct {
 	bottomId             string
 	parentBranchName     string
 	remoteName           string
+	remote               string
 	localName            string
 	tip                  *commit
 	bottom               *commit
 	parentBranch         *branch
 	isGitBranch          bool
+	isMainBranch         bool
 	isAmbiguousBranch    bool
 	isRemote             bool
 	isCurrent            bool
 	isSetAsParent        bool
+	isSquashMerged       bool
+	isUserNamed          bool
 	HasLocalOnly         bool
 	HasRemoteOnly        bool
 	color                cui.Color
 	AmbiguousTipId       string
 	ambiguousBranchNames []string
+	worktreePath         string
 	x                    int
 }
 
@@ -4,7 +4,6 @@ import (
 	"sort"
 
 	"github.com/michael-reichenauer/gmc/server/viewrepo/augmented"
-	"github.com/michael-reichenauer/gmc/utils"
 	"github.com/michael-reichenauer/gmc/utils/git"
 )
 
@@ -42,7 +41,7 @@ func (t *ViewRepoService) getAugmentedBranches(branchNames []string, augmentedRe
 	branches = t.addLocalBranches(branches, augmentedRepo)
 	branches = t.addRemoteBranches(branches, augmentedRepo)
 
-	t.sortBranches(branches)
+	t.sortBranches(branches, augmentedRepo.BranchPriority)
 	return branches
 }
 
@@ -117,7 +116,7 @@ func (t *ViewRepoService) containsBranch(branches []*augmented.Branch, name stri
 	return false
 }
 
-func (t *ViewRepoService) sortBranches(branches []*augmented.Branch) {
+func (t *ViewRepoService) sortBranches(branches []*augmented.Branch, priority augmented.BranchPriority) {
 	sort.SliceStable(branches, func(l, r int) bool {
 		left := branches[l]
 		right := branches[r]
@@ -128,9 +127,9 @@ func (t *ViewRepoService) sortBranches(branches []*augmented.Branch) {
 			return true
 		}
 
-		// Prioritize known branches like master, develop
-		il := utils.StringsIndex(augmented.DefaultBranchPriority, left.Name)
-		ir := utils.StringsIndex(augmented.DefaultBranchPriority, right.Name)
+		// Prioritize the main and long-lived branches like main, develop
+		il := priority.Index(left.Name)
+		ir := priority.Index(right.Name)
 		if il != -1 && (il < ir || ir == -1) {
 			// Left item is known branch with higher priority
 			return true
@@ -154,21 +153,11 @@ func (t *ViewRepoService) getDefaultBranchIDs(gmRepo augmented.Repo) []string {
 	if ok {
 		return t.addBranchWithAncestors(branchIDs, branch)
 	}
-	branch, ok = gmRepo.BranchByName(remoteMainName)
-	if ok {
-		return t.addBranchWithAncestors(branchIDs, branch)
-	}
-	branch, ok = gmRepo.BranchByName(mainName)
-	if ok {
-		return t.addBranchWithAncestors(branchIDs, branch)
-	}
-	branch, ok = gmRepo.BranchByName(remoteMasterName)
-	if ok {
-		return t.addBranchWithAncestors(branchIDs, branch)
-	}
-	branch, ok = gmRepo.BranchByName(masterName)
-	if ok {
-		return t.addBranchWithAncestors(branchIDs, branch)
+	for _, name := range gmRepo.BranchPriority.MainNames() {
+		branch, ok = gmRepo.BranchByName(name)
+		if ok {
+			return t.addBranchWithAncestors(branchIDs, branch)
+		}
 	}
 	return branchIDs
 }
@@ -29,6 +29,12 @@ func (t *branchesGraph) SetGraph(repo *repo) {
 				isAmbiguous = true
 			}
 
+			if c.IsStash {
+				// Stashes are virtual commits, which are not part of any branch
+				t.drawBranch(repo, b, c, isAmbiguous) // Drawing ┃
+				continue
+			}
+
 			if c == b.tip && c.Branch != b {
 				// this tip commit is not on this branch (multiple branch tips on the same commit)
 				t.drawOtherBranchTip(repo, b, c)
@@ -54,9 +60,24 @@ func (t *branchesGraph) SetGraph(repo *repo) {
 		}
 	}
 
+	t.drawSquashMerged(repo)
 	t.trimUnusedGraphColumns(repo)
 }
 
+func (t *branchesGraph) drawSquashMerged(repo *repo) {
+	// Squash merged or rebased branches have no merge, draw a dashed merge left of the tip ╌
+	for _, b := range repo.Branches {
+		if !b.isSquashMerged || b.x == 0 || b.tip.Branch != b {
+			continue
+		}
+		y := b.tip.Index
+		if repo.Commits[y].graph[b.x].Connect != api.BBlank {
+			continue
+		}
+		repo.SetGraphConnect(b.x, y, api.SquashMerged, b.color) // ╌
+	}
+}
+
 func (t *branchesGraph) trimUnusedGraphColumns(repo *repo) {
 	// trim unused graph columns
 	rightMostBranch := lo.MaxBy(repo.Branches, func(v1 *branch, max *branch) bool {
@@ -86,7 +107,7 @@ func (t *branchesGraph) drawBranch(repo *repo, b *branch, c *commit, isAmbiguous
 	y := c.Index
 	color := b.color
 
-	if c.Branch != b && c != b.tip {
+	if (c.Branch != b || c.IsStash) && c != b.tip {
 		// Other branch commit, normal branch line (no commit on that branch)
 		if isAmbiguous {
 			color = cui.CWhite
@@ -30,6 +30,7 @@ type commit struct {
 	IsRemoteOnly   bool
 	IsAmbiguous    bool
 	IsAmbiguousTip bool
+	IsStash        bool
 }
 
 func (c *commit) String() string {
@@ -20,9 +20,13 @@ type repo struct {
 	CurrentBranchName  string
 	WorkingFolder      string
 	UncommittedChanges int
+	StagedChanges      int
 	augmentedRepo      augmented.Repo
 	Conflicts          int
+	Submodules         int
 	MergeMessage       string
+	IsCherryPicking    bool
+	RebaseMessage      string
 }
 
 func newRepo() *repo {
@@ -67,9 +71,19 @@ func (t *repo) addVirtualStatusCommit(gRepo augmented.Repo) {
 
 	allChanges := gRepo.Status.AllChanges()
 	statusText := fmt.Sprintf("%d uncommitted files", allChanges)
-	if gRepo.Status.IsMerging && gRepo.Status.MergeMessage != "" {
+	if gRepo.Status.Submodules > 0 {
+		statusText = fmt.Sprintf("%s (%d changed submodules)", statusText, gRepo.Status.Submodules)
+	}
+	// A paused rebase also has a merge message (of the current commit), which is not shown
+	if gRepo.Status.IsMerging && gRepo.Status.MergeMessage != "" && !gRepo.Status.IsRebasing {
 		statusText = fmt.Sprintf("%s, %s", gRepo.Status.MergeMessage, statusText)
 	}
+	if gRepo.Status.IsCherryPicking {
+		statusText = fmt.Sprintf("Cherry-picking, %s", statusText)
+	}
+	if gRepo.Status.IsRebasing {
+		statusText = fmt.Sprintf("%s, %s", gRepo.Status.RebaseMessage, statusText)
+	}
 	if gRepo.Status.Conflicted > 0 {
 		statusText = fmt.Sprintf("CONFLICTS: %d, %s", gRepo.Status.Conflicted, statusText)
 	}
@@ -79,6 +93,45 @@ func (t *repo) addVirtualStatusCommit(gRepo augmented.Repo) {
 	t.commitById[c.ID] = c
 }
 
+// addVirtualStashCommit adds the stash on the branch of the base commit, or if the base commit is
+// not shown (nil base branch), on the stash branch or the current branch
+func (t *repo) addVirtualStashCommit(stash augmented.Stash, baseBranch *augmented.Branch) {
+	var branch *branch
+	if baseBranch != nil {
+		branch = t.tryGetBranchByName(baseBranch.Name)
+	}
+	if branch == nil {
+		branch = t.tryGetBranchByName(stash.Branch)
+	}
+	if branch == nil {
+		branch = t.tryGetBranchByName(t.CurrentBranchName)
+	}
+	if branch == nil {
+		if len(t.Branches) == 0 {
+			return
+		}
+		// Neither the stash branch nor the current branch is shown, use the main branch
+		branch = t.Branches[0]
+	}
+
+	c := &commit{
+		ID:         stash.Id,
+		SID:        stash.Sid,
+		Subject:    fmt.Sprintf("%s: %s", stash.Name, stash.Message),
+		Message:    stash.Message,
+		Author:     "",
+		AuthorTime: stash.Time,
+		ParentIDs:  []string{stash.ParentID},
+		ChildIDs:   []string{},
+		Branch:     branch,
+		Index:      len(t.Commits),
+		graph:      make([]api.GraphColumn, len(t.Branches)+1),
+		IsStash:    true,
+	}
+	t.Commits = append(t.Commits, c)
+	t.commitById[c.ID] = c
+}
+
 func (t *repo) addSearchCommit(gc *augmented.Commit) {
 	c := t.toCommit(gc, len(t.Commits), false)
 	t.Commits = append(t.Commits, c)
@@ -146,14 +199,19 @@ func (t *repo) toBranch(b *augmented.Branch, index int) *branch {
 		bottomId:             b.BottomID,
 		parentBranchName:     parentBranchName,
 		isGitBranch:          b.IsGitBranch,
+		isMainBranch:         b.IsMainBranch,
 		isRemote:             b.IsRemote,
 		isAmbiguousBranch:    b.IsAmbiguousBranch,
 		remoteName:           b.RemoteName,
+		remote:               b.Remote,
 		localName:            b.LocalName,
 		isCurrent:            b.IsCurrent,
 		isSetAsParent:        b.IsSetAsParent,
+		isSquashMerged:       t.augmentedRepo.SquashMerged[b.Name],
+		isUserNamed:          b.IsUserNamed,
 		AmbiguousTipId:       b.AmbiguousTipId,
 		ambiguousBranchNames: ambiguousBranchNames,
+		worktreePath:         b.WorktreePath,
 	}
 }
 
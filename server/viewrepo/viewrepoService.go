@@ -12,7 +12,6 @@ import (
 	"github.com/michael-reichenauer/gmc/api"
 	"github.com/michael-reichenauer/gmc/common/config"
 	"github.com/michael-reichenauer/gmc/server/viewrepo/augmented"
-	"github.com/michael-reichenauer/gmc/utils"
 	"github.com/michael-reichenauer/gmc/utils/cui"
 	"github.com/michael-reichenauer/gmc/utils/git"
 	"github.com/michael-reichenauer/gmc/utils/linq"
@@ -22,13 +21,6 @@ import (
 	"github.com/thoas/go-funk"
 )
 
-const (
-	masterName       = "master"
-	remoteMasterName = "origin/master"
-	mainName         = "main"
-	remoteMainName   = "origin/main"
-)
-
 var branchColors = []cui.Color{
 	cui.CMagenta,
 	cui.CGreen,
@@ -61,17 +53,45 @@ type ViewRepoService struct {
 
 func NewViewRepoService(configService *config.Service, rootPath string) *ViewRepoService {
 	ctx, cancel := context.WithCancel(context.Background())
+	augmentedRepo := augmented.NewRepoServiceWithGit(newGit(ctx, configService, rootPath))
+	if configService != nil {
+		// Show same number of commits, if more history was loaded the last time
+		augmentedRepo.SetHistoryDepth(configService.GetRepo(rootPath).HistoryDepth)
+		// Prioritize the configured main and long-lived branches, e.g. trunk, develop and release/*
+		repoConfig := configService.GetRepoConfig(rootPath)
+		augmentedRepo.SetBranchPriority(repoConfig.MainBranch, repoConfig.LongLivedBranches)
+		parsers := linq.Map(configService.GetConfig().SubjectParsers, func(v config.SubjectParser) augmented.SubjectParser {
+			return augmented.SubjectParser{Name: v.Name, RegExp: v.RegExp, NoNames: v.NoNames}
+		})
+		if err := augmentedRepo.SetSubjectParsers(parsers); err != nil {
+			log.Warnf("Failed to set merge subject parsers, %v", err)
+		}
+		// Show the cached repo, while the fresh repo is read, when the repo is opened
+		augmentedRepo.EnableCache(configService.CacheFolder(), configService.ProgramVersion)
+	}
 
-	return &ViewRepoService{
+	t := &ViewRepoService{
 		changes:         observer.NewProperty(nil),
 		showRequests:    make(chan showRequest),
 		currentBranches: make(chan []string),
 		branchesGraph:   newBranchesGraph(),
-		augmentedRepo:   augmented.NewRepoService(rootPath),
+		augmentedRepo:   augmentedRepo,
 		configService:   configService,
 		ctx:             ctx,
 		cancel:          cancel,
 	}
+	augmentedRepo.Git().SetProgressHandler(t.PostRemoteProgress)
+	return t
+}
+
+// newGit returns the git of the repo, which reads the log, branches and tags directly from the
+// git object files, if configured, instead of running git. Running git commands are killed, when
+// the repo is closed
+func newGit(ctx context.Context, configService *config.Service, rootPath string) git.Git {
+	if configService != nil && configService.GetConfig().UseObjectReader {
+		return git.NewWithObjectReader(ctx, rootPath)
+	}
+	return git.NewWithContext(ctx, rootPath)
 }
 
 func (t *ViewRepoService) Git() git.Git {
@@ -103,19 +123,64 @@ func (t *ViewRepoService) CloseRepo() {
 	//close(t.repoChangesIn)
 }
 
+// CancelRemoteCommands cancels running remote git commands, e.g. a push or a pull, which takes
+// too long
+func (t *ViewRepoService) CancelRemoteCommands() {
+	t.augmentedRepo.Git().CancelRemoteCommands()
+}
+
+// PostRemoteProgress posts the progress of a running remote command, e.g. a push or a clone, to
+// the client, which shows it in the progress
+func (t *ViewRepoService) PostRemoteProgress(p git.RemoteProgress) {
+	progress := toApiRemoteProgress(p)
+	t.changes.Update(api.RepoChange{RemoteProgress: &progress})
+}
+
 func (t *ViewRepoService) TriggerRefreshModel() {
 	log.Event("vms-refresh")
 	t.augmentedRepo.TriggerManualRefresh()
 }
 
 func (t *ViewRepoService) GetCommitDiff(id string) (api.CommitDiff, error) {
-	diff, err := t.augmentedRepo.GetCommitDiff(id)
+	diff, err := t.getCommitDiff(id)
 	if err != nil {
 		return api.CommitDiff{}, err
 	}
 	return ToApiCommitDiff(diff), nil
 }
 
+func (t *ViewRepoService) getCommitDiff(id string) (git.CommitDiff, error) {
+	viewRepo := t.getViewRepo()
+	if viewRepo != nil {
+		if stash, ok := viewRepo.augmentedRepo.StashByID(id); ok {
+			// Stashes are shown as virtual commits, diff the stash relative its base commit
+			diff, err := t.augmentedRepo.GetStashDiff(stash.Name)
+			if err != nil {
+				return git.CommitDiff{}, err
+			}
+			diff.Id = stash.Id
+			diff.Message = stash.Message
+			return diff, nil
+		}
+	}
+
+	return t.augmentedRepo.GetCommitDiff(id)
+}
+
+// LoadMoreHistory loads older commits of a large repo with a partial log and stores the number of
+// commits to show for the repo
+func (t *ViewRepoService) LoadMoreHistory() error {
+	depth, ok := t.augmentedRepo.LoadMoreHistory()
+	if !ok || t.configService == nil {
+		// Not a partial log or no config to store the depth in
+		return nil
+	}
+	t.configService.SetRepo(t.augmentedRepo.RepoPath(), func(r *config.Repo) {
+		r.HistoryDepth = depth
+	})
+	return nil
+}
+
 func (t *ViewRepoService) GetFileDiff(path string) ([]api.CommitDiff, error) {
 	diff, err := t.augmentedRepo.GetFileDiff(path)
 	if err != nil {
@@ -129,7 +194,7 @@ func (t *ViewRepoService) GetCommitDetails(id string) (api.CommitDetailsRsp, err
 	if !ok {
 		return api.CommitDetailsRsp{}, fmt.Errorf("unknown commit %q", id)
 	}
-	diff, err := t.augmentedRepo.GetCommitDiff(id)
+	diff, err := t.getCommitDiff(id)
 	if err != nil {
 		return api.CommitDetailsRsp{}, err
 	}
@@ -150,8 +215,16 @@ func (t *ViewRepoService) GetCommitDetails(id string) (api.CommitDetailsRsp, err
 		branchName = fmt.Sprintf("ambiguous (one of: %s)", names)
 	}
 
+	// Explain why the commit is on the branch, e.g. to decide to set a parent branch
+	branchRule := ""
+	var branchCandidates []string
+	if ac, ok := t.viewRepo.augmentedRepo.TryGetCommitByID(id); ok {
+		branchRule = ac.BranchRule
+		branchCandidates = ac.BranchCandidates
+	}
+
 	return api.CommitDetailsRsp{Id: c.ID, BranchName: branchName, BranchColor: api.Color(cui.CWhite),
-		Message: c.Message, Files: files}, nil
+		Message: c.Message, Files: files, BranchRule: branchRule, BranchCandidates: branchCandidates}, nil
 }
 
 func (t *ViewRepoService) SwitchToBranch(name string, displayName string) error {
@@ -160,10 +233,152 @@ func (t *ViewRepoService) SwitchToBranch(name string, displayName string) error
 	return t.augmentedRepo.SwitchToBranch(displayName)
 }
 
+// CheckoutCommit checks out the commit as a detached head, e.g. a commit in the reflog
+func (t *ViewRepoService) CheckoutCommit(id string) error {
+	return t.augmentedRepo.CheckoutCommit(id)
+}
+
+func (t *ViewRepoService) GetReflog(ref string) ([]api.ReflogEntry, error) {
+	entries, err := t.augmentedRepo.GetReflog(ref)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(entries, func(v git.ReflogEntry, _ int) api.ReflogEntry { return toApiReflogEntry(v) }), nil
+}
+
+// CreateBranchAt creates and shows a branch at the commit, e.g. to recover a deleted branch
+func (t *ViewRepoService) CreateBranchAt(name, id string) error {
+	if err := t.augmentedRepo.CreateBranchAt(name, id); err != nil {
+		return err
+	}
+	t.ShowBranch(name)
+	return nil
+}
+
+func (t *ViewRepoService) GetWorktrees() ([]api.Worktree, error) {
+	worktrees, err := t.augmentedRepo.GetWorktrees()
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(worktrees, func(v git.Worktree, _ int) api.Worktree { return toApiWorktree(v) }), nil
+}
+
+// AddWorktree adds a worktree for the branch and returns the worktree path, which, if path
+// is empty, is a sibling folder to the main worktree named "<main folder>-<branch>"
+func (t *ViewRepoService) AddWorktree(path, branchName string) (string, error) {
+	if path == "" {
+		worktrees, err := t.augmentedRepo.GetWorktrees()
+		if err != nil {
+			return "", err
+		}
+		main, ok := lo.Find(worktrees, func(v git.Worktree) bool { return v.IsMain })
+		if !ok {
+			return "", fmt.Errorf("failed to find main worktree")
+		}
+		name := strings.ReplaceAll(git.StripRemotePrefix(branchName), "/", "-")
+		path = fmt.Sprintf("%s-%s", main.Path, name)
+	}
+
+	if err := t.augmentedRepo.AddWorktree(path, branchName); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (t *ViewRepoService) RemoveWorktree(path string, isForced bool) error {
+	return t.augmentedRepo.RemoveWorktree(path, isForced)
+}
+
+func (t *ViewRepoService) GetSubmodules() ([]api.Submodule, error) {
+	submodules, err := t.augmentedRepo.GetSubmodules()
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(submodules, func(v git.Submodule, _ int) api.Submodule { return toApiSubmodule(v) }), nil
+}
+
+func (t *ViewRepoService) UpdateSubmodules() error {
+	return t.augmentedRepo.UpdateSubmodules()
+}
+
 func (t *ViewRepoService) Commit(Commit string) error {
 	return t.augmentedRepo.Commit(Commit)
 }
 
+func (t *ViewRepoService) CommitStaged(message string) error {
+	return t.augmentedRepo.CommitStaged(message)
+}
+
+// GetUncommittedFiles returns the changed files, with their staged and unstaged state
+func (t *ViewRepoService) GetConflictFiles() ([]string, error) {
+	status, err := t.augmentedRepo.GetStatus()
+	if err != nil {
+		return nil, err
+	}
+	return status.ConflictsFiles, nil
+}
+
+func (t *ViewRepoService) GetConflictFile(path string) (api.ConflictFile, error) {
+	f, err := t.augmentedRepo.GetConflictFile(path)
+	if err != nil {
+		return api.ConflictFile{}, err
+	}
+	return toApiConflictFile(f), nil
+}
+
+func (t *ViewRepoService) ResolveConflict(path string, choices []api.ConflictChoice) error {
+	gitChoices := lo.Map(choices, func(v api.ConflictChoice, _ int) git.ConflictChoice {
+		return git.ConflictChoice(v)
+	})
+	return t.augmentedRepo.ResolveConflict(path, gitChoices)
+}
+
+func (t *ViewRepoService) GetUncommittedFiles() ([]api.UncommittedFile, error) {
+	status, err := t.augmentedRepo.GetStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []api.UncommittedFile
+	add := func(path string) *api.UncommittedFile {
+		for i := range files {
+			if files[i].Path == path {
+				return &files[i]
+			}
+		}
+		files = append(files, api.UncommittedFile{Path: path})
+		return &files[len(files)-1]
+	}
+
+	for _, path := range status.StagedFiles {
+		add(path).IsStaged = true
+	}
+	for _, path := range status.UnstagedFiles {
+		add(path).IsUnstaged = true
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return strings.ToLower(files[i].Path) < strings.ToLower(files[j].Path)
+	})
+	return files, nil
+}
+
+func (t *ViewRepoService) StageFile(path string) error {
+	return t.augmentedRepo.StageFile(path)
+}
+
+func (t *ViewRepoService) UnstageFile(path string) error {
+	return t.augmentedRepo.UnstageFile(path)
+}
+
+func (t *ViewRepoService) StageSection(path string, section api.SectionDiff, fromLine, toLine int) error {
+	return t.augmentedRepo.StageSection(path, toGitSectionDiff(section), fromLine, toLine)
+}
+
+func (t *ViewRepoService) UnstageSection(path string, section api.SectionDiff, fromLine, toLine int) error {
+	return t.augmentedRepo.UnstageSection(path, toGitSectionDiff(section), fromLine, toLine)
+}
+
 func (t *ViewRepoService) UndoCommit(id string) error {
 	return t.augmentedRepo.UndoCommit(id)
 }
@@ -176,6 +391,22 @@ func (t *ViewRepoService) UncommitLastCommit() error {
 	return t.augmentedRepo.UncommitLastCommit()
 }
 
+func (t *ViewRepoService) AmendCommit(message string) error {
+	return t.augmentedRepo.AmendCommit(message)
+}
+
+func (t *ViewRepoService) RewordCommit(id, message string) error {
+	return t.augmentedRepo.RewordCommit(id, message)
+}
+
+func (t *ViewRepoService) DropCommit(id string) error {
+	return t.augmentedRepo.DropCommit(id)
+}
+
+func (t *ViewRepoService) SquashCommits(fromID, toID, message string) error {
+	return t.augmentedRepo.SquashCommits(fromID, toID, message)
+}
+
 func (t *ViewRepoService) UndoAllUncommittedChanges() error {
 	return t.augmentedRepo.UndoAllUncommittedChanges()
 }
@@ -187,7 +418,7 @@ func (t *ViewRepoService) CleanWorkingFolder() error {
 func (t *ViewRepoService) BranchColor(branch *branch) cui.Color {
 	if branch.parentBranch == nil {
 		// branch has no parent or parent is remote of this branch, lets use it
-		return t.branchNameColor(branch.displayName, 0)
+		return t.branchNameColor(branch.displayName, branch.isMainBranch, 0)
 	}
 
 	if branch.remoteName == branch.parentBranch.name {
@@ -195,18 +426,18 @@ func (t *ViewRepoService) BranchColor(branch *branch) cui.Color {
 		return t.BranchColor(branch.parentBranch)
 	}
 
-	color := t.branchNameColor(branch.displayName, 0)
-	parentColor := t.branchNameColor(branch.parentBranch.displayName, 0)
+	color := t.branchNameColor(branch.displayName, branch.isMainBranch, 0)
+	parentColor := t.branchNameColor(branch.parentBranch.displayName, branch.parentBranch.isMainBranch, 0)
 
 	if color == parentColor {
 		// branch got same color as parent, lets change branch color
-		color = t.branchNameColor(branch.displayName, 1)
+		color = t.branchNameColor(branch.displayName, branch.isMainBranch, 1)
 	}
 
 	return color
 }
 
-func (t *ViewRepoService) branchNameColor(name string, addIndex int) cui.Color {
+func (t *ViewRepoService) branchNameColor(name string, isMainBranch bool, addIndex int) cui.Color {
 	if strings.HasPrefix(name, "ambiguous@") {
 		return cui.CWhite
 	}
@@ -214,7 +445,7 @@ func (t *ViewRepoService) branchNameColor(name string, addIndex int) cui.Color {
 	if ok {
 		return cui.Color(color)
 	}
-	if name == masterName || name == mainName {
+	if isMainBranch {
 		return cui.CMagenta
 	}
 
@@ -244,6 +475,50 @@ func (t *ViewRepoService) GetFiles(ref string) ([]string, error) {
 	return t.augmentedRepo.GetFiles(ref)
 }
 
+// GetFileBlame returns the blame lines of the file, where each line has the name and color
+// of the branch of the commit, which last changed the line
+func (t *ViewRepoService) GetFileBlame(path, ref string) ([]api.BlameLine, error) {
+	lines, err := t.augmentedRepo.GetFileBlame(path, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	viewRepo := t.getViewRepo()
+	return lo.Map(lines, func(v git.BlameLine, _ int) api.BlameLine {
+		branchName, color := t.commitBranch(viewRepo, v.CommitID)
+		return toApiBlameLine(v, branchName, color)
+	}), nil
+}
+
+// commitBranch returns the name and color of the commit branch, using the shown branch if
+// the commit is shown or else the branch in the augmented repo
+func (t *ViewRepoService) commitBranch(viewRepo *repo, commitID string) (string, cui.Color) {
+	if c, ok := viewRepo.CommitById(commitID); ok {
+		return c.Branch.name, c.Branch.color
+	}
+	if c, ok := viewRepo.augmentedRepo.TryGetCommitByID(commitID); ok {
+		return c.Branch.Name, t.augmentedBranchColor(c.Branch)
+	}
+	return "", cui.CWhite
+}
+
+// augmentedBranchColor returns the same color as BranchColor, but for a branch not shown
+func (t *ViewRepoService) augmentedBranchColor(branch *augmented.Branch) cui.Color {
+	if branch.ParentBranch == nil {
+		return t.branchNameColor(branch.DisplayName, branch.IsMainBranch, 0)
+	}
+
+	if branch.RemoteName == branch.ParentBranch.Name {
+		return t.augmentedBranchColor(branch.ParentBranch)
+	}
+
+	color := t.branchNameColor(branch.DisplayName, branch.IsMainBranch, 0)
+	if color == t.branchNameColor(branch.ParentBranch.DisplayName, branch.ParentBranch.IsMainBranch, 0) {
+		color = t.branchNameColor(branch.DisplayName, branch.IsMainBranch, 1)
+	}
+	return color
+}
+
 func (t *ViewRepoService) GetAmbiguousBranchBranches(args api.AmbiguousBranchBranchesReq) []api.Branch {
 	branches := []api.Branch{}
 
@@ -390,9 +665,7 @@ func (t *ViewRepoService) getShownBranches(skipMaster bool) []api.Branch {
 	viewRepo := t.getViewRepo()
 	bs := []api.Branch{}
 	for _, b := range viewRepo.Branches {
-		if skipMaster &&
-			(b.name == masterName || b.name == remoteMasterName ||
-				b.name == mainName || b.name == remoteMainName) {
+		if skipMaster && b.isMainBranch {
 			// Do not support closing main branch
 			continue
 		}
@@ -601,6 +874,7 @@ func (t *ViewRepoService) getSearchModel(gRepo augmented.Repo, searchText string
 	currentBranch, ok := gRepo.CurrentBranch()
 	repo.UncommittedChanges = gRepo.Status.AllChanges()
 	repo.Conflicts = gRepo.Status.Conflicted
+	repo.Submodules = gRepo.Status.Submodules
 
 	if ok {
 		repo.CurrentBranchName = currentBranch.Name
@@ -625,8 +899,12 @@ func (t *ViewRepoService) GetViewModel(augRepo augmented.Repo, branchNames []str
 	repo.augmentedRepo = augRepo
 	repo.WorkingFolder = augRepo.RepoPath
 	repo.UncommittedChanges = augRepo.Status.AllChanges()
+	repo.StagedChanges = augRepo.Status.Staged
 	repo.Conflicts = augRepo.Status.Conflicted
+	repo.Submodules = augRepo.Status.Submodules
 	repo.MergeMessage = augRepo.Status.MergeMessage
+	repo.IsCherryPicking = augRepo.Status.IsCherryPicking
+	repo.RebaseMessage = augRepo.Status.RebaseMessage
 
 	branches := t.getAugmentedBranches(branchNames, augRepo)
 	for _, b := range branches {
@@ -641,7 +919,22 @@ func (t *ViewRepoService) GetViewModel(augRepo augmented.Repo, branchNames []str
 	if !augRepo.Status.OK() {
 		repo.addVirtualStatusCommit(augRepo)
 	}
+	// Stashes are shown right above the commit they were created on, or at the top, if that
+	// commit is not shown
+	stashes := make(map[string][]augmented.Stash)
+	for _, s := range augRepo.Stashes {
+		if c, ok := augRepo.TryGetCommitByID(s.ParentID); ok && repo.containsBranch(c.Branch) {
+			stashes[s.ParentID] = append(stashes[s.ParentID], s)
+			continue
+		}
+		repo.addVirtualStashCommit(s, nil)
+	}
 	for _, c := range augRepo.Commits {
+		if repo.containsBranch(c.Branch) {
+			for _, s := range stashes[c.Id] {
+				repo.addVirtualStashCommit(s, c.Branch)
+			}
+		}
 		repo.addGitCommit(c)
 	}
 	for _, b := range repo.Branches {
@@ -857,10 +1150,130 @@ func (t *ViewRepoService) PullBranch(name string) error {
 	return t.augmentedRepo.PullBranch(name)
 }
 
+func (t *ViewRepoService) PushBranchTo(remote, name string) error {
+	return t.augmentedRepo.PushBranchTo(remote, name)
+}
+
+// PullBranchFrom pulls a local branch from a specified remote, like e.g. 'upstream'
+func (t *ViewRepoService) PullBranchFrom(remote, name string) error {
+	viewRepo := t.getViewRepo()
+	if current, ok := viewRepo.augmentedRepo.CurrentBranch(); ok && current.Name == name {
+		// The current branch is checked out, it needs to be pulled (merged) and not just fetched
+		return t.augmentedRepo.PullCurrentBranchFrom(remote, name)
+	}
+
+	return t.augmentedRepo.PullBranchFrom(remote, name)
+}
+
 func (t *ViewRepoService) MergeBranch(name string) error {
 	return t.augmentedRepo.MergeBranch(name)
 }
 
+func (t *ViewRepoService) CherryPick(ids []string) error {
+	return t.augmentedRepo.CherryPick(ids)
+}
+
+// RebaseBranch rebases the branch onto the onto branch, or onto the inferred parent branch if
+// onto is empty
+func (t *ViewRepoService) RebaseBranch(name, onto string) error {
+	viewRepo := t.getViewRepo()
+	b, ok := viewRepo.augmentedRepo.BranchByName(name)
+	if !ok {
+		return fmt.Errorf("unknown branch %q", name)
+	}
+	if b.IsRemote {
+		if b.LocalName == "" {
+			return fmt.Errorf("cannot rebase remote branch %q, which has no local branch", name)
+		}
+		name = b.LocalName
+	}
+
+	if onto == "" {
+		// Skip the remote/local counterparts of the branch itself to get the actual parent
+		pb := b.ParentBranch
+		for pb != nil && pb.BaseName() == b.BaseName() {
+			pb = pb.ParentBranch
+		}
+		if pb == nil {
+			return fmt.Errorf("branch %q has no parent branch to rebase onto", name)
+		}
+		onto = pb.Name
+	}
+
+	return t.augmentedRepo.RebaseBranch(name, onto)
+}
+
+func (t *ViewRepoService) RebaseContinue() error {
+	return t.augmentedRepo.RebaseContinue()
+}
+
+func (t *ViewRepoService) RebaseSkip() error {
+	return t.augmentedRepo.RebaseSkip()
+}
+
+func (t *ViewRepoService) RebaseAbort() error {
+	return t.augmentedRepo.RebaseAbort()
+}
+
+func (t *ViewRepoService) CherryPickContinue() error {
+	return t.augmentedRepo.CherryPickContinue()
+}
+
+func (t *ViewRepoService) CherryPickAbort() error {
+	return t.augmentedRepo.CherryPickAbort()
+}
+
+func (t *ViewRepoService) CreateTag(name, commitID, message string) error {
+	return t.augmentedRepo.CreateTag(name, commitID, message)
+}
+
+func (t *ViewRepoService) PushTag(name string) error {
+	return t.augmentedRepo.PushTag(name)
+}
+
+func (t *ViewRepoService) DeleteTag(name string) error {
+	err := t.augmentedRepo.DeleteTag(name)
+	if err != nil {
+		return err
+	}
+
+	err = t.augmentedRepo.DeleteRemoteTag(name)
+	if err != nil && !strings.Contains(err.Error(), "remote ref does not exist") {
+		return err
+	}
+	// Tag deleted locally and remote (or tag was never pushed)
+	return nil
+}
+
+func (t *ViewRepoService) GetStashes() []api.Stash {
+	viewRepo := t.getViewRepo()
+	if viewRepo == nil {
+		return []api.Stash{}
+	}
+	return toApiStashes(viewRepo.augmentedRepo.Stashes)
+}
+
+func (t *ViewRepoService) StashPush(message string, includeUntracked bool) error {
+	return t.augmentedRepo.StashPush(message, includeUntracked)
+}
+
+// RemoveLockFile removes a stale lock file, e.g. an index.lock, which blocks git commands
+func (t *ViewRepoService) RemoveLockFile(path string) error {
+	return t.augmentedRepo.RemoveLockFile(path)
+}
+
+func (t *ViewRepoService) StashApply(name string) error {
+	return t.augmentedRepo.StashApply(name)
+}
+
+func (t *ViewRepoService) StashPop(name string) error {
+	return t.augmentedRepo.StashPop(name)
+}
+
+func (t *ViewRepoService) StashDrop(name string) error {
+	return t.augmentedRepo.StashDrop(name)
+}
+
 func (t *ViewRepoService) CreateBranch(name, parentName string) error {
 	viewRepo := t.getViewRepo()
 	parent, ok := viewRepo.augmentedRepo.BranchByName(parentName)
@@ -879,7 +1292,7 @@ func (t *ViewRepoService) DeleteBranch(name string, isForced bool) error {
 	if !branch.IsGitBranch {
 		return fmt.Errorf("not a git branch %q", name)
 	}
-	if utils.StringsContains(augmented.DefaultBranchPriority, name) {
+	if branch.IsMainBranch {
 		return fmt.Errorf("branch is protected %q", name)
 	}
 
@@ -916,7 +1329,7 @@ func (t *ViewRepoService) DeleteBranch(name string, isForced bool) error {
 
 	if remoteBranch != nil {
 		// Deleting remote branch
-		err := t.augmentedRepo.DeleteRemoteBranch(remoteBranch.Name)
+		err := t.augmentedRepo.DeleteRemoteBranch(remoteBranch.Remote, remoteBranch.Name)
 		if err != nil {
 			return err
 		}
@@ -949,6 +1362,28 @@ func (t *ViewRepoService) UnsetAsParentBranch(name string) error {
 	return t.augmentedRepo.UnsetAsParentBranch(b.BaseName())
 }
 
+// SetBranchName names the unnamed or ambiguous branch of the commit for all users of the repo,
+// or removes the name, if the name is empty
+func (t *ViewRepoService) SetBranchName(commitID, name string) error {
+	viewRepo := t.getViewRepo()
+	c, ok := viewRepo.augmentedRepo.TryGetCommitByID(commitID)
+	if !ok {
+		return fmt.Errorf("unknown commit %q", commitID)
+	}
+
+	var tipID string
+	switch {
+	case c.IsAmbiguous:
+		tipID = c.Branch.AmbiguousTipId
+	case !c.Branch.IsGitBranch:
+		tipID = c.Branch.TipID
+	default:
+		return fmt.Errorf("not an unnamed or ambiguous branch %q", c.Branch.Name)
+	}
+
+	return t.augmentedRepo.SetBranchName(tipID, strings.TrimSpace(name))
+}
+
 func (t *ViewRepoService) addTags(repo *repo, tags []augmented.Tag) {
 	for _, tag := range tags {
 		c, ok := repo.commitById[tag.CommitID]
@@ -958,7 +1393,3 @@ func (t *ViewRepoService) addTags(repo *repo, tags []augmented.Tag) {
 		c.Tags = append(c.Tags, tag.TagName)
 	}
 }
-
-func isMainBranch(name string) bool {
-	return name == masterName || name == remoteMasterName || name == mainName || name == remoteMainName
-}
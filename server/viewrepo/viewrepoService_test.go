@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/michael-reichenauer/gmc/api"
 	"github.com/michael-reichenauer/gmc/client/console"
 	"github.com/michael-reichenauer/gmc/server/viewrepo/augmented"
 	"github.com/michael-reichenauer/gmc/utils"
@@ -93,3 +94,71 @@ func CurrentRoot() string {
 	}
 	return root
 }
+
+func TestStashAboveBaseCommit(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	g := git.New(wf.Path())
+	assert.NoError(t, g.InitRepo())
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	wf.File("a.txt").Write("1")
+	assert.NoError(t, g.Commit("first"))
+	wf.File("a.txt").Write("2")
+	assert.NoError(t, g.Commit("second"))
+
+	// A stash on the second commit, followed by a third commit
+	wf.File("a.txt").Write("stashed")
+	assert.NoError(t, g.StashPush("wip", false))
+	wf.File("a.txt").Write("3")
+	assert.NoError(t, g.Commit("third"))
+
+	repo, err := augmented.NewRepoService(wf.Path()).GetFreshRepo()
+	assert.NoError(t, err)
+	viewRepo := NewViewRepoService(nil, wf.Path()).GetViewModel(repo, []string{"master"})
+
+	subjects := []string{}
+	for _, c := range viewRepo.Commits {
+		subjects = append(subjects, c.Subject)
+	}
+	assert.Equal(t, []string{"third", "stash@{0}: On master: wip", "second", "first"}, subjects)
+}
+
+func TestErrorsOfCommandsKeepGitErrors(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	g := git.New(wf.Path())
+	assert.NoError(t, g.InitRepo())
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	wf.File("a.txt").Write("1")
+	assert.NoError(t, g.Commit("initial"))
+	assert.NoError(t, g.CreateBranch("feature"))
+	wf.File("a.txt").Write("feature")
+	assert.NoError(t, g.Commit("feature"))
+	assert.NoError(t, g.Checkout("master"))
+	wf.File("a.txt").Write("master")
+	assert.NoError(t, g.Commit("master"))
+	assert.ErrorIs(t, g.MergeBranch("feature"), git.ErrConflicts)
+	s := NewViewRepoService(nil, wf.Path())
+
+	// A stale lock file blocks resolving a conflict, until it is removed
+	wf.File(".git", "index.lock").Write("")
+	err := s.ResolveConflict("a.txt", []api.ConflictChoice{api.ChooseTheirs})
+	assert.ErrorIs(t, err, git.ErrLockFile)
+	lockPath, ok := git.LockFilePath(err)
+	assert.True(t, ok)
+	assert.NoError(t, s.RemoveLockFile(lockPath))
+	assert.NoError(t, s.ResolveConflict("a.txt", []api.ConflictChoice{api.ChooseTheirs}))
+	assert.NoError(t, s.Commit("merged"))
+
+	// Local changes block a commit edit, until they are stashed, where untracked files are kept
+	wf.File("b.txt").Write("1")
+	assert.NoError(t, s.Commit("second"))
+	wf.File("a.txt").Write("local")
+	wf.File("c.txt").Write("untracked")
+	cs, _ := g.GetLog()
+	err = s.DropCommit(cs.MustBySubject("second").ID)
+	assert.ErrorIs(t, err, git.ErrDirtyWorkingTree)
+	assert.NoError(t, s.StashPush("Stashed before retry", false))
+	assert.NoError(t, s.DropCommit(cs.MustBySubject("second").ID))
+	assert.Equal(t, "untracked", wf.File("c.txt").Read())
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/jroimartin/gocui"
 	"github.com/michael-reichenauer/gmc/utils/log"
 )
 
@@ -16,6 +18,7 @@ const (
 	showFullTimeout  = 15 * time.Second
 	line1            = "┌───────────────────┐"
 	line2            = "└───────────────────┘"
+	line2Cancel      = "└───── Esc: cancel ─┘"
 )
 
 var (
@@ -26,6 +29,10 @@ var (
 
 type Progress interface {
 	Close()
+	// SetCancel shows a cancel hint and calls the cancel function, if the user presses Esc
+	SetCancel(cancel func())
+	// SetText shows the text below the progress indicator, e.g. the progress of a clone
+	SetText(text string)
 }
 
 type progress struct {
@@ -36,6 +43,8 @@ type progress struct {
 	length       int
 	startTime    time.Time
 	showProgress bool
+	cancels      []func() // Cancel functions of the shown operations, which can be canceled
+	details      string   // Shown below the progress indicator, e.g. the progress of a clone
 }
 
 func showProgress(ui *ui, format string, v ...interface{}) Progress {
@@ -55,7 +64,7 @@ func showProgress(ui *ui, format string, v ...interface{}) Progress {
 	if instance.showCount == 1 {
 		instance.show()
 	}
-	instance.SetText(text)
+	instance.setText(text)
 	instance.view.SetTop()
 	return instance
 }
@@ -85,6 +94,23 @@ func (t *progress) newView() View {
 }
 
 func (t *progress) SetText(text string) {
+	if t.view == nil {
+		// Already closed, e.g. a late progress of a clone
+		return
+	}
+	t.details = text
+	lines := strings.Split(text, "\n")
+	width := 30
+	for _, l := range lines {
+		if n := utf8.RuneCountInString(l) + 1; n > width {
+			width = n
+		}
+	}
+	t.view.SetBound(CenterBounds(width, 3+len(lines), width, 3+len(lines)))
+	t.view.NotifyChanged()
+}
+
+func (t *progress) setText(text string) {
 	log.Debugf("Progress text: %q", text)
 	// Calculate margin between text and progress indicator (max two lines of text)
 	lines := strings.Split(text, "\n")
@@ -97,6 +123,24 @@ func (t *progress) SetText(text string) {
 	t.view.NotifyChanged()
 }
 
+func (t *progress) SetCancel(cancel func()) {
+	if len(t.cancels) == 0 {
+		t.view.SetKey(gocui.KeyEsc, t.onCancel)
+	}
+	t.cancels = append(t.cancels, cancel)
+	t.view.NotifyChanged()
+}
+
+func (t *progress) onCancel() {
+	log.Infof("Cancel progress %q", t.text)
+	cancels := t.cancels
+	t.cancels = nil
+	for _, cancel := range cancels {
+		cancel()
+	}
+	t.view.NotifyChanged()
+}
+
 func (t *progress) Close() {
 	log.Debugf("End progress #%d", instance.showCount)
 	instance.showCount--
@@ -115,7 +159,10 @@ func (t *progress) textFunc(ViewPage) string {
 		// Show no progress for a show while in case operation completes fast
 		return ""
 	}
-	t.view.SetTop()
+	if t.ui.currentView() == t.view {
+		// Not on top of e.g. a credential dialog, which is shown while a push is in progress
+		t.view.SetTop()
+	}
 
 	//if sinceStart < showFullTimeout {
 	length := t.length - 2
@@ -123,7 +170,14 @@ func (t *progress) textFunc(ViewPage) string {
 		length = 0
 	}
 
-	mark := fmt.Sprintf("%s\n│%-19s│\n%s", line1, strings.Repeat(waitMark2, 1+length%9), line2)
+	bottom := line2
+	if len(t.cancels) > 0 {
+		bottom = line2Cancel
+	}
+	mark := fmt.Sprintf("%s\n│%-19s│\n%s", line1, strings.Repeat(waitMark2, 1+length%9), bottom)
+	if t.details != "" {
+		return MagentaDk(mark) + "\n" + t.details
+	}
 	return MagentaDk(mark)
 	//}
 
@@ -90,6 +90,7 @@ type ViewProperties struct {
 	OnMoved          func()
 	Name             string
 	IsEditable       bool
+	IsMasked         bool // Editable text is shown masked, e.g. a password
 	IsWrap           bool
 	IsMoveUpDownWrap bool
 	OnEdit           func()
@@ -231,6 +232,9 @@ func (h *view) Show(bf BoundFunc) {
 		h.guiView.Editable = true
 		h.guiView.Editor = gocui.EditorFunc(h.textEditor)
 	}
+	if h.properties.IsMasked {
+		h.guiView.Mask = '*'
+	}
 	if !h.properties.IsEditable {
 		h.SetKey(gocui.KeyArrowUp, h.OnKeyArrowUp)
 		h.SetKey(gocui.KeyArrowDown, h.OnKeyArrowDown)
@@ -0,0 +1,196 @@
+package git
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/michael-reichenauer/gmc/utils/log"
+)
+
+// Git and ssh credential prompts, e.g. for a password when pushing, must not use the terminal,
+// which is used by the console ui. Instead, gmc is set as GIT_ASKPASS and SSH_ASKPASS helper. The
+// helper process connects to the gmc process, which started the git command, over a local socket
+// and the prompt is answered by the credential prompter, e.g. a password dialog.
+// Credentials are never logged.
+const (
+	askpassAddrEnv  = "GMC_ASKPASS_ADDR"
+	askpassTokenEnv = "GMC_ASKPASS_TOKEN"
+	askpassTimeout  = 10 * time.Minute
+)
+
+// CredentialPrompter returns the answer of a credential prompt, e.g. "Password for 'https://host':",
+// where isSecret is true for passwords and passphrases, which should be masked. Returns false if the
+// prompt was canceled
+type CredentialPrompter func(prompt string, isSecret bool) (answer string, ok bool)
+
+type askpassRequest struct {
+	Token  string
+	Prompt string
+}
+
+type askpassResponse struct {
+	Answer string
+	OK     bool
+}
+
+type askpassServer struct {
+	mutex    sync.Mutex
+	prompter CredentialPrompter
+	exePath  string
+	addr     string
+	token    string
+}
+
+var askpass = &askpassServer{}
+
+// SetCredentialPrompter sets the prompter, which answers credential prompts of git commands. If no
+// prompter is set, git commands prompt as usual, e.g. in the terminal
+func SetCredentialPrompter(prompter CredentialPrompter) {
+	askpass.mutex.Lock()
+	defer askpass.mutex.Unlock()
+	askpass.prompter = prompter
+}
+
+// IsAskpassHelper returns true if the program was started by git or ssh to answer a credential prompt
+func IsAskpassHelper() bool {
+	return os.Getenv(askpassAddrEnv) != ""
+}
+
+// RunAskpassHelper asks the gmc process, which started the git command, to answer the prompt in
+// the args and writes the answer to stdout. Returns the exit code of the helper process
+func RunAskpassHelper(args []string) int {
+	prompt := "Password:"
+	if len(args) > 1 {
+		prompt = strings.Join(args[1:], " ")
+	}
+
+	conn, err := net.DialTimeout("tcp", os.Getenv(askpassAddrEnv), 10*time.Second)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gmc askpass: failed to connect, %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+
+	req := askpassRequest{Token: os.Getenv(askpassTokenEnv), Prompt: prompt}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		fmt.Fprintf(os.Stderr, "gmc askpass: failed to send prompt, %v\n", err)
+		return 1
+	}
+	var rsp askpassResponse
+	if err := json.NewDecoder(conn).Decode(&rsp); err != nil {
+		fmt.Fprintf(os.Stderr, "gmc askpass: failed to read answer, %v\n", err)
+		return 1
+	}
+	if !rsp.OK {
+		return 1
+	}
+	fmt.Fprintln(os.Stdout, rsp.Answer)
+	return 0
+}
+
+// env returns the environment variables, which make git and ssh use gmc as askpass helper, or
+// nil if no credential prompter is set
+func (t *askpassServer) env() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.prompter == nil {
+		return nil
+	}
+	if t.addr == "" {
+		if err := t.start(); err != nil {
+			log.Warnf("Failed to start askpass server, %v", err)
+			return nil
+		}
+	}
+
+	// DISPLAY is left as is, older ssh versions without SSH_ASKPASS_REQUIRE use the helper only if set
+	return []string{
+		"GIT_ASKPASS=" + t.exePath,
+		"SSH_ASKPASS=" + t.exePath,
+		"SSH_ASKPASS_REQUIRE=force", // Use askpass even if there is a terminal (OpenSSH 8.4)
+		"GIT_TERMINAL_PROMPT=0",     // Never prompt in the terminal, e.g. if a prompt is canceled
+		askpassAddrEnv + "=" + t.addr,
+		askpassTokenEnv + "=" + t.token,
+	}
+}
+
+// start listens for helper connections on a local port, where the random token ensures that only
+// helpers started by gmc git commands get answers
+func (t *askpassServer) start() error {
+	exePath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	t.exePath = exePath
+	t.token = hex.EncodeToString(tokenBytes)
+	t.addr = listener.Addr().String()
+	log.Infof("Askpass server listening on %s", t.addr)
+	go t.serve(listener)
+	return nil
+}
+
+func (t *askpassServer) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Warnf("Askpass server stopped, %v", err)
+			return
+		}
+		go t.handle(conn)
+	}
+}
+
+func (t *askpassServer) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(askpassTimeout))
+
+	var req askpassRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		log.Warnf("Invalid askpass request, %v", err)
+		return
+	}
+
+	t.mutex.Lock()
+	prompter := t.prompter
+	isValid := subtle.ConstantTimeCompare([]byte(req.Token), []byte(t.token)) == 1
+	t.mutex.Unlock()
+	if !isValid {
+		log.Warnf("Askpass request with invalid token")
+		return
+	}
+
+	rsp := askpassResponse{}
+	if prompter != nil {
+		// The prompt is not logged, since it might contain e.g. a user name and host
+		log.Debugf("Credential prompt")
+		rsp.Answer, rsp.OK = prompter(req.Prompt, isSecretPrompt(req.Prompt))
+		log.Debugf("Credential prompt answered: %v", rsp.OK)
+	}
+	if err := json.NewEncoder(conn).Encode(rsp); err != nil {
+		log.Warnf("Failed to send askpass answer, %v", err)
+	}
+}
+
+// isSecretPrompt returns true for e.g. passwords and passphrases, but false for user names and
+// ssh host key confirmations
+func isSecretPrompt(prompt string) bool {
+	prompt = strings.ToLower(prompt)
+	return !strings.HasPrefix(prompt, "username") && !strings.Contains(prompt, "(yes/no")
+}
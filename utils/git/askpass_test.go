@@ -0,0 +1,93 @@
+package git
+
+import (
+	"errors"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils"
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialPrompts(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	out, err := exec.Command("git", "--exec-path").Output()
+	assert.NoError(t, err)
+	backendPath := filepath.Join(strings.TrimSpace(string(out)), "git-http-backend")
+	if !utils.FileExists(backendPath) {
+		t.Skip("git-http-backend not available")
+	}
+	// Ignore e.g. credential helpers in the user git config
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	wf.File("gitconfig").Write("")
+	t.Setenv("GIT_CONFIG_GLOBAL", wf.Path("gitconfig"))
+
+	// A bare repo, served by git over http, which requires basic authentication
+	serverPath := wf.MkDir("server").Path()
+	bare := New(wf.MkDir("server", "repo.git").Path())
+	assert.NoError(t, bare.InitRepoBare())
+	runGit(t, bare.RepoPath(), "config", "http.receivepack", "true")
+	backend := &cgi.Handler{Path: backendPath, Env: []string{"GIT_PROJECT_ROOT=" + serverPath, "GIT_HTTP_EXPORT_ALL=1"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		backend.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	// The prompter answers the user name and password prompts
+	var mutex sync.Mutex
+	var prompts []string
+	isCanceled := false
+	SetCredentialPrompter(func(prompt string, isSecret bool) (string, bool) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		prompts = append(prompts, prompt)
+		if isCanceled {
+			return "", false
+		}
+		if strings.HasPrefix(prompt, "Username") {
+			assert.False(t, isSecret)
+			return "user", true
+		}
+		assert.True(t, isSecret)
+		return "secret", true
+	})
+	defer SetCredentialPrompter(nil)
+
+	// Clone and push using the credentials from the prompts
+	path := wf.Path("local")
+	assert.NoError(t, New(wf.Path()).Clone(server.URL+"/repo.git", path))
+	assert.Equal(t, 2, len(prompts))
+	assert.True(t, strings.HasPrefix(prompts[0], "Username for "), prompts[0])
+	assert.True(t, strings.HasPrefix(prompts[1], "Password for "), prompts[1])
+
+	g := New(path)
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	wf.File("local", "a.txt").Write("1")
+	assert.NoError(t, g.Commit("initial"))
+	assert.NoError(t, g.PushBranch("master"))
+	assert.Equal(t, 4, len(prompts))
+	l, err := bare.GetLog()
+	assert.NoError(t, err)
+	assert.Equal(t, "initial", l[0].Subject)
+
+	// A canceled prompt fails the command, without prompting in the terminal
+	mutex.Lock()
+	isCanceled = true
+	mutex.Unlock()
+	err = g.Fetch()
+	assert.True(t, errors.Is(err, ErrAuthentication), "%v", err)
+	assert.NotContains(t, err.Error(), "secret")
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlameLine is a line in a file with the commit, which last changed the line
+type BlameLine struct {
+	CommitID   string
+	SID        string
+	Author     string
+	AuthorTime time.Time
+	Subject    string
+	LineNumber int
+	Text       string
+}
+
+// blameCommit is the commit info, which porcelain output only includes the first time a
+// commit is referenced
+type blameCommit struct {
+	author     string
+	authorTime time.Time
+	subject    string
+}
+
+// blame of file lines
+type blameService struct {
+	cmd gitCommander
+}
+
+func newBlameService(cmd gitCommander) *blameService {
+	return &blameService{cmd: cmd}
+}
+
+// getFileBlame returns the blame lines of the file at the ref, or of the working tree file if
+// ref is empty (uncommitted lines then have the UncommittedID commit id)
+func (t *blameService) getFileBlame(path, ref string) ([]BlameLine, error) {
+	args := []string{"blame", "--porcelain"}
+	if ref != "" {
+		args = append(args, ref)
+	}
+	args = append(args, "--", filepath.ToSlash(path))
+
+	output, err := t.cmd.Git(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to blame %q, %v", path, err)
+	}
+	lines, err := parseBlame(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse blame of %q, %v", path, err)
+	}
+	return lines, nil
+}
+
+// parseBlame parses 'git blame --porcelain' output, where each line is a header line
+// '<sha> <orig-line> <final-line> [<count>]', optional commit info lines and a tab prefixed text
+func parseBlame(output string) ([]BlameLine, error) {
+	var lines []BlameLine
+	commits := make(map[string]*blameCommit)
+	var current *blameCommit
+	currentID := ""
+	lineNumber := 0
+
+	for _, row := range strings.Split(output, "\n") {
+		if strings.HasPrefix(row, "\t") {
+			if current == nil {
+				return nil, fmt.Errorf("line without commit header")
+			}
+			lines = append(lines, BlameLine{
+				CommitID:   currentID,
+				SID:        ToSid(currentID),
+				Author:     current.author,
+				AuthorTime: current.authorTime,
+				Subject:    current.subject,
+				LineNumber: lineNumber,
+				Text:       row[1:],
+			})
+			continue
+		}
+
+		key, value, _ := strings.Cut(row, " ")
+		if isBlameCommitID(key) {
+			parts := strings.Fields(value)
+			if len(parts) < 2 {
+				return nil, fmt.Errorf("invalid header %q", row)
+			}
+			n, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid header %q, %v", row, err)
+			}
+			currentID = key
+			lineNumber = n
+			c, ok := commits[key]
+			if !ok {
+				c = &blameCommit{}
+				commits[key] = c
+			}
+			current = c
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		switch key {
+		case "author":
+			current.author = value
+		case "author-time":
+			sec, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid author time %q, %v", row, err)
+			}
+			current.authorTime = time.Unix(sec, 0)
+		case "summary":
+			current.subject = value
+		}
+	}
+	return lines, nil
+}
+
+func isBlameCommitID(text string) bool {
+	if len(text) != 40 {
+		return false
+	}
+	for _, c := range text {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFileBlame(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	git := New(wf.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	file1 := "a.txt"
+	wf.File(file1).Write("1\n2\n3\n")
+	assert.NoError(t, git.Commit("initial"))
+	wf.File(file1).Write("1\nb\n3\n")
+	assert.NoError(t, git.Commit("second"))
+	cs, _ := git.GetLog()
+	c1 := cs.MustBySubject("initial")
+	c2 := cs.MustBySubject("second")
+
+	lines, err := git.GetFileBlame(file1, c2.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, c1.ID, lines[0].CommitID)
+	assert.Equal(t, "initial", lines[0].Subject)
+	assert.Equal(t, "test", lines[0].Author)
+	assert.Equal(t, 1, lines[0].LineNumber)
+	assert.Equal(t, "1", lines[0].Text)
+	assert.Equal(t, c2.ID, lines[1].CommitID)
+	assert.Equal(t, "second", lines[1].Subject)
+	assert.Equal(t, "b", lines[1].Text)
+	assert.Equal(t, c1.ID, lines[2].CommitID)
+	assert.Equal(t, "initial", lines[2].Subject)
+	assert.Equal(t, 3, lines[2].LineNumber)
+
+	// At an older ref
+	lines, err = git.GetFileBlame(file1, c1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", lines[1].Text)
+	assert.Equal(t, c1.ID, lines[1].CommitID)
+
+	// Uncommitted lines in the working tree file
+	wf.File(file1).Write("1\nb\nc\n")
+	lines, err = git.GetFileBlame(file1, "")
+	assert.NoError(t, err)
+	assert.Equal(t, UncommittedID, lines[2].CommitID)
+	assert.Equal(t, "c", lines[2].Text)
+	assert.Equal(t, c2.ID, lines[1].CommitID)
+}
@@ -2,6 +2,7 @@ package git
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -14,12 +15,14 @@ type Branch struct {
 	TipID            string
 	IsCurrent        bool
 	IsRemote         bool
-	RemoteName       string
+	RemoteName       string // Remote branch name, i.e. the upstream branch of a local branch
+	Remote           string // Remote of a remote branch or remote of the upstream branch of a local branch
 	IsDetached       bool
 	AheadCount       int
 	BehindCount      int
 	IsRemoteMissing  bool
 	TipCommitMessage string
+	WorktreePath     string // Path of the other worktree, where the branch is checked out
 }
 
 type Branches []Branch
@@ -29,13 +32,16 @@ func (t *Branch) String() string {
 }
 
 const (
-	branchesRegexpText = `(?im)^(\*)?\s+(\(HEAD detached at (\S+)\)|(\S+))\s+(\S+)(\s+)?(\[(\S+)(:\s)?(ahead\s(\d+))?(,\s)?(behind\s(\d+))?(gone)?\])?(\s+)?(.+)?`
+	branchesRegexpText = `(?im)^(\*)?\s+(\((?:HEAD detached at|no branch, rebasing) (\S+)\)|(\S+))\s+(\S+)(\s+)?(\[(\S+)(:\s)?(ahead\s(\d+))?(,\s)?(behind\s(\d+))?(gone)?\])?(\s+)?(.+)?`
 	remotePrefix       = "remotes/"
-	originPrefix       = "origin/"
 )
 
 var branchesRegexp = utils.CompileRegexp(branchesRegexpText)
 
+// Branches checked out in other worktrees are prefixed with '+' and have the worktree path
+// after the tip id, e.g. "+ feature <sha> (/path/to/worktree) [origin/feature] message"
+var worktreeBranchRegexp = utils.CompileRegexp(`^\+(\s+\S+\s+\S+ )\(([^)]*)\) `)
+
 type branchesService struct {
 	cmd gitCommander
 }
@@ -65,7 +71,7 @@ func (bs Branches) MustByName(name string) Branch {
 func (t *branchesService) checkout(name string) error {
 	_, err := t.cmd.Git("checkout", name)
 	if err != nil {
-		return fmt.Errorf("failed to get checkout %q, %v", name, err)
+		return fmt.Errorf("failed to get checkout %q, %w", name, err)
 	}
 	return nil
 }
@@ -78,6 +84,25 @@ func (t *branchesService) getBranches() (Branches, error) {
 	return t.parseBranchesOutput(branchesText)
 }
 
+// getDefaultBranch returns the default branch of the origin remote, e.g. "main", as set by clone in
+// refs/remotes/origin/HEAD, or "" if the origin remote has no default branch
+func (t *branchesService) getDefaultBranch() (string, error) {
+	path := filepath.Join(CommonGitDir(GitDir(t.cmd.WorkingDir())), "refs", "remotes", "origin", "HEAD")
+	if !utils.FileExists(path) {
+		return "", nil
+	}
+	text, err := t.cmd.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read origin default branch, %v", err)
+	}
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "ref: refs/remotes/origin/") {
+		// Not a symbolic ref, i.e. no default branch
+		return "", nil
+	}
+	return strings.TrimPrefix(text, "ref: refs/remotes/origin/"), nil
+}
+
 func (t *branchesService) mergeBranch(name string) error {
 	name = StripRemotePrefix(name)
 	// $"merge --no-ff --no-commit --stat --progress {name}", ct);
@@ -148,6 +173,12 @@ func (t *branchesService) parseBranchesOutput(branchesText string) ([]Branch, er
 }
 
 func (t *branchesService) parseBranchLine(line string) (Branch, bool, error) {
+	worktreePath := ""
+	if m := worktreeBranchRegexp.FindStringSubmatch(line); m != nil {
+		worktreePath = m[2]
+		line = " " + m[1] + line[len(m[0]):]
+	}
+
 	match := branchesRegexp.FindStringSubmatch(line)
 	if match == nil {
 		return Branch{}, true, fmt.Errorf("failed to parse branch line %q", line)
@@ -168,15 +199,20 @@ func (t *branchesService) parseBranchLine(line string) (Branch, bool, error) {
 	}
 
 	displayName := name
-	if strings.HasPrefix(name, originPrefix) {
-		// make remote branch display name same as local branch name
-		displayName = fmt.Sprintf("%s", name[len(originPrefix):])
+	if isRemote && strings.HasPrefix(name, DefaultRemote+"/") {
+		// make default remote branch display name same as local branch name,
+		// while branches of other remotes (e.g. upstream/main) keep the remote prefix
+		displayName = name[len(DefaultRemote)+1:]
 	}
 
 	tipID := match[5]
 	isCurrent := match[1] == "*"
 
 	remoteName := match[8]
+	remote := RemoteOf(remoteName)
+	if isRemote {
+		remote = RemoteOf(name)
+	}
 	aheadCount, _ := strconv.Atoi(match[11])
 	behindCount, _ := strconv.Atoi(match[14])
 	isRemoteMissing := match[15] == "gone"
@@ -190,10 +226,12 @@ func (t *branchesService) parseBranchLine(line string) (Branch, bool, error) {
 		IsDetached:       isDetached,
 		IsRemote:         isRemote,
 		RemoteName:       remoteName,
+		Remote:           remote,
 		AheadCount:       aheadCount,
 		BehindCount:      behindCount,
 		IsRemoteMissing:  isRemoteMissing,
 		TipCommitMessage: tipCommitMessage,
+		WorktreePath:     filepath.FromSlash(worktreePath),
 	}, false, nil
 }
 
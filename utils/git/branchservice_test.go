@@ -80,3 +80,27 @@ func TestListBranches_Manual(t *testing.T) {
 		t.Logf("%v", b)
 	}
 }
+
+func TestDefaultBranch(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+
+	// A server repo with a trunk branch, which is the default branch of clones
+	serverPath := wf.MkDir("server").Path()
+	server := New(serverPath)
+	assert.NoError(t, server.InitRepo())
+	assert.NoError(t, server.ConfigUser("test", "test@test.com"))
+	wf.File("server", "a.txt").Write("1")
+	assert.NoError(t, server.Commit("initial"))
+	runGit(t, serverPath, "branch", "-m", "trunk")
+
+	name, err := server.GetDefaultBranch()
+	assert.NoError(t, err)
+	assert.Equal(t, "", name)
+
+	path := wf.Path("local")
+	assert.NoError(t, New(wf.Path()).Clone(serverPath, path))
+	name, err = New(path).GetDefaultBranch()
+	assert.NoError(t, err)
+	assert.Equal(t, "trunk", name)
+}
@@ -0,0 +1,65 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cherry-pick of commits onto the current branch
+type cherryPickService struct {
+	cmd gitCommander
+}
+
+func newCherryPickService(cmd gitCommander) *cherryPickService {
+	return &cherryPickService{cmd: cmd}
+}
+
+// cherryPick picks the specified commits, in the specified order, onto the current branch.
+// An id can also be a range, e.g. "<from>..<to>".
+func (t *cherryPickService) cherryPick(ids []string) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("no commits to cherry-pick")
+	}
+
+	args := append([]string{"cherry-pick", "-x"}, ids...)
+	output, err := t.cmd.Git(args...)
+	if err != nil {
+		if t.isConflicts(err, output) {
+			// The cherry-pick is kept in progress until conflicts are resolved (or aborted)
+			return ErrConflicts
+		}
+		return fmt.Errorf("failed to cherry-pick, %w", err)
+	}
+	return nil
+}
+
+func (t *cherryPickService) continueCherryPick() error {
+	// Stage resolved conflicts before continuing
+	_, err := t.cmd.Git("add", ".")
+	if err != nil {
+		return fmt.Errorf("failed to stage before continue cherry-pick, %w", err)
+	}
+
+	// Use a no-op editor to keep the commit message of the picked commit
+	output, err := t.cmd.Git("-c", "core.editor=true", "cherry-pick", "--continue")
+	if err != nil {
+		if t.isConflicts(err, output) {
+			// Next commit in a range of commits resulted in new conflicts
+			return ErrConflicts
+		}
+		return fmt.Errorf("failed to continue cherry-pick, %w", err)
+	}
+	return nil
+}
+
+func (t *cherryPickService) abortCherryPick() error {
+	_, err := t.cmd.Git("cherry-pick", "--abort")
+	if err != nil {
+		return fmt.Errorf("failed to abort cherry-pick, %w", err)
+	}
+	return nil
+}
+
+func (t *cherryPickService) isConflicts(err error, output string) bool {
+	return strings.Contains(err.Error(), "exit status 1") && strings.Contains(output, "CONFLICT")
+}
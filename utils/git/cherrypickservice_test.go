@@ -0,0 +1,83 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCherryPick(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	git := New(wf.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	file1 := "a.txt"
+	file2 := "b.txt"
+	wf.File(file1).Write("1")
+	assert.NoError(t, git.Commit("initial"))
+
+	assert.NoError(t, git.CreateBranch("release"))
+	wf.File(file2).Write("fix1")
+	assert.NoError(t, git.Commit("fix1"))
+	wf.File(file2).Write("fix2")
+	assert.NoError(t, git.Commit("fix2"))
+	cs, _ := git.GetLog()
+	fix1 := cs.MustBySubject("fix1").ID
+	fix2 := cs.MustBySubject("fix2").ID
+
+	// Pick a range of commits onto master
+	assert.NoError(t, git.Checkout("master"))
+	assert.NoError(t, git.CherryPick([]string{fix1, fix2}))
+	assert.Equal(t, "fix2", wf.File(file2).Read())
+	cs, _ = git.GetLog()
+	assert.Equal(t, 5, len(cs))
+
+	st, _ := git.GetStatus()
+	assert.False(t, st.IsCherryPicking)
+}
+
+func TestCherryPickConflicts(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	git := New(wf.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	file1 := "a.txt"
+	wf.File(file1).Write("1")
+	assert.NoError(t, git.Commit("initial"))
+
+	assert.NoError(t, git.CreateBranch("release"))
+	wf.File(file1).Write("release")
+	assert.NoError(t, git.Commit("fix"))
+	cs, _ := git.GetLog()
+	fix := cs.MustBySubject("fix").ID
+
+	assert.NoError(t, git.Checkout("master"))
+	wf.File(file1).Write("master")
+	assert.NoError(t, git.Commit("master change"))
+
+	// Conflicts, cherry-pick is kept in progress, which can be aborted
+	assert.Equal(t, ErrConflicts, git.CherryPick([]string{fix}))
+	st, _ := git.GetStatus()
+	assert.True(t, st.IsCherryPicking)
+	assert.Equal(t, 1, st.Conflicted)
+	assert.NoError(t, git.CherryPickAbort())
+	st, _ = git.GetStatus()
+	assert.False(t, st.IsCherryPicking)
+	assert.Equal(t, "master", wf.File(file1).Read())
+
+	// Conflicts, which are resolved and then continued
+	assert.Equal(t, ErrConflicts, git.CherryPick([]string{fix}))
+	wf.File(file1).Write("resolved")
+	assert.NoError(t, git.CherryPickContinue())
+	st, _ = git.GetStatus()
+	assert.False(t, st.IsCherryPicking)
+	assert.Equal(t, 0, st.Conflicted)
+	cs, _ = git.GetLog()
+	assert.Equal(t, "fix", cs[0].Subject)
+	assert.Equal(t, "resolved", wf.File(file1).Read())
+}
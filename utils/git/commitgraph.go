@@ -0,0 +1,185 @@
+package git
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/michael-reichenauer/gmc/utils"
+)
+
+const (
+	graphParentNone     = 0x70000000
+	graphExtraEdges     = 0x80000000
+	graphLastEdge       = 0x80000000
+	graphCommitDataSize = 20 + 4 + 4 + 8
+)
+
+// commitGraph reads parents and commit times from the "objects/info/commit-graph" file or the
+// split "objects/info/commit-graphs" chain, which is much faster than reading commit objects.
+// Commits are identified by their position in the graph, where the positions of a chain
+// layer follow the positions of its base layers
+type commitGraph struct {
+	layers []*commitGraphLayer
+}
+
+type commitGraphLayer struct {
+	basePos    uint32 // Number of commits in the base layers
+	count      uint32
+	fanout     []byte
+	ids        []byte
+	data       []byte
+	extraEdges []byte
+}
+
+// openCommitGraph returns the commit graph or nil if the repo has no (supported) commit graph
+func openCommitGraph(objectsDir string) (*commitGraph, error) {
+	infoDir := filepath.Join(objectsDir, "info")
+	var paths []string
+	if chain, err := utils.FileRead(filepath.Join(infoDir, "commit-graphs", "commit-graph-chain")); err == nil {
+		for _, line := range strings.Split(string(chain), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				paths = append(paths, filepath.Join(infoDir, "commit-graphs", "graph-"+line+".graph"))
+			}
+		}
+	} else if path := filepath.Join(infoDir, "commit-graph"); utils.FileExists(path) {
+		paths = []string{path}
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	graph := &commitGraph{}
+	basePos := uint32(0)
+	for _, path := range paths {
+		layer, err := readCommitGraphLayer(path, basePos)
+		if err != nil {
+			return nil, err
+		}
+		graph.layers = append(graph.layers, layer)
+		basePos += layer.count
+	}
+	return graph, nil
+}
+
+// readCommitGraphLayer reads a commit graph file, which has a "CGPH" header, a table of chunk
+// ids and offsets and then the chunks
+func readCommitGraphLayer(path string, basePos uint32) (*commitGraphLayer, error) {
+	file, err := utils.FileRead(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit graph %s, %v", path, err)
+	}
+	if len(file) < 8 || !bytes.Equal(file[:4], []byte("CGPH")) || file[4] != 1 || file[5] != 1 {
+		return nil, fmt.Errorf("unsupported commit graph %s", path)
+	}
+
+	chunkCount := int(file[6])
+	chunks := make(map[string][]byte)
+	for i := 0; i < chunkCount; i++ {
+		pos := 8 + i*12
+		if pos+24 > len(file) {
+			return nil, fmt.Errorf("invalid commit graph %s", path)
+		}
+		start := binary.BigEndian.Uint64(file[pos+4:])
+		end := binary.BigEndian.Uint64(file[pos+12+4:])
+		if start > end || end > uint64(len(file)) {
+			return nil, fmt.Errorf("invalid commit graph chunk in %s", path)
+		}
+		chunks[string(file[pos:pos+4])] = file[start:end]
+	}
+
+	layer := &commitGraphLayer{
+		basePos:    basePos,
+		fanout:     chunks["OIDF"],
+		ids:        chunks["OIDL"],
+		data:       chunks["CDAT"],
+		extraEdges: chunks["EDGE"],
+	}
+	if len(layer.fanout) != 256*4 {
+		return nil, fmt.Errorf("invalid commit graph fanout in %s", path)
+	}
+	layer.count = binary.BigEndian.Uint32(layer.fanout[255*4:])
+	if len(layer.ids) != int(layer.count)*20 || len(layer.data) != int(layer.count)*graphCommitDataSize {
+		return nil, fmt.Errorf("invalid commit graph in %s", path)
+	}
+	return layer, nil
+}
+
+// position returns the graph position of the commit
+func (t *commitGraph) position(id objectID) (uint32, bool) {
+	for _, l := range t.layers {
+		lo := uint32(0)
+		if id[0] > 0 {
+			lo = binary.BigEndian.Uint32(l.fanout[(int(id[0])-1)*4:])
+		}
+		hi := binary.BigEndian.Uint32(l.fanout[int(id[0])*4:])
+		for lo < hi {
+			mid := (lo + hi) / 2
+			switch bytes.Compare(l.ids[mid*20:mid*20+20], id[:]) {
+			case 0:
+				return l.basePos + mid, true
+			case -1:
+				lo = mid + 1
+			default:
+				hi = mid
+			}
+		}
+	}
+	return 0, false
+}
+
+func (t *commitGraph) layer(pos uint32) (*commitGraphLayer, uint32) {
+	for i := len(t.layers) - 1; i >= 0; i-- {
+		if pos >= t.layers[i].basePos {
+			return t.layers[i], pos - t.layers[i].basePos
+		}
+	}
+	panic(fmt.Sprintf("invalid commit graph position %d", pos))
+}
+
+func (t *commitGraph) id(pos uint32) objectID {
+	l, i := t.layer(pos)
+	var id objectID
+	copy(id[:], l.ids[i*20:])
+	return id
+}
+
+// commitTime returns the 34 bit commit time (seconds), which is stored after the 30 bit
+// generation number
+func (t *commitGraph) commitTime(pos uint32) int64 {
+	l, i := t.layer(pos)
+	data := l.data[i*graphCommitDataSize+20+8:]
+	return int64(binary.BigEndian.Uint32(data)&0x3)<<32 | int64(binary.BigEndian.Uint32(data[4:]))
+}
+
+// parents returns the parent positions, where the second parent value of an octopus merge is an
+// index in the extra edges list of the second and following parents
+func (t *commitGraph) parents(pos uint32) ([]uint32, error) {
+	l, i := t.layer(pos)
+	data := l.data[i*graphCommitDataSize+20:]
+	parent1 := binary.BigEndian.Uint32(data)
+	parent2 := binary.BigEndian.Uint32(data[4:])
+	if parent1 == graphParentNone {
+		return nil, nil
+	}
+	if parent2 == graphParentNone {
+		return []uint32{parent1}, nil
+	}
+	if parent2&graphExtraEdges == 0 {
+		return []uint32{parent1, parent2}, nil
+	}
+
+	parents := []uint32{parent1}
+	for edge := int(parent2 &^ graphExtraEdges); ; edge++ {
+		if (edge+1)*4 > len(l.extraEdges) {
+			return nil, fmt.Errorf("invalid commit graph extra edge %d", edge)
+		}
+		value := binary.BigEndian.Uint32(l.extraEdges[edge*4:])
+		parents = append(parents, value&^graphLastEdge)
+		if value&graphLastEdge != 0 {
+			return parents, nil
+		}
+	}
+}
@@ -19,36 +19,46 @@ func newCommit(cmd gitCommander) *commitService {
 }
 
 func (t *commitService) commitAllChanges(message string) error {
-	// Encode '"' chars
-	message = strings.ReplaceAll(message, "\"", "\\\"")
 	if !t.isMergeInProgress() {
 		_, err := t.cmd.Git("add", ".")
 		if err != nil {
-			return fmt.Errorf("failed to stage before commit, %v", err)
+			return fmt.Errorf("failed to stage before commit, %w", err)
 		}
 	}
 
-	_, err := t.cmd.Git("commit", "-am", message)
+	return t.commit("-am", message)
+}
+
+// commitStagedChanges commits only the changes in the index, unstaged changes are kept
+func (t *commitService) commitStagedChanges(message string) error {
+	return t.commit("-m", message)
+}
+
+func (t *commitService) commit(messageArg, message string) error {
+	// Encode '"' chars
+	message = strings.ReplaceAll(message, "\"", "\\\"")
+
+	_, err := t.cmd.Git("commit", messageArg, message)
 	if err != nil {
-		return fmt.Errorf("failed to commit, %v", err)
+		return fmt.Errorf("failed to commit, %w", err)
 	}
 	return nil
 }
 
 func (t *commitService) isMergeInProgress() bool {
-	mergeHeadPath := path.Join(t.cmd.WorkingDir(), ".git", "MERGE_HEAD")
+	mergeHeadPath := path.Join(GitDir(t.cmd.WorkingDir()), "MERGE_HEAD")
 	return utils.FileExists(mergeHeadPath)
 }
 
 func (t *commitService) undoAllUncommittedChanges() error {
 	_, err := t.cmd.Git("reset", "--hard")
 	if err != nil {
-		return fmt.Errorf("failed to reset, %v", err)
+		return fmt.Errorf("failed to reset, %w", err)
 	}
 
 	_, err = t.cmd.Git("clean", "-fd")
 	if err != nil {
-		return fmt.Errorf("failed to clean, %v", err)
+		return fmt.Errorf("failed to clean, %w", err)
 	}
 
 	return nil
@@ -60,11 +70,11 @@ func (t *commitService) undoUncommittedFileChanges(path string) error {
 		if t.isFileUnknown(err, path) {
 			err := os.Remove(path)
 			if err != nil {
-				return fmt.Errorf("failed to reset, %v", err)
+				return fmt.Errorf("failed to reset, %w", err)
 			}
 			return nil
 		}
-		return fmt.Errorf("failed to reset, %v", err)
+		return fmt.Errorf("failed to reset, %w", err)
 	}
 
 	return nil
@@ -73,12 +83,12 @@ func (t *commitService) undoUncommittedFileChanges(path string) error {
 func (t *commitService) cleanWorkingFolder() error {
 	_, err := t.cmd.Git("reset", "--hard")
 	if err != nil {
-		return fmt.Errorf("failed to reset, %v", err)
+		return fmt.Errorf("failed to reset, %w", err)
 	}
 
 	_, err = t.cmd.Git("clean", "-fxd")
 	if err != nil {
-		return fmt.Errorf("failed to clean, %v", err)
+		return fmt.Errorf("failed to clean, %w", err)
 	}
 
 	return nil
@@ -87,7 +97,7 @@ func (t *commitService) cleanWorkingFolder() error {
 func (t *commitService) undoCommit(id string) error {
 	_, err := t.cmd.Git("revert", "--no-commit", id)
 	if err != nil {
-		return fmt.Errorf("failed to reset, %v", err)
+		return fmt.Errorf("failed to reset, %w", err)
 	}
 
 	return nil
@@ -96,7 +106,7 @@ func (t *commitService) undoCommit(id string) error {
 func (t *commitService) uncommitLastCommit() error {
 	_, err := t.cmd.Git("reset", "HEAD~1")
 	if err != nil {
-		return fmt.Errorf("failed to reset, %v", err)
+		return fmt.Errorf("failed to reset, %w", err)
 	}
 
 	return nil
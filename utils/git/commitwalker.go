@@ -0,0 +1,360 @@
+package git
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commitWalker walks commits using the commit-graph for parents and commit times if
+// possible and otherwise the commit objects
+type commitWalker struct {
+	store   *objectStore
+	graph   *commitGraph
+	shallow map[objectID]bool
+	cached  map[objectID]*commitNode // Nodes of a previous walk, read only
+	nodes   map[objectID]*commitNode
+}
+
+type commitNode struct {
+	id       objectID
+	time     int64
+	parents  []objectID
+	commit   *Commit // The commit, if the commit object has been read
+	children int     // Number of children, which are not yet shown in the log
+	isQueued bool
+	seq      int // Queue order, for commits with the same time
+}
+
+func (t *objectReader) newCommitWalker() (*commitWalker, error) {
+	store, err := t.objects()
+	if err != nil {
+		return nil, err
+	}
+	graph, err := openCommitGraph(filepath.Join(t.commonGitDir(), "objects"))
+	if err != nil {
+		return nil, err
+	}
+	t.cacheMutex.Lock()
+	cached := t.cachedNodes
+	t.cacheMutex.Unlock()
+	shallow := t.readShallow()
+	if len(shallow) > 0 {
+		// Parents of shallow commits change when deepening the repo
+		cached = nil
+	}
+	return &commitWalker{
+		store:   store,
+		graph:   graph,
+		shallow: shallow,
+		cached:  cached,
+		nodes:   make(map[objectID]*commitNode),
+	}, nil
+}
+
+func (w *commitWalker) node(id objectID) (*commitNode, error) {
+	if n, ok := w.nodes[id]; ok {
+		return n, nil
+	}
+	n := &commitNode{id: id}
+	if c, ok := w.cached[id]; ok {
+		n.time, n.parents, n.commit = c.time, c.parents, c.commit
+		w.nodes[id] = n
+		return n, nil
+	}
+	if pos, ok := w.graphPosition(id); ok {
+		n.time = w.graph.commitTime(pos)
+		positions, err := w.graph.parents(pos)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range positions {
+			n.parents = append(n.parents, w.graph.id(p))
+		}
+	} else {
+		commit, err := w.readCommit(id)
+		if err != nil {
+			return nil, err
+		}
+		n.commit = &commit
+		n.time = commit.CommitTime.Unix()
+		for _, p := range commit.ParentIDs {
+			pid, err := toObjectID(p)
+			if err != nil {
+				return nil, err
+			}
+			n.parents = append(n.parents, pid)
+		}
+	}
+	if w.shallow[id] {
+		n.parents = nil
+	}
+	w.nodes[id] = n
+	return n, nil
+}
+
+// readCommits reads the commit objects of the nodes in parallel, since inflating objects is the
+// most time consuming part of reading the log
+func (w *commitWalker) readCommits(nodes []*commitNode) error {
+	var toRead []*commitNode
+	for _, n := range nodes {
+		if n.commit == nil {
+			toRead = append(toRead, n)
+		}
+	}
+
+	workers := runtime.NumCPU()
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := worker; j < len(toRead); j += workers {
+				commit, err := w.readCommit(toRead[j].id)
+				if err != nil {
+					errs[worker] = err
+					return
+				}
+				toRead[j].commit = &commit
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *commitWalker) graphPosition(id objectID) (uint32, bool) {
+	if w.graph == nil {
+		return 0, false
+	}
+	return w.graph.position(id)
+}
+
+// commit returns the commit of the node, the commit object is read if not already read
+func (w *commitWalker) commit(n *commitNode) (Commit, error) {
+	if n.commit == nil {
+		commit, err := w.readCommit(n.id)
+		if err != nil {
+			return Commit{}, err
+		}
+		n.commit = &commit
+	}
+	commit := *n.commit
+	if w.shallow[n.id] {
+		commit.ParentIDs = nil
+	}
+	return commit, nil
+}
+
+// oneline returns the subject as shown by 'git branch -v', i.e. the lines of the first
+// paragraph of the message joined by space
+func (w *commitWalker) oneline(id objectID) (string, error) {
+	n, err := w.node(id)
+	if err != nil {
+		return "", err
+	}
+	commit, err := w.commit(n)
+	if err != nil {
+		return "", err
+	}
+	paragraph, _, _ := strings.Cut(strings.TrimLeft(commit.Message, "\n"), "\n\n")
+	return strings.Join(strings.Fields(paragraph), " "), nil
+}
+
+// aheadBehind returns the number of commits reachable from only a and only b. Both sides are
+// walked in commit time order, until all queued commits are reachable from both
+func (w *commitWalker) aheadBehind(a, b objectID) (int, int, error) {
+	const (
+		fromA    = 1
+		fromB    = 2
+		fromBoth = fromA | fromB
+	)
+	if a == b {
+		return 0, 0, nil
+	}
+
+	flags := map[objectID]uint8{a: fromA, b: fromB}
+	queue := &commitQueue{}
+	defer func() {
+		for _, n := range queue.nodes {
+			n.isQueued = false
+		}
+	}()
+	active := 0 // Queued commits, which are not yet reachable from both
+	for _, id := range []objectID{a, b} {
+		n, err := w.node(id)
+		if err != nil {
+			return 0, 0, err
+		}
+		queue.add(n)
+		active++
+	}
+
+	for active > 0 {
+		n := queue.pop()
+		f := flags[n.id]
+		if f != fromBoth {
+			active--
+		}
+		for _, p := range n.parents {
+			pf := flags[p]
+			if pf|f == pf {
+				continue
+			}
+			flags[p] = pf | f
+			pn, err := w.node(p)
+			if err != nil {
+				return 0, 0, err
+			}
+			if !pn.isQueued {
+				queue.add(pn)
+				if pf|f != fromBoth {
+					active++
+				}
+			} else if pf|f == fromBoth {
+				active--
+			}
+		}
+	}
+
+	ahead, behind := 0, 0
+	for _, f := range flags {
+		switch f {
+		case fromA:
+			ahead++
+		case fromB:
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+func (w *commitWalker) readCommit(id objectID) (Commit, error) {
+	typ, data, err := w.store.readObject(id)
+	if err != nil {
+		return Commit{}, err
+	}
+	if typ != objectCommit {
+		return Commit{}, fmt.Errorf("object %s is not a commit", id)
+	}
+	commit, err := parseCommitObject(id.String(), data)
+	if err != nil {
+		return Commit{}, fmt.Errorf("failed to parse commit %s, %v", id, err)
+	}
+	return commit, nil
+}
+
+// parseCommitObject parses 'tree', 'parent', 'author' and 'committer' header lines and
+// the message after the first empty line
+func parseCommitObject(id string, data []byte) (Commit, error) {
+	commit := Commit{ID: id, SID: ToSid(id)}
+	headers, message, _ := bytes.Cut(data, []byte("\n\n"))
+	for _, line := range strings.Split(string(headers), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "parent":
+			commit.ParentIDs = append(commit.ParentIDs, value)
+		case "author":
+			name, authorTime, err := parseSignature(value)
+			if err != nil {
+				return Commit{}, err
+			}
+			commit.Author, commit.AuthorTime = name, authorTime
+		case "committer":
+			_, commitTime, err := parseSignature(value)
+			if err != nil {
+				return Commit{}, err
+			}
+			commit.CommitTime = commitTime
+		}
+	}
+
+	text := strings.ReplaceAll(string(message), "\r", "")
+	commit.Message = strings.TrimSuffix(text, "\n")
+	commit.Subject = strings.Split(commit.Message, "\n")[0]
+	return commit, nil
+}
+
+// parseSignature parses '<name> <<email>> <unix time> <+-hhmm>'
+func parseSignature(value string) (string, time.Time, error) {
+	i := strings.LastIndex(value, "> ")
+	if i == -1 {
+		return "", time.Time{}, fmt.Errorf("invalid signature %q", value)
+	}
+	name := strings.TrimSpace(value[:strings.LastIndex(value[:i], "<")])
+	fields := strings.Fields(value[i+2:])
+	if len(fields) != 2 || len(fields[1]) != 5 {
+		return "", time.Time{}, fmt.Errorf("invalid signature time %q", value)
+	}
+	seconds, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid signature time %q, %v", value, err)
+	}
+	hours, err1 := strconv.Atoi(fields[1][1:3])
+	minutes, err2 := strconv.Atoi(fields[1][3:5])
+	if err1 != nil || err2 != nil {
+		return "", time.Time{}, fmt.Errorf("invalid signature zone %q", value)
+	}
+	offset := hours*3600 + minutes*60
+	if fields[1][0] == '-' {
+		offset = -offset
+	}
+
+	// Use the local zone if it has the same offset, same as time.Parse() used by the log service
+	t := time.Unix(seconds, 0)
+	if _, localOffset := t.Zone(); localOffset == offset {
+		return name, t, nil
+	}
+	return name, t.In(time.FixedZone("", offset)), nil
+}
+
+func firstLine(data []byte) []byte {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	return line
+}
+
+// commitQueue is a priority queue of commits, latest commit time first and then in queue order
+type commitQueue struct {
+	nodes []*commitNode
+	seq   int
+}
+
+func (q *commitQueue) add(n *commitNode) {
+	q.seq++
+	n.seq = q.seq
+	n.isQueued = true
+	heap.Push(q, n)
+}
+
+func (q *commitQueue) pop() *commitNode {
+	n := heap.Pop(q).(*commitNode)
+	n.isQueued = false
+	return n
+}
+
+func (q *commitQueue) Len() int { return len(q.nodes) }
+func (q *commitQueue) Less(i, j int) bool {
+	if q.nodes[i].time != q.nodes[j].time {
+		return q.nodes[i].time > q.nodes[j].time
+	}
+	return q.nodes[i].seq < q.nodes[j].seq
+}
+func (q *commitQueue) Swap(i, j int)      { q.nodes[i], q.nodes[j] = q.nodes[j], q.nodes[i] }
+func (q *commitQueue) Push(x interface{}) { q.nodes = append(q.nodes, x.(*commitNode)) }
+func (q *commitQueue) Pop() interface{} {
+	n := q.nodes[len(q.nodes)-1]
+	q.nodes = q.nodes[:len(q.nodes)-1]
+	return n
+}
@@ -0,0 +1,238 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/michael-reichenauer/gmc/utils"
+)
+
+type ConflictChoice int
+
+const (
+	ChooseNone ConflictChoice = iota
+	ChooseOurs
+	ChooseTheirs
+	ChooseBoth // Ours followed by theirs
+)
+
+// ConflictFile is a conflicted file, with the content of the base (:1:), ours (:2:) and
+// theirs (:3:) stages, merged into chunks of either unchanged or conflicting lines
+type ConflictFile struct {
+	Path      string
+	Base      string
+	Ours      string
+	Theirs    string
+	HasBase   bool // False if e.g. both added the file
+	HasOurs   bool // False if deleted by us
+	HasTheirs bool // False if deleted by them
+	IsCRLF    bool
+	Chunks    []ConflictChunk
+}
+
+type ConflictChunk struct {
+	IsConflict bool
+	Lines      []string // The lines of a non conflict chunk
+	Ours       []string
+	Base       []string
+	Theirs     []string
+}
+
+const (
+	markerOurs   = "<<<<<<< "
+	markerBase   = "||||||| "
+	markerSplit  = "======="
+	markerTheirs = ">>>>>>> "
+)
+
+// resolving of merge conflicts
+type conflictService struct {
+	cmd gitCommander
+}
+
+func newConflictService(cmd gitCommander) *conflictService {
+	return &conflictService{cmd: cmd}
+}
+
+func (t *conflictService) getConflictFile(path string) (ConflictFile, error) {
+	f := ConflictFile{Path: path}
+	f.Base, f.HasBase = t.readStage(1, path)
+	f.Ours, f.HasOurs = t.readStage(2, path)
+	f.Theirs, f.HasTheirs = t.readStage(3, path)
+	if !f.HasOurs && !f.HasTheirs {
+		return ConflictFile{}, fmt.Errorf("file %q has no conflicts", path)
+	}
+
+	working, _ := t.cmd.ReadFile(filepath.Join(t.cmd.WorkingDir(), path))
+	f.IsCRLF = strings.Contains(working, "\r\n")
+
+	merged, err := t.mergeStages(f)
+	if err != nil {
+		return ConflictFile{}, err
+	}
+	f.Chunks = parseConflictChunks(merged)
+	return f, nil
+}
+
+// resolveConflict writes the file using the choices for each conflict chunk and stages it
+func (t *conflictService) resolveConflict(path string, choices []ConflictChoice) error {
+	f, err := t.getConflictFile(path)
+	if err != nil {
+		return err
+	}
+	text, err := f.Resolve(choices)
+	if err != nil {
+		return err
+	}
+
+	if (text == "" && !f.HasOurs && isAll(choices, ChooseOurs)) ||
+		(text == "" && !f.HasTheirs && isAll(choices, ChooseTheirs)) {
+		// Chose the side, which deleted the file
+		if _, err := t.cmd.Git("rm", "--quiet", "--force", "--", path); err != nil {
+			return fmt.Errorf("failed to remove %q, %w", path, err)
+		}
+		return nil
+	}
+
+	if err := utils.FileWrite(filepath.Join(t.cmd.WorkingDir(), path), []byte(text)); err != nil {
+		return fmt.Errorf("failed to write %q, %w", path, err)
+	}
+	if _, err := t.cmd.Git("add", "--", path); err != nil {
+		return fmt.Errorf("failed to stage %q, %w", path, err)
+	}
+	return nil
+}
+
+func (t *conflictService) readStage(stage int, path string) (string, bool) {
+	text, err := t.cmd.Git("show", fmt.Sprintf(":%d:%s", stage, filepath.ToSlash(path)))
+	if err != nil {
+		return "", false
+	}
+	return text, true
+}
+
+// mergeStages merges the stages in diff3 style, i.e. with base lines in the conflicts
+func (t *conflictService) mergeStages(f ConflictFile) (string, error) {
+	var paths []string
+	defer func() {
+		for _, path := range paths {
+			os.Remove(path)
+		}
+	}()
+	for _, text := range []string{f.Ours, f.Base, f.Theirs} {
+		path, err := writeGitDirTempFile(t.cmd.WorkingDir(), "gmc_merge_*", text)
+		if err != nil {
+			return "", err
+		}
+		paths = append(paths, path)
+	}
+
+	output, err := t.cmd.Git("merge-file", "-p", "--diff3", "-L", "ours", "-L", "base", "-L", "theirs",
+		paths[0], paths[1], paths[2])
+	if err != nil && (!strings.Contains(err.Error(), "exit status") ||
+		strings.Contains(err.Error(), "exit status 255")) {
+		// merge-file exits with the number of conflicts, but with a negative value for errors
+		return "", fmt.Errorf("failed to merge %q, %w", f.Path, err)
+	}
+	return strings.ReplaceAll(output, "\r", ""), nil
+}
+
+// Resolve returns the file text, where each conflict chunk is replaced by the lines of
+// the corresponding choice
+func (t ConflictFile) Resolve(choices []ConflictChoice) (string, error) {
+	var lines []string
+	conflictIndex := 0
+	for _, c := range t.Chunks {
+		if !c.IsConflict {
+			lines = append(lines, c.Lines...)
+			continue
+		}
+
+		choice := ChooseNone
+		if conflictIndex < len(choices) {
+			choice = choices[conflictIndex]
+		}
+		conflictIndex++
+
+		switch choice {
+		case ChooseOurs:
+			lines = append(lines, c.Ours...)
+		case ChooseTheirs:
+			lines = append(lines, c.Theirs...)
+		case ChooseBoth:
+			lines = append(lines, c.Ours...)
+			lines = append(lines, c.Theirs...)
+		default:
+			return "", fmt.Errorf("conflict %d in %q is not resolved", conflictIndex, t.Path)
+		}
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+	newLine := "\n"
+	if t.IsCRLF {
+		newLine = "\r\n"
+	}
+	return strings.Join(lines, newLine) + newLine, nil
+}
+
+// ConflictsCount returns the number of conflict chunks
+func (t ConflictFile) ConflictsCount() int {
+	count := 0
+	for _, c := range t.Chunks {
+		if c.IsConflict {
+			count++
+		}
+	}
+	return count
+}
+
+// parseConflictChunks parses diff3 style merged text into chunks
+func parseConflictChunks(text string) []ConflictChunk {
+	var chunks []ConflictChunk
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return chunks
+	}
+
+	var current ConflictChunk
+	var side *[]string
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case strings.HasPrefix(line, markerOurs) && side == nil:
+			if len(current.Lines) > 0 {
+				chunks = append(chunks, current)
+			}
+			current = ConflictChunk{IsConflict: true}
+			side = &current.Ours
+		case strings.HasPrefix(line, markerBase) && side == &current.Ours:
+			side = &current.Base
+		case line == markerSplit && (side == &current.Ours || side == &current.Base):
+			side = &current.Theirs
+		case strings.HasPrefix(line, markerTheirs) && side == &current.Theirs:
+			chunks = append(chunks, current)
+			current = ConflictChunk{}
+			side = nil
+		case side != nil:
+			*side = append(*side, line)
+		default:
+			current.Lines = append(current.Lines, line)
+		}
+	}
+	if len(current.Lines) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+func isAll(choices []ConflictChoice, choice ConflictChoice) bool {
+	for _, c := range choices {
+		if c != choice {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveConflicts(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	git := New(wf.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	file1 := "a.txt"
+	wf.File(file1).Write("1\n2\n3\n4\n5\n6\n7\n")
+	assert.NoError(t, git.Commit("initial"))
+
+	assert.NoError(t, git.CreateBranch("feature"))
+	wf.File(file1).Write("1\nf2\n3\n4\n5\nf6\n7\n")
+	assert.NoError(t, git.Commit("feature"))
+
+	assert.NoError(t, git.Checkout("master"))
+	wf.File(file1).Write("1\nm2\n3\n4\n5\nm6\n7\n")
+	assert.NoError(t, git.Commit("master"))
+	assert.ErrorIs(t, git.MergeBranch("feature"), ErrConflicts)
+
+	st, _ := git.GetStatus()
+	assert.Equal(t, []string{file1}, st.ConflictsFiles)
+
+	f, err := git.GetConflictFile(file1)
+	assert.NoError(t, err)
+	assert.True(t, f.HasBase && f.HasOurs && f.HasTheirs)
+	assert.Equal(t, "1\n2\n3\n4\n5\n6\n7\n", f.Base)
+	assert.Equal(t, "1\nm2\n3\n4\n5\nm6\n7\n", f.Ours)
+	assert.Equal(t, "1\nf2\n3\n4\n5\nf6\n7\n", f.Theirs)
+	assert.Equal(t, 2, f.ConflictsCount())
+	assert.Equal(t, 5, len(f.Chunks))
+	assert.Equal(t, []string{"1"}, f.Chunks[0].Lines)
+	assert.Equal(t, []string{"m2"}, f.Chunks[1].Ours)
+	assert.Equal(t, []string{"2"}, f.Chunks[1].Base)
+	assert.Equal(t, []string{"f2"}, f.Chunks[1].Theirs)
+	assert.Equal(t, []string{"3", "4", "5"}, f.Chunks[2].Lines)
+	tmpFiles, _ := filepath.Glob(filepath.Join(wf.Path(), ".git", "gmc_merge_*"))
+	assert.Empty(t, tmpFiles)
+
+	// All conflicts must be resolved
+	assert.Error(t, git.ResolveConflict(file1, []ConflictChoice{ChooseOurs}))
+
+	assert.NoError(t, git.ResolveConflict(file1, []ConflictChoice{ChooseTheirs, ChooseBoth}))
+	assert.Equal(t, "1\nf2\n3\n4\n5\nm6\nf6\n7\n", wf.File(file1).Read())
+	st, _ = git.GetStatus()
+	assert.Equal(t, 0, st.Conflicted)
+	assert.Equal(t, []string{file1}, st.StagedFiles)
+	assert.NoError(t, git.Commit("merged"))
+}
+
+func TestParseConflictChunks(t *testing.T) {
+	text := "a\n<<<<<<< ours\no\n||||||| base\nb\n=======\nt1\nt2\n>>>>>>> theirs\n" +
+		"<<<<<<< ours\n=======\nt\n>>>>>>> theirs\n"
+	chunks := parseConflictChunks(text)
+	assert.Equal(t, []ConflictChunk{
+		{Lines: []string{"a"}},
+		{IsConflict: true, Ours: []string{"o"}, Base: []string{"b"}, Theirs: []string{"t1", "t2"}},
+		{IsConflict: true, Theirs: []string{"t"}},
+	}, chunks)
+
+	f := ConflictFile{Chunks: chunks, IsCRLF: true}
+	text, err := f.Resolve([]ConflictChoice{ChooseBoth, ChooseOurs})
+	assert.NoError(t, err)
+	assert.Equal(t, "a\r\no\r\nt1\r\nt2\r\n", text)
+}
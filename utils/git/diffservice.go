@@ -36,6 +36,7 @@ type FileDiff struct {
 	PathBefore   string
 	PathAfter    string
 	IsRenamed    bool
+	IsSubmodule  bool // A changed submodule commit, described in the section lines
 	DiffMode     DiffMode
 	SectionDiffs []SectionDiff
 }
@@ -56,12 +57,13 @@ type LinesDiff struct {
 
 // fetches from remote origin
 type diffService struct {
-	cmd           gitCommander
-	statusHandler *statusService
+	cmd              gitCommander
+	statusHandler    *statusService
+	submoduleService *submoduleService
 }
 
 func newDiff(cmd gitCommander, statusHandler *statusService) *diffService {
-	return &diffService{cmd: cmd, statusHandler: statusHandler}
+	return &diffService{cmd: cmd, statusHandler: statusHandler, submoduleService: newSubmoduleService(cmd)}
 }
 
 func (t *diffService) commitDiff(id string) (CommitDiff, error) {
@@ -70,7 +72,7 @@ func (t *diffService) commitDiff(id string) (CommitDiff, error) {
 	}
 
 	diffText, err := t.cmd.Git("show", "--date=iso",
-		"--first-parent", "--root", "--patch", "--ignore-space-change", "--no-color",
+		"--first-parent", "--root", "--patch", "--ignore-space-change", "--no-color", "--submodule=short",
 		//"--output-indicator-context==", "--output-indicator-new=>", "--output-indicator-old=<",
 		"--find-renames", "--unified=6", id)
 	if err != nil {
@@ -84,7 +86,7 @@ func (t *diffService) commitDiff(id string) (CommitDiff, error) {
 }
 
 func (t *diffService) fileDiff(path string) ([]CommitDiff, error) {
-	diffText, err := t.cmd.Git("log", "--date=iso", "--patch", "--follow", "--", path)
+	diffText, err := t.cmd.Git("log", "--date=iso", "--patch", "--submodule=short", "--follow", "--", path)
 	if err != nil {
 		return []CommitDiff{}, err
 	}
@@ -97,7 +99,7 @@ func (t *diffService) fileDiff(path string) ([]CommitDiff, error) {
 
 func (t *diffService) unCommittedDiff() (CommitDiff, error) {
 	diffText, err := t.cmd.Git("diff", "--date=iso",
-		"--first-parent", "--root", "--patch", "--ignore-space-change", "--no-color",
+		"--first-parent", "--root", "--patch", "--ignore-space-change", "--no-color", "--submodule=short",
 		//	"--output-indicator-context==", "--output-indicator-new=>", "--output-indicator-old=<",
 		"--find-renames", "--unified=6", "HEAD")
 	if err != nil {
@@ -180,6 +182,7 @@ func (t *diffService) parse(text, path string, isUncommitted bool) ([]CommitDiff
 	// For file history with other similar file paths, the file diff can be empty, lets filter them
 	//commitDiffs = linq.Filter(commitDiffs, func(v CommitDiff) bool { return len(v.FileDiffs) > 0 })
 
+	t.submoduleService.setSubmoduleDiffs(commitDiffs)
 	return commitDiffs, nil
 }
 
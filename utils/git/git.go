@@ -1,6 +1,7 @@
 package git
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os/exec"
@@ -8,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/michael-reichenauer/gmc/utils"
+	"github.com/michael-reichenauer/gmc/utils/log"
 )
 
 const (
@@ -16,18 +18,35 @@ const (
 	PartialLogCommitID = "ffffffffffffffffffffffffffffffffffffffff"
 )
 
-var ErrConflicts = errors.New("merge resulted in conflict(s)")
+var (
+	ErrConflicts = errors.New("merge resulted in conflict(s)")
+	ErrCanceled  = errors.New("git command was canceled")
+)
 
 type Git interface {
 	RepoPath() string
+	CancelRemoteCommands()
+	SetProgressHandler(handler func(p RemoteProgress))
 	GetLog() (Commits, error)
 	GetLogMax(maxCommitCount int) (Commits, error)
+	GetNewLog(knownIDs []string) (Commits, error)
 	GetStatus() (Status, error)
 	GetBranches() (Branches, error)
+	GetDefaultBranch() (string, error)
+	IsSquashMerged(name, target, since string) (bool, error)
+	MergeBase(name1, name2 string) (string, error)
 	GetFiles(ref string) ([]string, error)
+	GetFileBlame(path, ref string) ([]BlameLine, error)
+	GetReflog(ref string) ([]ReflogEntry, error)
+	GetWorktrees() ([]Worktree, error)
+	GetSubmodules() ([]Submodule, error)
+	UpdateSubmodules() error
+	AddWorktree(path, branchName string) error
+	RemoveWorktree(path string, isForced bool) error
 
 	InitRepo() error
 	InitRepoBare() error
+	RemoveLockFile(path string) error
 	Clone(uri, path string) error
 	ConfigUser(name, email string) error
 
@@ -36,41 +55,85 @@ type Git interface {
 	FileDiff(path string) ([]CommitDiff, error)
 	Checkout(name string) error
 	Commit(message string) error
+	CommitStaged(message string) error
+	StageFile(path string) error
+	UnstageFile(path string) error
+	StageSection(path string, section SectionDiff, fromLine, toLine int) error
+	UnstageSection(path string, section SectionDiff, fromLine, toLine int) error
+	GetRemotes() ([]Remote, error)
 	Fetch() error
 	PushBranch(name string) error
+	PushBranchTo(remote, name string) error
 	CreateBranch(name string) error
 	CreateBranchAt(name string, id string) error
 	MergeBranch(name string) error
 	MergeSquashBranch(name string) error
-	DeleteRemoteBranch(name string) error
+	CherryPick(ids []string) error
+	CherryPickContinue() error
+	CherryPickAbort() error
+	GetConflictFile(path string) (ConflictFile, error)
+	ResolveConflict(path string, choices []ConflictChoice) error
+	RebaseBranch(name, onto string) error
+	RebaseContinue() error
+	RebaseSkip() error
+	RebaseAbort() error
+	DeleteRemoteBranch(remote, name string) error
 	DeleteLocalBranch(name string, isForced bool) error
 	GetTags() ([]Tag, error)
+	CreateTag(name, commitID, message string) error
+	DeleteTag(name string) error
+	PushTag(name string) error
+	DeleteRemoteTag(name string) error
+	GetStashes() ([]Stash, error)
+	StashPush(message string, includeUntracked bool) error
+	StashApply(name string) error
+	StashPop(name string) error
+	StashDrop(name string) error
+	StashDiff(name string) (CommitDiff, error)
 	PullCurrentBranch() error
+	PullCurrentBranchFrom(remote, name string) error
 	PullBranch(name string) error
+	PullBranchFrom(remote, name string) error
 	GetKeyValue(key string) (string, error)
 	SetKeyValue(key, value string) error
 	PushKeyValue(key string) error
 	PullKeyValue(key string) error
 	UndoCommit(id string) error
 	UncommitLastCommit() error
+	AmendCommit(message string) error
+	RewordCommit(id, message string) error
+	DropCommit(id string) error
+	SquashCommits(fromID, toID, message string) error
 	UndoAllUncommittedChanges() error
 	UndoUncommittedFileChanges(path string) error
 	CleanWorkingFolder() error
 }
 
 type git struct {
-	cmd             gitCommander
-	statusService   *statusService
-	logService      *logService
-	branchService   *branchesService
-	ignoreService   *ignoreService
-	diffService     *diffService
-	commitService   *commitService
-	remoteService   *remoteService
-	tagService      *tagService
-	keyValueService *keyValueService
-	repoService     *repoService
-	configService   *configService
+	cmd               gitCommander
+	statusService     *statusService
+	logService        *logService
+	branchService     *branchesService
+	ignoreService     *ignoreService
+	diffService       *diffService
+	commitService     *commitService
+	stageService      *stageService
+	remoteService     *remoteService
+	tagService        *tagService
+	stashService      *stashService
+	cherryPickService *cherryPickService
+	historyService    *historyService
+	rebaseService     *rebaseService
+	conflictService   *conflictService
+	blameService      *blameService
+	reflogService     *reflogService
+	worktreeService   *worktreeService
+	submoduleService  *submoduleService
+	patchIDService    *patchIDService
+	keyValueService   *keyValueService
+	repoService       *repoService
+	configService     *configService
+	objectReader      *objectReader // Optional, reads log, branches, tags and files without git
 }
 
 func New(path string) Git {
@@ -78,22 +141,49 @@ func New(path string) Git {
 	return NewWithCmd(cmd)
 }
 
+// NewWithContext returns a Git, where running git commands are killed, when the context is done,
+// e.g. when the repo is closed
+func NewWithContext(ctx context.Context, path string) Git {
+	return NewWithCmd(newGitCmdWithContext(ctx, path))
+}
+
+// NewWithObjectReader returns a Git, which reads the log, branches, tags and files directly from
+// the repo object files instead of running git, with a fallback to git if reading fails
+func NewWithObjectReader(ctx context.Context, path string) Git {
+	g := NewWithCmd(newGitCmdWithContext(ctx, path)).(*git)
+	g.objectReader = newObjectReader(path)
+	return g
+}
+
 func NewWithCmd(cmd gitCommander) Git {
 	status := newStatus(cmd)
+	logService := newLog(cmd)
+	diffService := newDiff(cmd, status)
 	remoteService := newRemoteService(cmd)
 	return &git{
-		cmd:             cmd,
-		statusService:   status,
-		logService:      newLog(cmd),
-		branchService:   newBranchService(cmd),
-		remoteService:   remoteService,
-		ignoreService:   newIgnoreHandler(cmd.WorkingDir()),
-		diffService:     newDiff(cmd, status),
-		commitService:   newCommit(cmd),
-		tagService:      newTagService(cmd),
-		keyValueService: newKeyValue(cmd, remoteService),
-		repoService:     newRepoService(cmd),
-		configService:   newConfigService(cmd),
+		cmd:               cmd,
+		statusService:     status,
+		logService:        logService,
+		branchService:     newBranchService(cmd),
+		remoteService:     remoteService,
+		ignoreService:     newIgnoreHandler(cmd.WorkingDir()),
+		diffService:       diffService,
+		commitService:     newCommit(cmd),
+		stageService:      newStageService(cmd),
+		tagService:        newTagService(cmd),
+		stashService:      newStashService(cmd, diffService, logService),
+		cherryPickService: newCherryPickService(cmd),
+		historyService:    newHistoryService(cmd),
+		rebaseService:     newRebaseService(cmd),
+		conflictService:   newConflictService(cmd),
+		blameService:      newBlameService(cmd),
+		reflogService:     newReflogService(cmd),
+		worktreeService:   newWorktreeService(cmd),
+		submoduleService:  newSubmoduleService(cmd),
+		patchIDService:    newPatchIDService(cmd),
+		keyValueService:   newKeyValue(cmd, remoteService),
+		repoService:       newRepoService(cmd),
+		configService:     newConfigService(cmd),
 	}
 }
 
@@ -105,6 +195,10 @@ func (t *git) InitRepoBare() error {
 	return t.repoService.InitRepoBare()
 }
 
+func (t *git) RemoveLockFile(path string) error {
+	return t.repoService.RemoveLockFile(path)
+}
+
 func (t *git) Clone(uri, path string) error {
 	return t.remoteService.clone(uri, path)
 }
@@ -117,19 +211,83 @@ func (t *git) RepoPath() string {
 	return t.cmd.WorkingDir()
 }
 
+// CancelRemoteCommands cancels running remote commands, e.g. clone, fetch, push and pull, which
+// then return ErrCanceled
+func (t *git) CancelRemoteCommands() {
+	t.cmd.CancelRemoteCommands()
+}
+
+// SetProgressHandler sets the handler of the progress of remote commands, e.g. clone, fetch, push
+// and pull
+func (t *git) SetProgressHandler(handler func(p RemoteProgress)) {
+	t.cmd.SetProgressHandler(handler)
+}
+
 func (t *git) GetLogMax(maxCommitCount int) (Commits, error) {
+	if t.objectReader != nil {
+		commits, err := t.objectReader.getLog(maxCommitCount)
+		if err == nil {
+			return commits, nil
+		}
+		log.Warnf("Failed to read log from objects, using git, %v", err)
+	}
 	return t.logService.getLog(maxCommitCount)
 }
 
+// GetNewLog returns the commits of all refs, which are not reachable from the known commits, in
+// the same order as in the full log
+func (t *git) GetNewLog(knownIDs []string) (Commits, error) {
+	if t.objectReader != nil {
+		commits, err := t.objectReader.getNewLog(knownIDs)
+		if err == nil {
+			return commits, nil
+		}
+		log.Warnf("Failed to read new log from objects, using git, %v", err)
+	}
+	return t.logService.getNewLog(knownIDs)
+}
+
 func (t *git) GetLog() (Commits, error) {
-	return t.logService.getLog(-1)
+	return t.GetLogMax(-1)
 }
 
 func (t *git) GetBranches() (Branches, error) {
+	if t.objectReader != nil {
+		branches, err := t.objectReader.getBranches()
+		if err == nil {
+			return branches, nil
+		}
+		log.Warnf("Failed to read branches from refs, using git, %v", err)
+	}
 	return t.branchService.getBranches()
 }
 
+// GetDefaultBranch returns the default branch of the origin remote (refs/remotes/origin/HEAD),
+// e.g. "main", or "" if unknown
+func (t *git) GetDefaultBranch() (string, error) {
+	return t.branchService.getDefaultBranch()
+}
+
+// IsSquashMerged returns true if the changes of the branch exist in the target branch commits
+// after since (e.g. the merge base), but not the branch commits, e.g. if the branch was squash
+// merged or rebased and merged
+func (t *git) IsSquashMerged(name, target, since string) (bool, error) {
+	return t.patchIDService.isSquashMerged(name, target, since)
+}
+
+// MergeBase returns the best common ancestor commit id of the two branches or commits
+func (t *git) MergeBase(name1, name2 string) (string, error) {
+	return t.patchIDService.mergeBase(name1, name2)
+}
+
 func (t *git) GetFiles(ref string) ([]string, error) {
+	if t.objectReader != nil {
+		files, err := t.objectReader.getFiles(ref)
+		if err == nil {
+			return files, nil
+		}
+		log.Warnf("Failed to read files from objects, using git, %v", err)
+	}
 	return t.logService.getFiles(ref)
 }
 
@@ -137,6 +295,10 @@ func (t *git) GetStatus() (Status, error) {
 	return t.statusService.getStatus()
 }
 
+func (t *git) GetRemotes() ([]Remote, error) {
+	return t.remoteService.getRemotes()
+}
+
 func (t *git) Fetch() error {
 	return t.remoteService.fetch()
 }
@@ -161,6 +323,28 @@ func (t *git) Commit(message string) error {
 	return t.commitService.commitAllChanges(message)
 }
 
+func (t *git) CommitStaged(message string) error {
+	return t.commitService.commitStagedChanges(message)
+}
+
+func (t *git) StageFile(path string) error {
+	return t.stageService.stageFile(path)
+}
+
+func (t *git) UnstageFile(path string) error {
+	return t.stageService.unstageFile(path)
+}
+
+// StageSection stages changed lines in a section of the uncommitted diff, where fromLine and
+// toLine are indexes in section.LinesDiffs, toLine -1 for the whole section
+func (t *git) StageSection(path string, section SectionDiff, fromLine, toLine int) error {
+	return t.stageService.stageSection(path, section, fromLine, toLine)
+}
+
+func (t *git) UnstageSection(path string, section SectionDiff, fromLine, toLine int) error {
+	return t.stageService.unstageSection(path, section, fromLine, toLine)
+}
+
 func (t *git) UndoCommit(id string) error {
 	return t.commitService.undoCommit(id)
 }
@@ -169,6 +353,23 @@ func (t *git) UncommitLastCommit() error {
 	return t.commitService.uncommitLastCommit()
 }
 
+func (t *git) AmendCommit(message string) error {
+	return t.historyService.amend(message)
+}
+
+func (t *git) RewordCommit(id, message string) error {
+	return t.historyService.reword(id, message)
+}
+
+func (t *git) DropCommit(id string) error {
+	return t.historyService.drop(id)
+}
+
+// SquashCommits squashes the commits from fromID (oldest) to toID (newest) into one commit
+func (t *git) SquashCommits(fromID, toID, message string) error {
+	return t.historyService.squash(fromID, toID, message)
+}
+
 func (t *git) UndoAllUncommittedChanges() error {
 	return t.commitService.undoAllUncommittedChanges()
 }
@@ -182,15 +383,27 @@ func (t *git) CleanWorkingFolder() error {
 }
 
 func (t *git) PushBranch(name string) error {
-	return t.remoteService.pushBranch(name)
+	return t.remoteService.pushBranch(DefaultRemote, name)
+}
+
+func (t *git) PushBranchTo(remote, name string) error {
+	return t.remoteService.pushBranch(remote, name)
 }
 
 func (t *git) PullCurrentBranch() error {
 	return t.remoteService.pullCurrentBranch()
 }
 
+func (t *git) PullCurrentBranchFrom(remote, name string) error {
+	return t.remoteService.pullCurrentBranchFrom(remote, name)
+}
+
 func (t *git) PullBranch(name string) error {
-	return t.remoteService.pullBranch(name)
+	return t.remoteService.pullBranch(DefaultRemote, name)
+}
+
+func (t *git) PullBranchFrom(remote, name string) error {
+	return t.remoteService.pullBranch(remote, name)
 }
 
 func (t *git) MergeBranch(name string) error {
@@ -201,6 +414,80 @@ func (t *git) MergeSquashBranch(name string) error {
 	return t.branchService.mergeSquashBranch(name)
 }
 
+func (t *git) CherryPick(ids []string) error {
+	return t.cherryPickService.cherryPick(ids)
+}
+
+func (t *git) CherryPickContinue() error {
+	return t.cherryPickService.continueCherryPick()
+}
+
+func (t *git) CherryPickAbort() error {
+	return t.cherryPickService.abortCherryPick()
+}
+
+// GetConflictFile returns the base, ours and theirs content of a conflicted file
+func (t *git) GetConflictFile(path string) (ConflictFile, error) {
+	return t.conflictService.getConflictFile(path)
+}
+
+// ResolveConflict writes and stages the conflicted file using a choice for each conflict
+func (t *git) ResolveConflict(path string, choices []ConflictChoice) error {
+	return t.conflictService.resolveConflict(path, choices)
+}
+
+// GetFileBlame returns the commit, which last changed each line of the file at the ref
+// (or the working tree file if ref is empty)
+func (t *git) GetFileBlame(path, ref string) ([]BlameLine, error) {
+	return t.blameService.getFileBlame(path, ref)
+}
+
+// GetReflog returns the reflog entries of the branch (or HEAD if ref is empty), latest first
+func (t *git) GetReflog(ref string) ([]ReflogEntry, error) {
+	return t.reflogService.getReflog(ref)
+}
+
+// GetWorktrees returns the main working tree and the linked worktrees
+func (t *git) GetWorktrees() ([]Worktree, error) {
+	return t.worktreeService.getWorktrees()
+}
+
+// AddWorktree adds a linked worktree at the path with the branch checked out
+func (t *git) AddWorktree(path, branchName string) error {
+	return t.worktreeService.addWorktree(path, branchName)
+}
+
+func (t *git) RemoveWorktree(path string, isForced bool) error {
+	return t.worktreeService.removeWorktree(path, isForced)
+}
+
+func (t *git) GetSubmodules() ([]Submodule, error) {
+	return t.submoduleService.getSubmodules()
+}
+
+// UpdateSubmodules initializes and updates all submodules to the recorded commits
+func (t *git) UpdateSubmodules() error {
+	return t.submoduleService.updateSubmodules()
+}
+
+// RebaseBranch rebases the branch onto the onto branch, returns ErrConflicts if the rebase
+// is paused due to conflicts
+func (t *git) RebaseBranch(name, onto string) error {
+	return t.rebaseService.rebase(name, onto)
+}
+
+func (t *git) RebaseContinue() error {
+	return t.rebaseService.continueRebase()
+}
+
+func (t *git) RebaseSkip() error {
+	return t.rebaseService.skipRebase()
+}
+
+func (t *git) RebaseAbort() error {
+	return t.rebaseService.abortRebase()
+}
+
 func (t *git) CreateBranch(name string) error {
 	return t.branchService.createBranch(name)
 }
@@ -209,8 +496,8 @@ func (t *git) CreateBranchAt(name string, id string) error {
 	return t.branchService.createBranchAt(name, id)
 }
 
-func (t *git) DeleteRemoteBranch(name string) error {
-	return t.remoteService.deleteRemoteBranch(name)
+func (t *git) DeleteRemoteBranch(remote, name string) error {
+	return t.remoteService.deleteRemoteBranch(remote, name)
 }
 
 func (t *git) DeleteLocalBranch(name string, isForced bool) error {
@@ -218,9 +505,56 @@ func (t *git) DeleteLocalBranch(name string, isForced bool) error {
 }
 
 func (t *git) GetTags() ([]Tag, error) {
+	if t.objectReader != nil {
+		tags, err := t.objectReader.getTags()
+		if err == nil {
+			return tags, nil
+		}
+		log.Warnf("Failed to read tags from refs, using git, %v", err)
+	}
 	return t.tagService.getTags()
 }
 
+func (t *git) CreateTag(name, commitID, message string) error {
+	return t.tagService.createTag(name, commitID, message)
+}
+
+func (t *git) DeleteTag(name string) error {
+	return t.tagService.deleteTag(name)
+}
+
+func (t *git) PushTag(name string) error {
+	return t.tagService.pushTag(name)
+}
+
+func (t *git) DeleteRemoteTag(name string) error {
+	return t.tagService.deleteRemoteTag(name)
+}
+
+func (t *git) GetStashes() ([]Stash, error) {
+	return t.stashService.getStashes()
+}
+
+func (t *git) StashPush(message string, includeUntracked bool) error {
+	return t.stashService.push(message, includeUntracked)
+}
+
+func (t *git) StashApply(name string) error {
+	return t.stashService.apply(name)
+}
+
+func (t *git) StashPop(name string) error {
+	return t.stashService.pop(name)
+}
+
+func (t *git) StashDrop(name string) error {
+	return t.stashService.drop(name)
+}
+
+func (t *git) StashDiff(name string) (CommitDiff, error) {
+	return t.stashService.show(name)
+}
+
 // GitVersion returns the git version
 func Version() string {
 	out, _ := exec.Command("git", "version").Output()
@@ -244,7 +578,16 @@ func (t *git) PullKeyValue(key string) error {
 }
 
 func StripRemotePrefix(name string) string {
-	return strings.TrimPrefix(name, "origin/")
+	return strings.TrimPrefix(name, DefaultRemote+"/")
+}
+
+// RemoteOf returns the remote part of a remote branch name like "upstream/main"
+func RemoteOf(remoteBranchName string) string {
+	i := strings.Index(remoteBranchName, "/")
+	if i == -1 {
+		return ""
+	}
+	return remoteBranchName[:i]
 }
 
 func WorkingTreeRoot(path string) (string, error) {
@@ -258,6 +601,10 @@ func WorkingTreeRoot(path string) (string, error) {
 		if utils.DirExists(gitRepoPath) {
 			return current, nil
 		}
+		if utils.FileExists(gitRepoPath) && GitDir(current) != gitRepoPath {
+			// A linked worktree, where .git is a file with a "gitdir: <path>" line
+			return current, nil
+		}
 		parent := filepath.Dir(current)
 		if parent == current {
 			// Reached top/root volume folder
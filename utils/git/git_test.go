@@ -12,6 +12,10 @@ import (
 )
 
 func TestMain(m *testing.M) {
+	if IsAskpassHelper() {
+		// The test binary is the askpass helper of git commands in tests, like gmc in the program
+		os.Exit(RunAskpassHelper(os.Args))
+	}
 	code := m.Run()
 	tests.CleanTemp()
 	os.Exit(code)
@@ -57,10 +61,20 @@ func (t *mockCmd) Git(args ...string) (string, error) {
 	return rsp.Output, err
 }
 
+func (t *mockCmd) GitWithInput(input string, args ...string) (string, error) {
+	return t.Git(args...)
+}
+
 func (t *mockCmd) WorkingDir() string {
 	return t.responses.Path
 }
 
+func (t *mockCmd) CancelRemoteCommands() {
+}
+
+func (t *mockCmd) SetProgressHandler(handler func(p RemoteProgress)) {
+}
+
 func (t *mockCmd) ReadFile(path string) (string, error) {
 	rsp, ok := t.responses.Cmds[path]
 	if !ok {
@@ -103,11 +117,30 @@ func (t *recorderCmd) Git(args ...string) (string, error) {
 	return output, err
 }
 
+func (t *recorderCmd) GitWithInput(input string, args ...string) (string, error) {
+	output, err := t.cmd.GitWithInput(input, args...)
+	e := ""
+	if err != nil {
+		e = err.Error()
+	}
+	t.responses.Cmds[fmt.Sprintf("%v", args)] = resp{Output: output, Error: e}
+
+	return output, err
+}
+
 func (t *recorderCmd) WorkingDir() string {
 	t.responses.Path = t.cmd.WorkingDir()
 	return t.responses.Path
 }
 
+func (t *recorderCmd) CancelRemoteCommands() {
+	t.cmd.CancelRemoteCommands()
+}
+
+func (t *recorderCmd) SetProgressHandler(handler func(p RemoteProgress)) {
+	t.cmd.SetProgressHandler(handler)
+}
+
 func (t *recorderCmd) ReadFile(path string) (string, error) {
 	output, err := t.cmd.ReadFile(path)
 	e := ""
@@ -1,27 +1,60 @@
 package git
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/michael-reichenauer/gmc/utils/log"
 	"github.com/michael-reichenauer/gmc/utils/timer"
 )
 
+// Timeouts of remote commands, which might hang, e.g. waiting for credentials or a slow server.
+// Clone has a longer timeout, since cloning a large repo can take a long time. Local commands
+// have no timeout, since e.g. a gc or a checkout of a large repo may take long as well
+const (
+	remoteCmdTimeout = 10 * time.Minute
+	cloneCmdTimeout  = 2 * time.Hour
+)
+
+// killGracePeriod is the time, which a canceled or timed out command has to stop, before it is
+// killed, e.g. for git to remove lock files like .git/index.lock
+const killGracePeriod = 2 * time.Second
+
+// Remote commands, which can be canceled by the user, e.g. if a push takes too long
+var remoteCmds = []string{"clone", "fetch", "pull", "push", "ls-remote", "submodule"}
+
 type gitCommander interface {
 	Git(arg ...string) (string, error)
+	GitWithInput(input string, arg ...string) (string, error)
 	WorkingDir() string
 	ReadFile(path string) (string, error)
+	CancelRemoteCommands()
+	SetProgressHandler(handler func(p RemoteProgress))
 }
 
 type gitCmd struct {
 	workingDir string
+	ctx        context.Context // Commands are killed, when done, e.g. when the repo is closed
+
+	remoteMutex   sync.Mutex
+	remoteID      int
+	remoteCancels map[int]context.CancelFunc // Cancel functions of running remote commands
+	onProgress    func(p RemoteProgress)     // Progress of remote commands with --progress
 }
 
 func newGitCmd(workingDir string) gitCommander {
-	return &gitCmd{workingDir: workingDir}
+	return newGitCmdWithContext(context.Background(), workingDir)
+}
+
+func newGitCmdWithContext(ctx context.Context, workingDir string) gitCommander {
+	return &gitCmd{workingDir: workingDir, ctx: ctx, remoteCancels: make(map[int]context.CancelFunc)}
 }
 
 func (t *gitCmd) WorkingDir() string {
@@ -33,14 +66,63 @@ func (t *gitCmd) ReadFile(path string) (string, error) {
 	return string(bytes), err
 }
 
+// CancelRemoteCommands cancels the running remote commands, e.g. a push, which waits for a slow
+// server. The canceled commands return ErrCanceled
+func (t *gitCmd) CancelRemoteCommands() {
+	t.remoteMutex.Lock()
+	defer t.remoteMutex.Unlock()
+	for _, cancel := range t.remoteCancels {
+		cancel()
+	}
+}
+
+// SetProgressHandler sets the handler of the progress of remote commands, which are run with the
+// --progress flag, e.g. a clone
+func (t *gitCmd) SetProgressHandler(handler func(p RemoteProgress)) {
+	t.remoteMutex.Lock()
+	defer t.remoteMutex.Unlock()
+	t.onProgress = handler
+}
+
 func (t *gitCmd) Git(args ...string) (string, error) {
+	return t.GitWithInput("", args...)
+}
+
+// GitWithInput runs git with the input as stdin, e.g. a diff for git patch-id
+func (t *gitCmd) GitWithInput(input string, args ...string) (string, error) {
 	argsText := strings.Join(args, " ")
 	log.Debugf("Cmd: git %s (%s) ...", argsText, t.workingDir)
+	ctx, cancel := t.commandContext(args)
+	defer cancel()
+	if ctx.Err() != nil {
+		// E.g. the repo is closed, no need to start the command
+		log.Infof("Canceled: git %s (%s)", argsText, t.workingDir)
+		return "", ErrCanceled
+	}
+
 	// Get the git cmd output
 	st := timer.Start()
 	c := exec.Command("git", args...)
 	c.Dir = t.workingDir
-	out, err := c.Output()
+	if input != "" {
+		c.Stdin = strings.NewReader(input)
+	}
+	if isRemoteCommand(commandName(args)) {
+		// Credential prompts of remote commands are answered by the askpass helper
+		if env := askpass.env(); env != nil {
+			c.Env = append(os.Environ(), env...)
+		}
+	}
+	out, err := runCmd(ctx, c, t.progressHandler(args))
+	if err != nil && ctx.Err() == context.Canceled {
+		log.Infof("Canceled: git %s (%s) %v", argsText, t.workingDir, st)
+		return "", ErrCanceled
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err := fmt.Errorf("failed: git %s (%s) %v\ntimed out", argsText, t.workingDir, st)
+		log.Warnf("%v", err)
+		return "", err
+	}
 	if err != nil {
 		errorText := ""
 		if ee, ok := err.(*exec.ExitError); ok {
@@ -48,11 +130,133 @@ func (t *gitCmd) Git(args ...string) (string, error) {
 			errorText = strings.ReplaceAll(errorText, "\t", "   ")
 		}
 		errorText = strings.TrimSuffix(errorText, "\n")
-		err := fmt.Errorf("failed: git %s (%s) %v\n%v\n%v", argsText, t.workingDir, st, err, errorText)
-		log.Warnf("%v", err)
-		return string(out), err
+		text := fmt.Sprintf("failed: git %s (%s) %v\n%v\n%v", argsText, t.workingDir, st, err, errorText)
+		log.Warnf("%v", text)
+		// Classify the error by both stderr and stdout, e.g. push --porcelain reports rejected refs
+		return string(out), newCmdError(text, errorText+"\n"+string(out))
 	}
 	log.Infof("OK: git %s (%s) %v", argsText, t.workingDir, st)
 	output := strings.ReplaceAll(string(out), "\r", "")
 	return output, nil
 }
+
+// progressHandler returns the progress handler if the command is run with --progress, or nil
+func (t *gitCmd) progressHandler(args []string) func(p RemoteProgress) {
+	if !hasArg(args, "--progress") {
+		return nil
+	}
+	t.remoteMutex.Lock()
+	defer t.remoteMutex.Unlock()
+	if t.onProgress == nil {
+		// The progress lines are still parsed, to not include them in error texts
+		return func(p RemoteProgress) {}
+	}
+	return t.onProgress
+}
+
+// commandContext returns the context of the command, where local commands are canceled only when
+// done, while remote commands have a timeout and can be canceled by CancelRemoteCommands as well
+func (t *gitCmd) commandContext(args []string) (context.Context, context.CancelFunc) {
+	name := commandName(args)
+	if !isRemoteCommand(name) {
+		return context.WithCancel(t.ctx)
+	}
+	timeout := remoteCmdTimeout
+	if name == "clone" {
+		timeout = cloneCmdTimeout
+	}
+	ctx, cancel := context.WithTimeout(t.ctx, timeout)
+
+	t.remoteMutex.Lock()
+	defer t.remoteMutex.Unlock()
+	t.remoteID++
+	id := t.remoteID
+	t.remoteCancels[id] = cancel
+	return ctx, func() {
+		t.remoteMutex.Lock()
+		delete(t.remoteCancels, id)
+		t.remoteMutex.Unlock()
+		cancel()
+	}
+}
+
+// runCmd runs the command and returns the output. If the context is done (canceled or timed out),
+// the command and its child processes (e.g. ssh or credential helpers) are stopped, and killed if
+// they have not stopped within the grace period.
+// If onProgress is set, the git --progress output on stderr is parsed and reported
+func runCmd(ctx context.Context, c *exec.Cmd, onProgress func(p RemoteProgress)) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	var progress *progressWriter
+	if onProgress != nil {
+		progress = newProgressWriter(&stderr, onProgress)
+		c.Stderr = progress
+	}
+	setProcessGroup(c)
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Stop first, so git can clean up, e.g. remove .git/index.lock or ref lock files
+			if err := stopProcessGroup(c); err != nil {
+				log.Debugf("Failed to stop git process %d, %v", c.Process.Pid, err)
+			}
+			select {
+			case <-time.After(killGracePeriod):
+				log.Warnf("Git process %d did not stop, killing it", c.Process.Pid)
+				if err := killProcessGroup(c); err != nil {
+					log.Warnf("Failed to kill git process %d, %v", c.Process.Pid, err)
+				}
+			case <-done:
+			}
+		case <-done:
+		}
+	}()
+	err := c.Wait()
+	close(done)
+	if progress != nil {
+		_ = progress.flush()
+	}
+
+	if ee, ok := err.(*exec.ExitError); ok {
+		ee.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+// commandName returns the git command name, e.g. "fetch" for "git -c key=value fetch origin"
+func commandName(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-c" || args[i] == "-C" {
+			i++
+			continue
+		}
+		if !strings.HasPrefix(args[i], "-") {
+			return args[i]
+		}
+	}
+	return ""
+}
+
+func hasArg(args []string, arg string) bool {
+	for _, a := range args {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+func isRemoteCommand(name string) bool {
+	for _, n := range remoteCmds {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package git
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in a new process group, so the command and its child
+// processes can be killed together
+func setProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// stopProcessGroup asks the command and its child processes to stop (SIGTERM), which lets git
+// remove lock files, e.g. .git/index.lock
+func stopProcessGroup(c *exec.Cmd) error {
+	return syscall.Kill(-c.Process.Pid, syscall.SIGTERM)
+}
+
+func killProcessGroup(c *exec.Cmd) error {
+	return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+}
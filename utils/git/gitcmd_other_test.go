@@ -0,0 +1,32 @@
+//go:build !windows
+
+package git
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanceledCommandsStoppedBeforeKilled(t *testing.T) {
+	// A command, which handles SIGTERM, is stopped, e.g. git removes lock files
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(500*time.Millisecond, cancel)
+	st := time.Now()
+	out, err := runCmd(ctx, exec.Command("sh", "-c", "trap 'echo stopped; exit 1' TERM; sleep 30"), nil)
+	assert.Error(t, err)
+	assert.Equal(t, "stopped\n", string(out))
+	assert.Less(t, time.Since(st), killGracePeriod)
+
+	// A command, which ignores SIGTERM, is killed after the grace period
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(500*time.Millisecond, cancel)
+	st = time.Now()
+	_, err = runCmd(ctx, exec.Command("sh", "-c", "trap '' TERM; sleep 30"), nil)
+	assert.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(st), killGracePeriod)
+	assert.Less(t, time.Since(st), 10*time.Second)
+}
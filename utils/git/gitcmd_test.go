@@ -0,0 +1,91 @@
+package git
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/michael-reichenauer/gmc/utils"
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandName(t *testing.T) {
+	assert.Equal(t, "fetch", commandName([]string{"fetch", "--prune", "origin"}))
+	assert.Equal(t, "rebase", commandName([]string{"-c", "core.editor=true", "rebase", "--continue"}))
+	assert.Equal(t, "status", commandName([]string{"--no-pager", "status"}))
+	assert.Equal(t, "", commandName([]string{"--version"}))
+	assert.True(t, isRemoteCommand("push"))
+	assert.False(t, isRemoteCommand("commit"))
+}
+
+func TestCommandTimeouts(t *testing.T) {
+	cmd := newGitCmd("").(*gitCmd)
+	ctx, cancel := cmd.commandContext([]string{"status"})
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	cancel()
+	assert.Equal(t, context.Canceled, ctx.Err())
+
+	ctx, cancel = cmd.commandContext([]string{"fetch", "origin"})
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(remoteCmdTimeout), deadline, time.Minute)
+}
+
+func TestCancelRemoteCommands(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	originPath := wf.MkDir("origin").Path()
+	assert.NoError(t, New(originPath).InitRepo())
+	localPath := wf.MkDir("local").Path()
+	assert.NoError(t, New(localPath).InitRepo())
+
+	// The fetch hangs, since the upload pack on the "remote" side never responds. Cancel kills
+	// both git and the upload pack process, otherwise the command would not return until the
+	// upload pack ends
+	cmd := newGitCmd(localPath)
+	time.AfterFunc(500*time.Millisecond, cmd.CancelRemoteCommands)
+	st := time.Now()
+	_, err := cmd.Git("fetch", "--upload-pack=sleep 30;:", originPath)
+	assert.Equal(t, ErrCanceled, err)
+	assert.Less(t, time.Since(st), 10*time.Second)
+
+	// Local commands are not canceled
+	cmd.CancelRemoteCommands()
+	_, err = cmd.Git("status")
+	assert.NoError(t, err)
+
+	// A canceled clone removes the partially cloned folder, where the "ext" remote never responds
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.ext.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+	g := New(wf.Path())
+	time.AfterFunc(500*time.Millisecond, g.CancelRemoteCommands)
+	clonePath := wf.Path("clone")
+	err = g.Clone("ext::sleep 30", clonePath)
+	assert.Equal(t, ErrCanceled, err)
+	assert.False(t, utils.DirExists(clonePath))
+}
+
+func TestCommandsKilledWhenContextDone(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	originPath := wf.MkDir("origin").Path()
+	assert.NoError(t, New(originPath).InitRepo())
+	localPath := wf.MkDir("local").Path()
+	assert.NoError(t, New(localPath).InitRepo())
+
+	// E.g. when the repo is closed
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := newGitCmdWithContext(ctx, localPath)
+	time.AfterFunc(500*time.Millisecond, cancel)
+	st := time.Now()
+	_, err := cmd.Git("fetch", "--upload-pack=sleep 30;:", originPath)
+	assert.Equal(t, ErrCanceled, err)
+	assert.Less(t, time.Since(st), 10*time.Second)
+
+	_, err = cmd.Git("status")
+	assert.Equal(t, ErrCanceled, err)
+}
@@ -0,0 +1,20 @@
+package git
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+func setProcessGroup(c *exec.Cmd) {
+}
+
+// stopProcessGroup asks the command and its child processes (the process tree) to stop, which
+// lets git remove lock files, e.g. .git/index.lock
+func stopProcessGroup(c *exec.Cmd) error {
+	return exec.Command("taskkill", "/T", "/PID", strconv.Itoa(c.Process.Pid)).Run()
+}
+
+// killProcessGroup kills the command and its child processes (the process tree)
+func killProcessGroup(c *exec.Cmd) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(c.Process.Pid)).Run()
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Typed errors of failed git commands, which are classified by the git output, e.g. to let the
+// user pull before pushing again, when a push was rejected. Use errors.Is() to check the type
+var (
+	ErrAuthentication     = errors.New("authentication failed")
+	ErrNonFastForward     = errors.New("rejected, since the remote branch has commits, which are not in the local branch")
+	ErrUnrelatedHistories = errors.New("refusing to merge unrelated histories")
+	ErrDirtyWorkingTree   = errors.New("local changes would be overwritten")
+	ErrNotFullyMerged     = errors.New("branch is not fully merged")
+	ErrNetwork            = errors.New("network unreachable")
+	ErrLockFile           = errors.New("lock file exists, another git process may be running")
+)
+
+var lockFileRegExp = regexp.MustCompile(`Unable to create '([^']+\.lock)': File exists`)
+
+// errorPatterns are lower case texts in git error output, which classify the error. The first
+// matching error is used, e.g. since auth failures also might mention "could not read from remote"
+var errorPatterns = []struct {
+	err      error
+	patterns []string
+}{
+	{ErrLockFile, []string{"': file exists"}},
+	{ErrAuthentication, []string{
+		"authentication failed", "could not read username", "could not read password",
+		"permission denied (publickey", "terminal prompts disabled", "invalid username or password",
+		"http basic: access denied", "the requested url returned error: 401",
+		"the requested url returned error: 403"}},
+	{ErrNetwork, []string{
+		"could not resolve host", "connection refused", "network is unreachable", "no route to host",
+		"connection timed out", "operation timed out", "failed to connect to",
+		"temporary failure in name resolution"}},
+	{ErrNonFastForward, []string{"non-fast-forward", "(fetch first)", "not possible to fast-forward"}},
+	{ErrUnrelatedHistories, []string{"refusing to merge unrelated histories"}},
+	{ErrDirtyWorkingTree, []string{
+		"would be overwritten by", "please commit your changes or stash them",
+		"you have unstaged changes", "your index contains uncommitted changes"}},
+	{ErrNotFullyMerged, []string{"is not fully merged"}},
+}
+
+// CmdError is the error of a failed git command, where Kind is the classified error, e.g.
+// ErrNonFastForward, or nil if the error is not classified
+type CmdError struct {
+	Kind     error
+	LockPath string // The lock file path, if Kind is ErrLockFile
+	text     string
+}
+
+func (e *CmdError) Error() string {
+	return e.text
+}
+
+func (e *CmdError) Unwrap() error {
+	return e.Kind
+}
+
+// LockFilePath returns the path of the existing lock file of an ErrLockFile error
+func LockFilePath(err error) (string, bool) {
+	var cmdErr *CmdError
+	if errors.As(err, &cmdErr) && cmdErr.LockPath != "" {
+		return cmdErr.LockPath, true
+	}
+	return "", false
+}
+
+// newCmdError returns the error of a failed command, classified by the command output
+func newCmdError(text, output string) *CmdError {
+	err := &CmdError{Kind: classifyError(output), text: text}
+	if err.Kind == ErrLockFile {
+		if matches := lockFileRegExp.FindStringSubmatch(output); matches != nil {
+			err.LockPath = matches[1]
+		}
+	}
+	return err
+}
+
+func classifyError(output string) error {
+	output = strings.ToLower(output)
+	for _, ep := range errorPatterns {
+		for _, p := range ep.patterns {
+			if strings.Contains(output, p) {
+				return ep.err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils"
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		output string
+		err    error
+	}{
+		{"fatal: Authentication failed for 'https://host/repo.git/'", ErrAuthentication},
+		{"git@host: Permission denied (publickey).\nfatal: Could not read from remote repository.", ErrAuthentication},
+		{"fatal: could not read Username for 'https://host': terminal prompts disabled", ErrAuthentication},
+		{"remote: HTTP Basic: Access denied", ErrAuthentication},
+		{"fatal: unable to access 'https://host/repo.git/': Could not resolve host: host", ErrNetwork},
+		{"ssh: connect to host host port 22: Connection refused", ErrNetwork},
+		{"fatal: unable to access 'https://host/': Failed to connect to host port 443: Connection timed out", ErrNetwork},
+		{" ! [rejected]        main -> main (fetch first)\nerror: failed to push some refs to 'host'", ErrNonFastForward},
+		{" ! [rejected]        main -> main (non-fast-forward)", ErrNonFastForward},
+		{"fatal: Not possible to fast-forward, aborting.", ErrNonFastForward},
+		{"fatal: refusing to merge unrelated histories", ErrUnrelatedHistories},
+		{"error: Your local changes to the following files would be overwritten by checkout:\n\ta.txt\nPlease commit your changes or stash them before you switch branches.\nAborting", ErrDirtyWorkingTree},
+		{"error: cannot pull with rebase: You have unstaged changes.", ErrDirtyWorkingTree},
+		{"error: The branch 'feature' is not fully merged.\nIf you are sure you want to delete it, run 'git branch -D feature'.", ErrNotFullyMerged},
+		{"fatal: Unable to create '/repo/.git/index.lock': File exists.\n\nAnother git process seems to be running", ErrLockFile},
+		{"error: pathspec 'unknown' did not match any file(s) known to git", nil},
+		{"", nil},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.err, classifyError(c.output), c.output)
+	}
+
+	err := newCmdError("failed: git commit", "fatal: Unable to create '/repo/.git/index.lock': File exists.")
+	assert.True(t, errors.Is(err, ErrLockFile))
+	assert.Equal(t, "failed: git commit", err.Error())
+	path, ok := LockFilePath(err)
+	assert.True(t, ok)
+	assert.Equal(t, "/repo/.git/index.lock", path)
+	_, ok = LockFilePath(errors.New("other"))
+	assert.False(t, ok)
+}
+
+func TestGitErrors(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+
+	// A server repo and a local repo, where another clone has pushed a commit
+	serverPath := wf.MkDir("server").Path()
+	assert.NoError(t, New(serverPath).InitRepoBare())
+	path := wf.Path("local")
+	g := New(wf.Path())
+	assert.NoError(t, g.Clone(serverPath, path))
+	g = New(path)
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	otherPath := wf.Path("other")
+	assert.NoError(t, New(wf.Path()).Clone(serverPath, otherPath))
+	other := New(otherPath)
+	assert.NoError(t, other.ConfigUser("test", "test@test.com"))
+
+	wf.File("local", "a.txt").Write("1")
+	assert.NoError(t, g.Commit("initial"))
+	assert.NoError(t, g.PushBranch("master"))
+	assert.NoError(t, other.PullCurrentBranch())
+	wf.File("other", "b.txt").Write("1")
+	assert.NoError(t, other.Commit("other"))
+	assert.NoError(t, other.PushBranch("master"))
+
+	// A push is rejected, since the server has a new commit
+	wf.File("local", "a.txt").Write("2")
+	assert.NoError(t, g.Commit("second"))
+	err := g.PushBranch("master")
+	assert.True(t, errors.Is(err, ErrNonFastForward), "%v", err)
+
+	// Deleting a branch with commits, which are not merged
+	assert.NoError(t, g.CreateBranch("feature"))
+	wf.File("local", "c.txt").Write("1")
+	assert.NoError(t, g.Commit("feature"))
+	assert.NoError(t, g.Checkout("master"))
+	err = g.DeleteLocalBranch("feature", false)
+	assert.True(t, errors.Is(err, ErrNotFullyMerged), "%v", err)
+
+	// A checkout, which would overwrite local changes
+	wf.File("local", "c.txt").Write("2")
+	err = g.Checkout("feature")
+	assert.True(t, errors.Is(err, ErrDirtyWorkingTree), "%v", err)
+	assert.NoError(t, g.UndoAllUncommittedChanges())
+
+	// A stale index.lock blocks commits, until it is removed
+	lockPath := filepath.Join(path, ".git", "index.lock")
+	wf.File("local", ".git", "index.lock").Write("")
+	wf.File("local", "a.txt").Write("3")
+	err = g.Commit("third")
+	assert.True(t, errors.Is(err, ErrLockFile), "%v", err)
+	lockErrPath, ok := LockFilePath(err)
+	assert.True(t, ok)
+	assert.True(t, isSamePath(lockPath, lockErrPath), lockErrPath)
+	assert.Error(t, g.RemoveLockFile(filepath.Join(path, "a.txt")))
+	assert.Error(t, g.RemoveLockFile(filepath.Join(wf.Path(), "other.lock")))
+	assert.NoError(t, g.RemoveLockFile(lockErrPath))
+	assert.False(t, utils.FileExists(lockPath))
+	assert.NoError(t, g.Commit("third"))
+}
@@ -0,0 +1,253 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	rebaseTodoFilePattern    = "gmc_rebase_todo_*"
+	commitMessageFilePattern = "gmc_commit_msg_*"
+)
+
+var ErrPushedCommit = errors.New("commit is already pushed to a remote, and cannot be edited")
+
+// edits of local (unpushed) commits on the current branch using non-interactive rebase
+type historyService struct {
+	cmd gitCommander
+}
+
+func newHistoryService(cmd gitCommander) *historyService {
+	return &historyService{cmd: cmd}
+}
+
+// amend amends the last commit with staged changes, and with the message if not empty
+func (t *historyService) amend(message string) error {
+	if err := t.verifyNotPushed("HEAD"); err != nil {
+		return err
+	}
+
+	args := []string{"commit", "--amend", "--allow-empty"}
+	if message == "" {
+		args = append(args, "--no-edit")
+	} else {
+		msgPath, err := t.writeMessageFile(message)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(msgPath)
+		args = append(args, "-F", msgPath)
+	}
+
+	_, err := t.cmd.Git(args...)
+	if err != nil {
+		return fmt.Errorf("failed to amend commit, %w", err)
+	}
+	return nil
+}
+
+// reword replaces the message of a local commit
+func (t *historyService) reword(id, message string) error {
+	if strings.TrimSpace(message) == "" {
+		return fmt.Errorf("failed to reword commit, empty message")
+	}
+	base, ids, err := t.getEditableCommits(id)
+	if err != nil {
+		return err
+	}
+	msgPath, err := t.writeMessageFile(message)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(msgPath)
+
+	var todo []string
+	for _, cid := range ids {
+		todo = append(todo, "pick "+cid)
+		if cid == ids[0] {
+			todo = append(todo, t.amendMessageExec(msgPath))
+		}
+	}
+
+	if err := t.rebase(base, todo); err != nil {
+		return fmt.Errorf("failed to reword commit, %w", err)
+	}
+	return nil
+}
+
+// drop removes a local commit from the current branch
+func (t *historyService) drop(id string) error {
+	base, ids, err := t.getEditableCommits(id)
+	if err != nil {
+		return err
+	}
+
+	todo := []string{"drop " + ids[0]}
+	for _, cid := range ids[1:] {
+		todo = append(todo, "pick "+cid)
+	}
+
+	if err := t.rebase(base, todo); err != nil {
+		return fmt.Errorf("failed to drop commit, %w", err)
+	}
+	return nil
+}
+
+// squash squashes the contiguous range of local commits from fromID (oldest) to toID (newest)
+// into one commit. The message of the fromID commit is kept if message is empty.
+func (t *historyService) squash(fromID, toID, message string) error {
+	base, ids, err := t.getEditableCommits(fromID)
+	if err != nil {
+		return err
+	}
+	toIndex := -1
+	for i, cid := range ids {
+		if strings.HasPrefix(cid, toID) {
+			toIndex = i
+			break
+		}
+	}
+	if toIndex < 1 {
+		return fmt.Errorf("failed to squash, %s is not a later commit than %s on the current branch",
+			ToSid(toID), ToSid(fromID))
+	}
+
+	todo := []string{"pick " + ids[0]}
+	for _, cid := range ids[1 : toIndex+1] {
+		todo = append(todo, "fixup "+cid)
+	}
+	if message != "" {
+		msgPath, err := t.writeMessageFile(message)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(msgPath)
+		todo = append(todo, t.amendMessageExec(msgPath))
+	}
+	for _, cid := range ids[toIndex+1:] {
+		todo = append(todo, "pick "+cid)
+	}
+
+	if err := t.rebase(base, todo); err != nil {
+		return fmt.Errorf("failed to squash commits, %w", err)
+	}
+	return nil
+}
+
+// getEditableCommits returns the base (parent) of the commit and the commits, oldest first,
+// from the commit to HEAD, which would be rewritten when editing the commit.
+// The base is empty if the commit is the root commit.
+func (t *historyService) getEditableCommits(id string) (string, []string, error) {
+	if _, err := t.cmd.Git("merge-base", "--is-ancestor", id, "HEAD"); err != nil {
+		return "", nil, fmt.Errorf("commit %s is not on the current branch", ToSid(id))
+	}
+	if err := t.verifyNotPushed(id); err != nil {
+		return "", nil, err
+	}
+
+	base := ""
+	revRange := "HEAD"
+	output, err := t.cmd.Git("rev-parse", "--verify", "--quiet", id+"^")
+	if err == nil {
+		base = strings.TrimSpace(output)
+		revRange = base + "..HEAD"
+	}
+
+	output, err = t.cmd.Git("rev-list", "--reverse", "--parents", revRange)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to list commits, %w", err)
+	}
+
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return "", nil, fmt.Errorf("cannot edit commit %s, merge commit %s would be rewritten",
+				ToSid(id), ToSid(fields[0]))
+		}
+		ids = append(ids, fields[0])
+	}
+	if len(ids) == 0 {
+		return "", nil, fmt.Errorf("no commits to edit")
+	}
+	return base, ids, nil
+}
+
+// verifyNotPushed returns ErrPushedCommit if the commit is contained in any remote branch,
+// since then all its ancestors are pushed as well.
+func (t *historyService) verifyNotPushed(id string) error {
+	output, err := t.cmd.Git("branch", "--remotes", "--contains", id)
+	if err != nil {
+		return fmt.Errorf("failed to check if commit %s is pushed, %w", ToSid(id), err)
+	}
+	if strings.TrimSpace(output) != "" {
+		return ErrPushedCommit
+	}
+	return nil
+}
+
+// rebase runs an interactive rebase, where the todo list is replaced by the specified todo lines.
+// The rebase is aborted if it fails, e.g. due to conflicts, to keep the branch unchanged.
+// Uncommitted changes are not stashed implicitly, the user has to commit or stash them first
+func (t *historyService) rebase(base string, todo []string) error {
+	output, err := t.cmd.Git("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(output) != "" {
+		return fmt.Errorf("uncommitted changes, commit or stash them first, %w", ErrDirtyWorkingTree)
+	}
+
+	todoPath, err := writeGitDirTempFile(t.cmd.WorkingDir(), rebaseTodoFilePattern, strings.Join(todo, "\n")+"\n")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(todoPath)
+
+	// The sequence editor just replaces the todo file git has created with the prepared file
+	args := []string{"-c", "sequence.editor=cp " + shellQuote(filepath.ToSlash(todoPath)),
+		"-c", "core.editor=true", "rebase", "--interactive"}
+	if base == "" {
+		args = append(args, "--root")
+	} else {
+		args = append(args, base)
+	}
+
+	output, err = t.cmd.Git(args...)
+	if err != nil {
+		if _, abortErr := t.cmd.Git("rebase", "--abort"); abortErr != nil {
+			return fmt.Errorf("%w, and failed to abort rebase, %v", err, abortErr)
+		}
+		if strings.Contains(output, "CONFLICT") || strings.Contains(err.Error(), "CONFLICT") {
+			return fmt.Errorf("edit resulted in conflicts, the branch was left unchanged")
+		}
+		return err
+	}
+	return nil
+}
+
+// amendMessageExec returns an exec todo line, which sets the message of the commit in the file
+func (t *historyService) amendMessageExec(msgPath string) string {
+	return "exec git commit --amend --only --allow-empty --no-verify -F " + shellQuote(msgPath)
+}
+
+// writeMessageFile writes the message to a temp file, which the caller removes when done
+func (t *historyService) writeMessageFile(message string) (string, error) {
+	msgPath, err := writeGitDirTempFile(t.cmd.WorkingDir(), commitMessageFilePattern, message)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(msgPath), nil
+}
+
+// shellQuote quotes the text for the shell, which git runs editors and exec commands in, where a
+// single quote in the text ends the quoting, is escaped and then starts the quoting again
+func shellQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
@@ -0,0 +1,122 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditLocalCommits(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	git := New(wf.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	wf.File("a.txt").Write("1")
+	assert.NoError(t, git.Commit("initial"))
+	wf.File("b.txt").Write("1")
+	assert.NoError(t, git.Commit("c1"))
+	wf.File("c.txt").Write("1")
+	assert.NoError(t, git.Commit("c2"))
+	wf.File("d.txt").Write("1")
+	assert.NoError(t, git.Commit("c3"))
+
+	// Amend message and staged content of HEAD
+	wf.File("e.txt").Write("1")
+	assert.NoError(t, git.StageFile("e.txt"))
+	assert.NoError(t, git.AmendCommit("c3 amended"))
+	cs, _ := git.GetLog()
+	assert.Equal(t, "c3 amended", cs[0].Subject)
+	assert.Equal(t, 4, len(cs))
+	st, _ := git.GetStatus()
+	assert.Equal(t, 0, st.Modified+st.Added+st.Deleted)
+
+	// Reword an older commit, which keeps the later commits
+	assert.NoError(t, git.RewordCommit(cs.MustBySubject("c1").ID, "c1 reworded\n\n#12 body"))
+	cs, _ = git.GetLog()
+	assert.Equal(t, 4, len(cs))
+	c1 := cs.MustBySubject("c1 reworded")
+	assert.Equal(t, "c1 reworded\n\n#12 body", c1.Message)
+	assert.Equal(t, "c3 amended", cs[0].Subject)
+
+	// Uncommitted changes are not stashed implicitly, so a drop is rejected
+	wf.File("a.txt").Write("2")
+	assert.ErrorIs(t, git.DropCommit(cs.MustBySubject("c2").ID), ErrDirtyWorkingTree)
+	assert.Equal(t, "2", wf.File("a.txt").Read())
+	assert.NoError(t, git.Commit("c4"))
+
+	// Drop a commit, later commits are kept
+	assert.NoError(t, git.DropCommit(cs.MustBySubject("c2").ID))
+	cs, _ = git.GetLog()
+	assert.Equal(t, 4, len(cs))
+	_, err := wf.File("c.txt").TryRead()
+	assert.Error(t, err)
+	assert.Equal(t, "2", wf.File("a.txt").Read())
+
+	// Squash c1 to c3 into one commit
+	cs, _ = git.GetLog()
+	assert.NoError(t, git.SquashCommits(cs.MustBySubject("c1 reworded").ID, cs.MustBySubject("c3 amended").ID, "squashed"))
+	cs, _ = git.GetLog()
+	assert.Equal(t, 3, len(cs))
+	assert.Equal(t, "c4", cs[0].Subject)
+	assert.Equal(t, "squashed", cs[1].Subject)
+	assert.Equal(t, "1", wf.File("d.txt").Read())
+	assert.Equal(t, "1", wf.File("e.txt").Read())
+}
+
+func TestEditCommitsInPathWithQuote(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	path := wf.MkDir("it's a repo").Path()
+	git := New(path)
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	wf.File("it's a repo", "a.txt").Write("1")
+	assert.NoError(t, git.Commit("initial"))
+	wf.File("it's a repo", "b.txt").Write("1")
+	assert.NoError(t, git.Commit("c1"))
+	wf.File("it's a repo", "c.txt").Write("1")
+	assert.NoError(t, git.Commit("c2"))
+
+	cs, _ := git.GetLog()
+	assert.NoError(t, git.RewordCommit(cs.MustBySubject("c1").ID, "c1 reworded"))
+	cs, _ = git.GetLog()
+	assert.Equal(t, []string{"c2", "c1 reworded", "initial"}, []string{cs[0].Subject, cs[1].Subject, cs[2].Subject})
+
+	// The todo and message files are removed
+	files, _ := filepath.Glob(filepath.Join(path, ".git", "gmc_*"))
+	assert.Empty(t, files)
+}
+
+func TestEditPushedCommits(t *testing.T) {
+	defer tests.CleanTemp()
+	wfRemote := tests.CreateTempFolder()
+	gitRemote := New(wfRemote.Path())
+	assert.NoError(t, gitRemote.InitRepoBare())
+
+	wf := tests.CreateTempFolder()
+	git := New(wf.Path())
+	assert.NoError(t, git.Clone(gitRemote.RepoPath(), wf.Path()))
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	wf.File("a.txt").Write("1")
+	assert.NoError(t, git.Commit("initial"))
+	assert.NoError(t, git.PushBranch("master"))
+	wf.File("b.txt").Write("1")
+	assert.NoError(t, git.Commit("local"))
+
+	cs, _ := git.GetLog()
+	pushed := cs.MustBySubject("initial").ID
+	assert.ErrorIs(t, git.RewordCommit(pushed, "reworded"), ErrPushedCommit)
+	assert.ErrorIs(t, git.DropCommit(pushed), ErrPushedCommit)
+	assert.ErrorIs(t, git.SquashCommits(pushed, cs.MustBySubject("local").ID, "squashed"), ErrPushedCommit)
+
+	// The local commit can still be edited
+	assert.NoError(t, git.RewordCommit(cs.MustBySubject("local").ID, "reworded"))
+	assert.NoError(t, git.PushBranch("master"))
+	assert.ErrorIs(t, git.AmendCommit("amended"), ErrPushedCommit)
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
@@ -69,6 +68,6 @@ func (t *keyValueService) getKeyPath(key string) string {
 }
 
 func (t *keyValueService) createTmpFile() (f *os.File, err error) {
-	gitRepoPath := filepath.Join(t.cmd.WorkingDir(), ".git")
+	gitRepoPath := GitDir(t.cmd.WorkingDir())
 	return ioutil.TempFile(gitRepoPath, "gmc-tmp-key-value-")
 }
@@ -54,6 +54,21 @@ func (t *logService) getLog(maxCount int) (Commits, error) {
 	return t.parseCommits(logText)
 }
 
+// getNewLog returns the commits, which are not reachable from the known commits, i.e. the known
+// commits and their ancestors are excluded
+func (t *logService) getNewLog(knownIDs []string) (Commits, error) {
+	args := []string{"log", "--all", "--date-order", "-z", "--pretty=%H|%ai|%ci|%an|%P|%B"}
+	if len(knownIDs) > 0 {
+		args = append(append(args, "--not"), knownIDs...)
+	}
+	logText, err := t.cmd.Git(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get new git log, %v", err)
+	}
+
+	return t.parseCommits(logText)
+}
+
 func (t *logService) getFiles(ref string) ([]string, error) {
 	args := []string{"ls-tree", "-r", ref, "--name-only"}
 
@@ -0,0 +1,498 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	symbolicRefPrefix = "ref: "
+	detachedAbbrevLen = 7 // Same as the default git abbreviation length of detached HEAD names
+)
+
+// objectReader reads the log, branches, tags and files directly from the refs and the object
+// files (loose objects, packfiles and the commit-graph) instead of running and parsing git
+// commands, which is much faster for large repos. Only reading is supported, status, diffs and
+// all commands still use git
+type objectReader struct {
+	workingDir string
+
+	storeMutex sync.Mutex
+	store      *objectStore
+
+	cacheMutex  sync.Mutex
+	cachedNodes map[objectID]*commitNode // Commits of the previous log, reused since commits never change
+}
+
+func newObjectReader(workingDir string) *objectReader {
+	return &objectReader{workingDir: workingDir}
+}
+
+func (t *objectReader) gitDir() string {
+	return GitDir(t.workingDir)
+}
+
+func (t *objectReader) commonGitDir() string {
+	return CommonGitDir(t.gitDir())
+}
+
+// objects returns the object store, which is created on first use and then reused, since it
+// keeps the packfiles open
+func (t *objectReader) objects() (*objectStore, error) {
+	t.storeMutex.Lock()
+	defer t.storeMutex.Unlock()
+	if t.store == nil {
+		store := newObjectStore(t.commonGitDir())
+		if err := store.refresh(); err != nil {
+			return nil, err
+		}
+		t.store = store
+	}
+	return t.store, nil
+}
+
+// getLog returns the commits reachable from all refs and HEAD in the same order as
+// 'git log --all --date-order', i.e. no parent before all of its children and otherwise by
+// commit time. maxCount <= 0 returns all commits
+func (t *objectReader) getLog(maxCount int) (Commits, error) {
+	return t.walkLog(maxCount, nil)
+}
+
+// getNewLog returns the commits, which are not reachable from the known commits, like
+// 'git log --all --date-order --not <known ids>'
+func (t *objectReader) getNewLog(knownIDs []string) (Commits, error) {
+	known := make([]objectID, 0, len(knownIDs))
+	for _, hexID := range knownIDs {
+		id, err := toObjectID(hexID)
+		if err != nil {
+			return nil, err
+		}
+		known = append(known, id)
+	}
+	return t.walkLog(-1, known)
+}
+
+// walkLog returns the commits of all refs in date order, except commits reachable from the
+// excluded commits
+func (t *objectReader) walkLog(maxCount int, excludedIDs []objectID) (Commits, error) {
+	w, err := t.newCommitWalker()
+	if err != nil {
+		return nil, err
+	}
+	tips, err := t.logTips(w)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[objectID]bool)
+	stack := append([]objectID{}, excludedIDs...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if excluded[id] {
+			continue
+		}
+		excluded[id] = true
+		n, err := w.node(id)
+		if err != nil {
+			return nil, err
+		}
+		stack = append(stack, n.parents...)
+	}
+
+	// All included commits must be visited to know when all children of a commit are shown
+	var included []*commitNode
+	isIncluded := make(map[objectID]bool)
+	stack = append([]objectID{}, tips...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if isIncluded[id] || excluded[id] {
+			continue
+		}
+		isIncluded[id] = true
+		n, err := w.node(id)
+		if err != nil {
+			return nil, err
+		}
+		included = append(included, n)
+		stack = append(stack, n.parents...)
+	}
+	for _, n := range included {
+		for _, p := range n.parents {
+			if !excluded[p] {
+				w.nodes[p].children++
+			}
+		}
+	}
+
+	// Only tips can be without children
+	queue := &commitQueue{}
+	for _, id := range tips {
+		if n := w.nodes[id]; !excluded[id] && n.children == 0 && !n.isQueued {
+			queue.add(n)
+		}
+	}
+
+	var logNodes []*commitNode
+	for queue.Len() > 0 && (maxCount <= 0 || len(logNodes) < maxCount) {
+		n := queue.pop()
+		logNodes = append(logNodes, n)
+		for _, p := range n.parents {
+			if excluded[p] {
+				continue
+			}
+			pn := w.nodes[p]
+			pn.children--
+			if pn.children == 0 {
+				queue.add(pn)
+			}
+		}
+	}
+
+	if err := w.readCommits(logNodes); err != nil {
+		return nil, err
+	}
+	commits := make(Commits, 0, len(logNodes))
+	for _, n := range logNodes {
+		commit, err := w.commit(n)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+
+	t.cacheMutex.Lock()
+	t.cachedNodes = w.nodes
+	t.cacheMutex.Unlock()
+	return commits, nil
+}
+
+// getBranches returns the same branches as 'git branch -vv --all', i.e. a detached HEAD,
+// local branches with upstream tracking info and remote branches
+func (t *objectReader) getBranches() (Branches, error) {
+	refs, err := t.readRefs()
+	if err != nil {
+		return nil, err
+	}
+	headRef, headID, err := readHead(t.gitDir(), refs)
+	if err != nil {
+		return nil, err
+	}
+	w, err := t.newCommitWalker()
+	if err != nil {
+		return nil, err
+	}
+	refIDs := make(map[string]objectID)
+	for _, r := range refs {
+		refIDs[r.name] = r.id
+	}
+	upstreams := t.readUpstreams()
+	worktreeBranches := t.readWorktreeBranches()
+
+	var branches Branches
+	if headRef == "" && headID != (objectID{}) {
+		subject, err := w.oneline(headID)
+		if err != nil {
+			return nil, err
+		}
+		name := headID.String()[:detachedAbbrevLen]
+		if rebasing, ok := t.readRebaseBranch(); ok {
+			name = rebasing
+		}
+		branches = append(branches, Branch{
+			Name:             fmt.Sprintf("(%s)", name),
+			DisplayName:      fmt.Sprintf("(%s)", name),
+			TipID:            headID.String(),
+			IsCurrent:        true,
+			IsDetached:       true,
+			TipCommitMessage: subject,
+		})
+	}
+
+	for _, r := range refs {
+		if r.target != "" || !strings.HasPrefix(r.name, "refs/heads/") {
+			continue
+		}
+		name := strings.TrimPrefix(r.name, "refs/heads/")
+		subject, err := w.oneline(r.id)
+		if err != nil {
+			return nil, err
+		}
+		b := Branch{
+			Name:             name,
+			DisplayName:      name,
+			TipID:            r.id.String(),
+			IsCurrent:        r.name == headRef,
+			TipCommitMessage: subject,
+			WorktreePath:     worktreeBranches[name],
+		}
+		if u, ok := upstreams[name]; ok {
+			b.RemoteName = u.name
+			b.Remote = RemoteOf(u.name)
+			if upstreamID, ok := refIDs[u.ref]; ok {
+				if b.AheadCount, b.BehindCount, err = w.aheadBehind(r.id, upstreamID); err != nil {
+					return nil, err
+				}
+			} else {
+				b.IsRemoteMissing = true
+			}
+		}
+		branches = append(branches, b)
+	}
+
+	for _, r := range refs {
+		if r.target != "" || !strings.HasPrefix(r.name, "refs/remotes/") {
+			continue
+		}
+		name := strings.TrimPrefix(r.name, "refs/remotes/")
+		subject, err := w.oneline(r.id)
+		if err != nil {
+			return nil, err
+		}
+		displayName := name
+		if strings.HasPrefix(name, DefaultRemote+"/") {
+			displayName = name[len(DefaultRemote)+1:]
+		}
+		branches = append(branches, Branch{
+			Name:             name,
+			DisplayName:      displayName,
+			TipID:            r.id.String(),
+			IsRemote:         true,
+			Remote:           RemoteOf(name),
+			TipCommitMessage: subject,
+		})
+	}
+	return branches, nil
+}
+
+// getTags returns the same tags as 'git show-ref --dereference --tags', i.e. annotated tags are
+// listed twice, with the tag object id and with the tagged commit id
+func (t *objectReader) getTags() ([]Tag, error) {
+	refs, err := t.readRefs()
+	if err != nil {
+		return nil, err
+	}
+	store, err := t.objects()
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []Tag
+	for _, r := range refs {
+		if r.target != "" || !strings.HasPrefix(r.name, "refs/tags/") {
+			continue
+		}
+		name := strings.TrimPrefix(r.name, "refs/tags/")
+		tags = append(tags, Tag{CommitID: r.id.String(), TagName: name})
+		if r.isPeeled {
+			tags = append(tags, Tag{CommitID: r.peeledID.String(), TagName: name})
+			continue
+		}
+		typ, data, err := store.readObject(r.id)
+		if err != nil {
+			return nil, err
+		}
+		if typ == objectTag {
+			peeledID, err := t.peel(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read tag %s, %v", name, err)
+			}
+			tags = append(tags, Tag{CommitID: peeledID.String(), TagName: name})
+		}
+	}
+	return tags, nil
+}
+
+// getFiles returns the paths of all files in the ref (branch, tag or commit id) in tree order,
+// same as the lines of 'git ls-tree -r --name-only <ref>'
+func (t *objectReader) getFiles(ref string) ([]string, error) {
+	id, err := t.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	store, err := t.objects()
+	if err != nil {
+		return nil, err
+	}
+	typ, data, err := store.readObject(id)
+	if err != nil {
+		return nil, err
+	}
+	if typ != objectCommit {
+		return nil, fmt.Errorf("ref %q is not a commit", ref)
+	}
+	treeID, err := toObjectID(string(bytes.TrimPrefix(firstLine(data), []byte("tree "))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse commit %s, %v", id, err)
+	}
+
+	var files []string
+	var walk func(treeID objectID, prefix string) error
+	walk = func(treeID objectID, prefix string) error {
+		_, data, err := store.readObject(treeID)
+		if err != nil {
+			return err
+		}
+		// Tree entries are '<mode> <name>\x00<20 bytes id>'
+		for len(data) > 0 {
+			i := bytes.IndexByte(data, 0)
+			if i == -1 || i+21 > len(data) {
+				return fmt.Errorf("invalid tree %s", treeID)
+			}
+			mode, name, _ := strings.Cut(string(data[:i]), " ")
+			var entryID objectID
+			copy(entryID[:], data[i+1:i+21])
+			data = data[i+21:]
+
+			if mode == "40000" {
+				if err := walk(entryID, prefix+name+"/"); err != nil {
+					return err
+				}
+				continue
+			}
+			files = append(files, prefix+name)
+		}
+		return nil
+	}
+	if err := walk(treeID, ""); err != nil {
+		return nil, err
+	}
+	// Same as the split git output, which ends with a new line
+	return append(files, ""), nil
+}
+
+// resolve returns the commit id of a commit id, branch, remote branch or tag name
+func (t *objectReader) resolve(ref string) (objectID, error) {
+	if id, err := toObjectID(ref); err == nil {
+		return id, nil
+	}
+	refs, err := t.readRefs()
+	if err != nil {
+		return objectID{}, err
+	}
+	if ref == "HEAD" {
+		_, id, err := readHead(t.gitDir(), refs)
+		return id, err
+	}
+	for _, name := range []string{ref, "refs/" + ref, "refs/heads/" + ref, "refs/tags/" + ref, "refs/remotes/" + ref} {
+		i := sort.Search(len(refs), func(i int) bool { return refs[i].name >= name })
+		if i < len(refs) && refs[i].name == name {
+			if refs[i].isPeeled {
+				return refs[i].peeledID, nil
+			}
+			return t.peelID(refs[i].id)
+		}
+	}
+	return objectID{}, fmt.Errorf("unknown ref %q", ref)
+}
+
+// logTips returns the commits of all refs and then HEAD of all worktrees, same as 'git log --all'
+func (t *objectReader) logTips(w *commitWalker) ([]objectID, error) {
+	refs, err := t.readRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	var tips []objectID
+	add := func(id objectID) error {
+		if id == (objectID{}) {
+			return nil
+		}
+		if _, ok := w.graphPosition(id); ok {
+			// Commits in the commit-graph do not need to be read to know they are commits
+			tips = append(tips, id)
+			return nil
+		}
+		commitID, err := t.peelID(id)
+		if err != nil {
+			return err
+		}
+		if commitID != (objectID{}) {
+			tips = append(tips, commitID)
+		}
+		return nil
+	}
+
+	for _, r := range refs {
+		id := r.id
+		if r.isPeeled {
+			id = r.peeledID
+		}
+		if err := add(id); err != nil {
+			return nil, err
+		}
+	}
+	_, headID, err := readHead(t.gitDir(), refs)
+	if err != nil {
+		return nil, err
+	}
+	if err := add(headID); err != nil {
+		return nil, err
+	}
+	worktreeDirs, _ := filepath.Glob(filepath.Join(t.commonGitDir(), "worktrees", "*"))
+	worktreeDirs = append(worktreeDirs, t.commonGitDir())
+	for _, dir := range worktreeDirs {
+		if isSamePath(dir, t.gitDir()) {
+			continue
+		}
+		// Other worktrees might be removed or being created
+		if _, id, err := readHead(dir, refs); err == nil {
+			if err := add(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return tips, nil
+}
+
+// peelID returns the commit of an annotated tag (or the id if it is a commit), or an empty id if
+// the tag is of some other object, e.g. a tree
+func (t *objectReader) peelID(id objectID) (objectID, error) {
+	store, err := t.objects()
+	if err != nil {
+		return objectID{}, err
+	}
+	for {
+		typ, data, err := store.readObject(id)
+		if err != nil {
+			return objectID{}, err
+		}
+		switch typ {
+		case objectCommit:
+			return id, nil
+		case objectTag:
+			if id, err = toObjectID(string(bytes.TrimPrefix(firstLine(data), []byte("object ")))); err != nil {
+				return objectID{}, fmt.Errorf("failed to parse tag %s, %v", id, err)
+			}
+		default:
+			return objectID{}, nil
+		}
+	}
+}
+
+// peel returns the object, which is tagged by the tag object data (following nested tags)
+func (t *objectReader) peel(tagData []byte) (objectID, error) {
+	store, err := t.objects()
+	if err != nil {
+		return objectID{}, err
+	}
+	for {
+		id, err := toObjectID(string(bytes.TrimPrefix(firstLine(tagData), []byte("object "))))
+		if err != nil {
+			return objectID{}, err
+		}
+		typ, data, err := store.readObject(id)
+		if err != nil {
+			return objectID{}, err
+		}
+		if typ != objectTag {
+			return id, nil
+		}
+		tagData = data
+	}
+}
@@ -0,0 +1,293 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectReaderSameAsGit(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+
+	// Prepare origin repo with branches, merges (incl an octopus merge) and tags
+	originPath := wf.MkDir("origin").Path()
+	assert.NoError(t, New(originPath).InitRepo())
+	imp := newFastImport()
+	imp.commit("main", 1600000000, "+0200", "initial", "a.txt")
+	imp.commit("feature", 1600000100, "+0000", "feature 1\n\nwith | body\r\nlines", "b.txt")
+	imp.commit("other", 1600000150, "-0530", "other 1", "c.txt")
+	imp.commit("main", 1600000200, "+0200", "main 2", "a.txt")
+	imp.commit("feature", 1600000300, "+0000", "feature 2", "dir/d.txt")
+	imp.commit("main", 1600000400, "+0200", "merge feature", "a.txt", "feature")
+	imp.commit("octopus", 1600000500, "+0100", "octopus", "e.txt", "feature", "other")
+	imp.tag("v1", "main", "Release 1")
+	imp.lightweightTag("v2", "feature")
+	imp.run(t, originPath)
+	runGit(t, originPath, "checkout", "-q", "main")
+
+	// Clone, with local branches tracking remote branches, which are ahead and behind
+	localPath := wf.Path("local")
+	runGit(t, wf.Path(), "clone", "-q", originPath, localPath)
+	local := New(localPath)
+	assert.NoError(t, local.ConfigUser("test", "test@test.com"))
+	assert.NoError(t, local.Checkout("feature"))
+	assert.NoError(t, local.Checkout("main"))
+	wf.File("local", "a.txt").Write("local")
+	assert.NoError(t, local.Commit("local 1"))
+
+	imp = newFastImport()
+	imp.continueBranch("main")
+	imp.commit("main", 1600000600, "+0200", "origin 1", "f.txt")
+	imp.commit("main", 1600000700, "+0200", "origin 2", "f.txt")
+	imp.run(t, originPath)
+	runGit(t, localPath, "fetch", "-q")
+	runGit(t, localPath, "branch", "-q", "gone", "main")
+	runGit(t, localPath, "config", "branch.gone.remote", "origin")
+	runGit(t, localPath, "config", "branch.gone.merge", "refs/heads/gone")
+
+	// Loose objects, packs from the clone and loose refs
+	assertObjectReaderSameAsGit(t, localPath)
+
+	// Single pack, packed-refs and a commit-graph
+	runGit(t, localPath, "gc", "-q")
+	assertObjectReaderSameAsGit(t, localPath)
+
+	// Split commit-graph chain and a detached HEAD
+	wf.File("local", "a.txt").Write("local 2")
+	assert.NoError(t, local.Commit("local 2"))
+	runGit(t, localPath, "commit-graph", "write", "--reachable", "--split")
+	assert.NoError(t, local.Checkout("HEAD~1"))
+	assertObjectReaderSameAsGit(t, localPath)
+
+	// Partial log, which is used for large repos
+	r := newObjectReader(localPath)
+	commits, err := r.getLog(3)
+	assert.NoError(t, err)
+	all, _ := local.GetLog()
+	assert.Equal(t, all[:3], commits)
+}
+
+func TestRemovedPacksKeptOpenForReaders(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+
+	path := wf.MkDir("repo").Path()
+	assert.NoError(t, New(path).InitRepo())
+	imp := newFastImport()
+	imp.commit("main", 1600000000, "+0200", "initial", "a.txt")
+	imp.run(t, path)
+	runGit(t, path, "gc", "-q")
+
+	store := newObjectStore(filepath.Join(path, ".git"))
+	defer store.close()
+	assert.NoError(t, store.refresh())
+	packs := store.acquirePacks()
+	assert.Len(t, packs, 1)
+
+	// A gc replaces the pack, while it is still read
+	imp = newFastImport()
+	imp.continueBranch("main")
+	imp.commit("main", 1600000100, "+0200", "second", "a.txt")
+	imp.run(t, path)
+	runGit(t, path, "gc", "-q")
+	assert.NoError(t, store.refresh())
+	assert.Len(t, store.packs, 1)
+	assert.NotEqual(t, packs[0], store.packs[0])
+	_, _, err := packs[0].readAt(packs[0].offset(0))
+	assert.NoError(t, err)
+
+	// The removed pack is closed, when the last reader releases it
+	store.releasePacks(packs)
+	_, err = packs[0].file.Stat()
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func assertObjectReaderSameAsGit(t *testing.T, path string) {
+	g := New(path).(*git)
+	r := newObjectReader(path)
+
+	expectedLog, err := g.logService.getLog(-1)
+	assert.NoError(t, err)
+	log, err := r.getLog(-1)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedLog, log)
+
+	// Commits, which are not reachable from the known commits, e.g. commits after a refresh
+	knownIDs := []string{expectedLog[len(expectedLog)/2].ID, expectedLog[len(expectedLog)-1].ID}
+	expectedNewLog, err := g.logService.getNewLog(knownIDs)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, expectedNewLog)
+	newLog, err := r.getNewLog(knownIDs)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedNewLog, newLog)
+
+	expectedBranches, err := g.branchService.getBranches()
+	assert.NoError(t, err)
+	branches, err := r.getBranches()
+	assert.NoError(t, err)
+	assert.Equal(t, expectedBranches, branches)
+
+	expectedTags, err := g.tagService.getTags()
+	assert.NoError(t, err)
+	tags, err := r.getTags()
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTags, tags)
+
+	for _, ref := range []string{"main", "origin/feature", "v1", expectedLog[1].ID} {
+		expectedFiles, err := g.logService.getFiles(ref)
+		assert.NoError(t, err)
+		files, err := r.getFiles(ref)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedFiles, files)
+	}
+}
+
+// The benchmarks compare reading the full log and the partial log (as for large repos) using git
+// and the object reader, both the first read and a refresh, which reuses the previous commits
+func BenchmarkGetLogGit(b *testing.B) {
+	benchmarkGetLog(b, false, false, -1)
+}
+
+func BenchmarkGetLogObjectReader(b *testing.B) {
+	benchmarkGetLog(b, true, false, -1)
+}
+
+func BenchmarkGetLogObjectReaderRefresh(b *testing.B) {
+	benchmarkGetLog(b, true, true, -1)
+}
+
+func BenchmarkGetPartialLogGit(b *testing.B) {
+	benchmarkGetLog(b, false, false, 500)
+}
+
+func BenchmarkGetPartialLogObjectReader(b *testing.B) {
+	benchmarkGetLog(b, true, false, 500)
+}
+
+func BenchmarkGetPartialLogObjectReaderRefresh(b *testing.B) {
+	benchmarkGetLog(b, true, true, 500)
+}
+
+func benchmarkGetLog(b *testing.B, isObjectReader, isRefresh bool, maxCount int) {
+	path := createBenchmarkRepo(b, 5000)
+	defer tests.CleanTemp()
+	g := New(path).(*git)
+	r := newObjectReader(path)
+	if isRefresh {
+		if _, err := r.getLog(maxCount); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var err error
+		switch {
+		case !isObjectReader:
+			_, err = g.logService.getLog(maxCount)
+		case isRefresh:
+			_, err = r.getLog(maxCount)
+		default:
+			_, err = newObjectReader(path).getLog(maxCount)
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// createBenchmarkRepo creates a gc:ed repo (packs and commit-graph) with a main branch and
+// feature branches, which are merged every 10 commits
+func createBenchmarkRepo(b *testing.B, commitCount int) string {
+	wf := tests.CreateTempFolder()
+	path := wf.MkDir("repo").Path()
+	if err := New(path).InitRepo(); err != nil {
+		b.Fatal(err)
+	}
+	imp := newFastImport()
+	for i := 0; i < commitCount; i++ {
+		tm := int64(1600000000 + i*60)
+		switch {
+		case i%10 == 5:
+			imp.commit(fmt.Sprintf("feature-%d", i/10), tm, "+0100", fmt.Sprintf("feature %d", i), "f.txt", "main")
+		case i%10 == 9:
+			imp.commit("main", tm, "+0100", fmt.Sprintf("merge %d", i), "a.txt", fmt.Sprintf("feature-%d", i/10))
+		default:
+			imp.commit("main", tm, "+0100", fmt.Sprintf("commit %d\n\nbody", i), "a.txt")
+		}
+	}
+	imp.run(b, path)
+	runGit(b, path, "gc", "-q")
+	return path
+}
+
+// fastImport creates commits with specified times using 'git fast-import', which is much faster
+// than running 'git commit' and avoids commits with the same commit time
+type fastImport struct {
+	sb       strings.Builder
+	marks    map[string]int  // Latest commit mark per branch
+	existing map[string]bool // Branches in the repo, which are continued by the next commit
+	mark     int
+}
+
+func newFastImport() *fastImport {
+	return &fastImport{marks: make(map[string]int), existing: make(map[string]bool)}
+}
+
+// continueBranch makes the next commit on the branch a child of the branch tip in the repo
+func (t *fastImport) continueBranch(branch string) {
+	t.existing[branch] = true
+}
+
+// commit adds a commit on the branch, which is created from the first merged branch if new
+func (t *fastImport) commit(branch string, tm int64, zone, message, file string, merges ...string) {
+	t.mark++
+	fmt.Fprintf(&t.sb, "commit refs/heads/%s\nmark :%d\n", branch, t.mark)
+	fmt.Fprintf(&t.sb, "author Author %d <a@test.com> %d %s\n", t.mark%3, tm, zone)
+	fmt.Fprintf(&t.sb, "committer Committer <c@test.com> %d %s\n", tm, zone)
+	fmt.Fprintf(&t.sb, "data %d\n%s\n", len(message), message)
+	if t.existing[branch] {
+		fmt.Fprintf(&t.sb, "from refs/heads/%s^0\n", branch)
+		delete(t.existing, branch)
+	} else if _, ok := t.marks[branch]; !ok && len(merges) > 0 {
+		fmt.Fprintf(&t.sb, "from :%d\n", t.marks[merges[0]])
+		merges = merges[1:]
+	}
+	for _, m := range merges {
+		fmt.Fprintf(&t.sb, "merge :%d\n", t.marks[m])
+	}
+	content := fmt.Sprintf("%s %d\n", file, t.mark)
+	fmt.Fprintf(&t.sb, "M 644 inline %s\ndata %d\n%s\n", file, len(content), content)
+	t.marks[branch] = t.mark
+}
+
+func (t *fastImport) tag(name, branch, message string) {
+	fmt.Fprintf(&t.sb, "tag %s\nfrom :%d\n", name, t.marks[branch])
+	fmt.Fprintf(&t.sb, "tagger Tagger <t@test.com> 1600000000 +0000\ndata %d\n%s\n", len(message), message)
+}
+
+func (t *fastImport) lightweightTag(name, branch string) {
+	fmt.Fprintf(&t.sb, "reset refs/tags/%s\nfrom :%d\n\n", name, t.marks[branch])
+}
+
+func (t *fastImport) run(tb testing.TB, path string) {
+	cmd := exec.Command("git", "fast-import", "--quiet")
+	cmd.Dir = path
+	cmd.Stdin = strings.NewReader(t.sb.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		tb.Fatalf("fast-import failed, %v\n%s", err, out)
+	}
+}
+
+func runGit(tb testing.TB, path string, args ...string) {
+	if _, err := newGitCmd(path).Git(args...); err != nil {
+		tb.Fatal(err)
+	}
+}
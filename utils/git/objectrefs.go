@@ -0,0 +1,234 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/michael-reichenauer/gmc/utils"
+)
+
+// gitRef is a ref, e.g. refs/heads/main, where a symbolic ref has a target ref name
+type gitRef struct {
+	name     string
+	id       objectID
+	peeledID objectID // The commit of an annotated tag, if known from packed-refs
+	isPeeled bool
+	target   string
+}
+
+// readRefs returns all refs sorted by name, where loose ref files override packed refs
+func (t *objectReader) readRefs() ([]gitRef, error) {
+	commonDir := t.commonGitDir()
+	refs := make(map[string]gitRef)
+
+	if data, err := utils.FileRead(filepath.Join(commonDir, "packed-refs")); err == nil {
+		var last *gitRef
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			switch {
+			case line == "" || strings.HasPrefix(line, "#"):
+			case strings.HasPrefix(line, "^"):
+				// The peeled commit of the annotated tag on the previous line
+				if id, err := toObjectID(line[1:]); err == nil && last != nil {
+					last.peeledID = id
+					last.isPeeled = true
+					refs[last.name] = *last
+				}
+			default:
+				hexID, name, _ := strings.Cut(line, " ")
+				id, err := toObjectID(hexID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse packed-refs line %q, %v", line, err)
+				}
+				last = &gitRef{name: name, id: id}
+				refs[name] = *last
+			}
+		}
+	}
+
+	refsDir := filepath.Join(commonDir, "refs")
+	err := filepath.Walk(refsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(commonDir, path)
+		if err != nil {
+			return nil
+		}
+		data, err := utils.FileRead(path)
+		if err != nil {
+			return nil
+		}
+		name := filepath.ToSlash(rel)
+		text := strings.TrimSpace(string(data))
+		if strings.HasPrefix(text, symbolicRefPrefix) {
+			refs[name] = gitRef{name: name, target: strings.TrimPrefix(text, symbolicRefPrefix)}
+			return nil
+		}
+		if id, err := toObjectID(text); err == nil {
+			refs[name] = gitRef{name: name, id: id}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read refs, %v", err)
+	}
+
+	var sorted []gitRef
+	for _, r := range refs {
+		if r.target != "" {
+			// Symbolic refs (e.g. refs/remotes/origin/HEAD) get the id of the target
+			target, ok := refs[r.target]
+			if !ok || target.target != "" {
+				continue
+			}
+			r.id, r.peeledID, r.isPeeled = target.id, target.peeledID, target.isPeeled
+		}
+		sorted = append(sorted, r)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	return sorted, nil
+}
+
+// readHead returns the HEAD branch ref name and commit id, the ref name is empty if detached
+// and the id is empty if the branch has no commits yet
+func readHead(gitDir string, refs []gitRef) (string, objectID, error) {
+	data, err := utils.FileRead(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", objectID{}, fmt.Errorf("failed to read HEAD, %v", err)
+	}
+	text := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(text, symbolicRefPrefix) {
+		id, err := toObjectID(text)
+		return "", id, err
+	}
+
+	name := strings.TrimPrefix(text, symbolicRefPrefix)
+	for _, r := range refs {
+		if r.name == name {
+			return name, r.id, nil
+		}
+	}
+	return name, objectID{}, nil
+}
+
+// readRebaseBranch returns the name of the branch being rebased, if HEAD is detached by a rebase
+func (t *objectReader) readRebaseBranch() (string, bool) {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		if data, err := utils.FileRead(filepath.Join(t.gitDir(), dir, "head-name")); err == nil {
+			return strings.TrimPrefix(strings.TrimSpace(string(data)), "refs/heads/"), true
+		}
+	}
+	return "", false
+}
+
+type upstream struct {
+	name string // Shown name, e.g. origin/main
+	ref  string // Ref name, e.g. refs/remotes/origin/main
+}
+
+// readUpstreams returns the upstream branches from the "branch.<name>.remote" and
+// "branch.<name>.merge" values in the repo config file
+func (t *objectReader) readUpstreams() map[string]upstream {
+	remotes := make(map[string]string)
+	merges := make(map[string]string)
+	data, err := utils.FileRead(filepath.Join(t.commonGitDir(), "config"))
+	if err != nil {
+		return nil
+	}
+
+	branch := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			branch = ""
+			section := strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			if strings.HasPrefix(section, "branch ") {
+				branch = strings.Trim(strings.TrimSpace(strings.TrimPrefix(section, "branch ")), `"`)
+			}
+			continue
+		}
+		if branch == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "remote":
+			remotes[branch] = value
+		case "merge":
+			merges[branch] = value
+		}
+	}
+
+	upstreams := make(map[string]upstream)
+	for branch, remote := range remotes {
+		merge, ok := merges[branch]
+		if !ok {
+			continue
+		}
+		name := strings.TrimPrefix(merge, "refs/heads/")
+		if remote == "." {
+			upstreams[branch] = upstream{name: name, ref: merge}
+			continue
+		}
+		upstreams[branch] = upstream{name: remote + "/" + name, ref: "refs/remotes/" + remote + "/" + name}
+	}
+	return upstreams
+}
+
+// readWorktreeBranches returns the paths of other worktrees by the branch checked out
+func (t *objectReader) readWorktreeBranches() map[string]string {
+	branches := make(map[string]string)
+	dirs, _ := filepath.Glob(filepath.Join(t.commonGitDir(), "worktrees", "*"))
+	dirs = append(dirs, t.commonGitDir())
+	for _, dir := range dirs {
+		if isSamePath(dir, t.gitDir()) {
+			continue
+		}
+		data, err := utils.FileRead(filepath.Join(dir, "HEAD"))
+		if err != nil {
+			continue
+		}
+		head := strings.TrimSpace(string(data))
+		if !strings.HasPrefix(head, symbolicRefPrefix+"refs/heads/") {
+			continue
+		}
+		name := strings.TrimPrefix(head, symbolicRefPrefix+"refs/heads/")
+		path := filepath.Dir(dir)
+		if gitDirFile, err := utils.FileRead(filepath.Join(dir, "gitdir")); err == nil {
+			// A linked worktree has a "gitdir" file with the path of the worktree .git file
+			path = filepath.Dir(filepath.FromSlash(strings.TrimSpace(string(gitDirFile))))
+		} else if readBareConfig(dir) {
+			continue
+		}
+		branches[name] = path
+	}
+	return branches
+}
+
+func readBareConfig(gitDir string) bool {
+	data, err := utils.FileRead(filepath.Join(gitDir, "config"))
+	return err == nil && strings.Contains(strings.ReplaceAll(string(data), " ", ""), "bare=true")
+}
+
+// readShallow returns the commits of a shallow clone, whose parents are not in the repo
+func (t *objectReader) readShallow() map[objectID]bool {
+	data, err := utils.FileRead(filepath.Join(t.commonGitDir(), "shallow"))
+	if err != nil {
+		return nil
+	}
+	shallow := make(map[objectID]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		if id, err := toObjectID(strings.TrimSpace(line)); err == nil {
+			shallow[id] = true
+		}
+	}
+	return shallow
+}
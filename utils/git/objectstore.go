@@ -0,0 +1,261 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/michael-reichenauer/gmc/utils"
+)
+
+type objectType int
+
+const (
+	objectCommit   objectType = 1
+	objectTree     objectType = 2
+	objectBlob     objectType = 3
+	objectTag      objectType = 4
+	objectOfsDelta objectType = 6
+	objectRefDelta objectType = 7
+)
+
+// objectID is a binary sha1 object id, used instead of hex strings when walking many commits
+type objectID [20]byte
+
+func (id objectID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func toObjectID(hexID string) (objectID, error) {
+	var id objectID
+	if len(hexID) != 40 {
+		return id, fmt.Errorf("invalid object id %q", hexID)
+	}
+	if _, err := hex.Decode(id[:], []byte(hexID)); err != nil {
+		return id, fmt.Errorf("invalid object id %q, %v", hexID, err)
+	}
+	return id, nil
+}
+
+var zlibReaders sync.Pool
+
+// objectStore reads objects directly from the loose object files and the packfiles in the
+// objects folder (and alternate object folders), without running git
+type objectStore struct {
+	objectsDirs []string
+
+	packsMutex sync.Mutex
+	packs      []*packFile
+}
+
+func newObjectStore(commonGitDir string) *objectStore {
+	objectsDir := filepath.Join(commonGitDir, "objects")
+	return &objectStore{objectsDirs: append([]string{objectsDir}, readAlternates(objectsDir)...)}
+}
+
+// readObject returns the type and the content of the object
+func (t *objectStore) readObject(id objectID) (objectType, []byte, error) {
+	typ, data, found, err := t.readPackedObject(id)
+	if err != nil || found {
+		return typ, data, err
+	}
+	typ, data, found, err = t.readLooseObject(id)
+	if err != nil || found {
+		return typ, data, err
+	}
+
+	// The object might be in a new pack (e.g. after a fetch or a gc), rescan packs and retry
+	if err := t.refresh(); err != nil {
+		return 0, nil, err
+	}
+	typ, data, found, err = t.readPackedObject(id)
+	if err != nil || found {
+		return typ, data, err
+	}
+	return 0, nil, fmt.Errorf("object %s not found", id)
+}
+
+// refresh opens new packfiles and closes packfiles, which have been removed (e.g. by a gc)
+func (t *objectStore) refresh() error {
+	t.packsMutex.Lock()
+	defer t.packsMutex.Unlock()
+
+	var paths []string
+	for _, dir := range t.objectsDirs {
+		files, err := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+		if err != nil {
+			return fmt.Errorf("failed to list packs in %s, %v", dir, err)
+		}
+		paths = append(paths, files...)
+	}
+
+	var packs []*packFile
+	for _, path := range paths {
+		if p, ok := t.packByPath(path); ok {
+			packs = append(packs, p)
+			continue
+		}
+		p, err := openPackFile(t, path)
+		if err != nil {
+			return err
+		}
+		packs = append(packs, p)
+	}
+	for _, p := range t.packs {
+		if !utils.FileExists(p.idxPath) {
+			p.remove()
+		}
+	}
+	t.packs = packs
+	return nil
+}
+
+func (t *objectStore) close() {
+	t.packsMutex.Lock()
+	defer t.packsMutex.Unlock()
+	for _, p := range t.packs {
+		p.remove()
+	}
+	t.packs = nil
+}
+
+func (t *objectStore) packByPath(idxPath string) (*packFile, bool) {
+	for _, p := range t.packs {
+		if p.idxPath == idxPath {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func (t *objectStore) readPackedObject(id objectID) (objectType, []byte, bool, error) {
+	packs := t.acquirePacks()
+	defer t.releasePacks(packs)
+
+	for _, p := range packs {
+		if offset, ok := p.find(id); ok {
+			typ, data, err := p.readAt(offset)
+			if err != nil {
+				return 0, nil, true, fmt.Errorf("failed to read object %s in %s, %v", id, p.packPath, err)
+			}
+			return typ, data, true, nil
+		}
+	}
+	return 0, nil, false, nil
+}
+
+// acquirePacks returns the current packs, which are kept open until released, even if they are
+// removed by a concurrent refresh
+func (t *objectStore) acquirePacks() []*packFile {
+	t.packsMutex.Lock()
+	defer t.packsMutex.Unlock()
+	for _, p := range t.packs {
+		p.acquire()
+	}
+	return t.packs
+}
+
+func (t *objectStore) releasePacks(packs []*packFile) {
+	t.packsMutex.Lock()
+	defer t.packsMutex.Unlock()
+	for _, p := range packs {
+		p.release()
+	}
+}
+
+// readLooseObject reads a zlib compressed "objects/xx/yyyy..." file with a
+// "<type> <size>\x00<content>" object
+func (t *objectStore) readLooseObject(id objectID) (objectType, []byte, bool, error) {
+	hexID := id.String()
+	for _, dir := range t.objectsDirs {
+		file, err := os.Open(filepath.Join(dir, hexID[:2], hexID[2:]))
+		if err != nil {
+			continue
+		}
+		data, err := inflate(file, -1)
+		file.Close()
+		if err != nil {
+			return 0, nil, true, fmt.Errorf("failed to read object %s, %v", hexID, err)
+		}
+
+		header, content, ok := bytes.Cut(data, []byte{0})
+		if !ok {
+			return 0, nil, true, fmt.Errorf("invalid object %s", hexID)
+		}
+		typeName, _, _ := strings.Cut(string(header), " ")
+		typ, ok := toObjectType(typeName)
+		if !ok {
+			return 0, nil, true, fmt.Errorf("invalid object type %q of %s", typeName, hexID)
+		}
+		return typ, content, true, nil
+	}
+	return 0, nil, false, nil
+}
+
+func toObjectType(name string) (objectType, bool) {
+	switch name {
+	case "commit":
+		return objectCommit, true
+	case "tree":
+		return objectTree, true
+	case "blob":
+		return objectBlob, true
+	case "tag":
+		return objectTag, true
+	}
+	return 0, false
+}
+
+// inflate decompresses zlib data, if size is known, the returned slice is allocated once
+func inflate(r io.Reader, size int64) ([]byte, error) {
+	var zr io.ReadCloser
+	if pooled, ok := zlibReaders.Get().(io.ReadCloser); ok {
+		if err := pooled.(zlib.Resetter).Reset(r, nil); err != nil {
+			return nil, err
+		}
+		zr = pooled
+	} else {
+		var err error
+		if zr, err = zlib.NewReader(r); err != nil {
+			return nil, err
+		}
+	}
+	defer zlibReaders.Put(zr)
+
+	if size < 0 {
+		return io.ReadAll(zr)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readAlternates returns the object folders listed in "objects/info/alternates", used e.g.
+// by repos cloned with --shared or --reference
+func readAlternates(objectsDir string) []string {
+	data, err := utils.FileRead(filepath.Join(objectsDir, "info", "alternates"))
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		dir := filepath.FromSlash(line)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(objectsDir, dir)
+		}
+		dirs = append(dirs, filepath.Clean(dir))
+	}
+	return dirs
+}
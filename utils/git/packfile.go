@@ -0,0 +1,311 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/michael-reichenauer/gmc/utils"
+)
+
+var packReaders = sync.Pool{New: func() interface{} { return bufio.NewReaderSize(nil, 1024) }}
+
+const (
+	packIdxHeaderSize = 8 + 256*4 // "\377tOc", version 2 and the fanout table
+	maxDeltaBaseCache = 512       // Number of cached delta base objects per pack
+)
+
+// packFile reads objects from a "pack-<sha>.pack" file, using the "pack-<sha>.idx" (version 2)
+// index to find object offsets. Objects may be stored as deltas to other objects in the pack
+type packFile struct {
+	store    *objectStore
+	idxPath  string
+	packPath string
+	idx      []byte
+	count    int
+	file     *os.File
+
+	// Guarded by the store packs mutex, a removed pack is closed when the last reader is done
+	readers   int
+	isRemoved bool
+
+	cacheMutex sync.Mutex
+	cache      map[int64]cachedObject // Delta base objects by offset
+}
+
+type cachedObject struct {
+	typ  objectType
+	data []byte
+}
+
+func openPackFile(store *objectStore, idxPath string) (*packFile, error) {
+	idx, err := utils.FileRead(idxPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack index %s, %v", idxPath, err)
+	}
+	if len(idx) < packIdxHeaderSize || !bytes.Equal(idx[:4], []byte("\377tOc")) ||
+		binary.BigEndian.Uint32(idx[4:8]) != 2 {
+		return nil, fmt.Errorf("unsupported pack index %s, only version 2 is supported", idxPath)
+	}
+	count := int(binary.BigEndian.Uint32(idx[8+255*4:]))
+	if len(idx) < packIdxHeaderSize+count*(20+4+4) {
+		return nil, fmt.Errorf("invalid pack index %s", idxPath)
+	}
+
+	packPath := strings.TrimSuffix(idxPath, ".idx") + ".pack"
+	file, err := os.Open(packPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pack %s, %v", packPath, err)
+	}
+	return &packFile{
+		store:    store,
+		idxPath:  idxPath,
+		packPath: packPath,
+		idx:      idx,
+		count:    count,
+		file:     file,
+		cache:    make(map[int64]cachedObject),
+	}, nil
+}
+
+// remove closes the pack, or if it is being read, when the last reader releases it
+func (t *packFile) remove() {
+	t.isRemoved = true
+	if t.readers == 0 {
+		t.file.Close()
+	}
+}
+
+func (t *packFile) acquire() {
+	t.readers++
+}
+
+func (t *packFile) release() {
+	t.readers--
+	if t.readers == 0 && t.isRemoved {
+		t.file.Close()
+	}
+}
+
+// find returns the pack offset of the object, using a binary search in the sorted ids,
+// within the fanout range of ids, which start with the same first byte
+func (t *packFile) find(id objectID) (int64, bool) {
+	lo := 0
+	if id[0] > 0 {
+		lo = int(binary.BigEndian.Uint32(t.idx[8+(int(id[0])-1)*4:]))
+	}
+	hi := int(binary.BigEndian.Uint32(t.idx[8+int(id[0])*4:]))
+	for lo < hi {
+		mid := (lo + hi) / 2
+		pos := packIdxHeaderSize + mid*20
+		switch bytes.Compare(t.idx[pos:pos+20], id[:]) {
+		case 0:
+			return t.offset(mid), true
+		case -1:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return 0, false
+}
+
+// offset returns the offset of the object at index i, where offsets larger than 31 bits are
+// stored in a separate table of 8 byte offsets
+func (t *packFile) offset(i int) int64 {
+	offsetsPos := packIdxHeaderSize + t.count*(20+4)
+	offset := binary.BigEndian.Uint32(t.idx[offsetsPos+i*4:])
+	if offset&0x80000000 == 0 {
+		return int64(offset)
+	}
+	largePos := offsetsPos + t.count*4 + int(offset&0x7fffffff)*8
+	return int64(binary.BigEndian.Uint64(t.idx[largePos:]))
+}
+
+// readAt reads the object at the offset and resolves delta objects to the full object
+func (t *packFile) readAt(offset int64) (objectType, []byte, error) {
+	r := packReaders.Get().(*bufio.Reader)
+	r.Reset(io.NewSectionReader(t.file, offset, 1<<62))
+	defer packReaders.Put(r)
+	typ, size, err := readPackObjectHeader(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	switch typ {
+	case objectCommit, objectTree, objectBlob, objectTag:
+		data, err := inflate(r, size)
+		return typ, data, err
+
+	case objectOfsDelta:
+		distance, err := readOffsetDistance(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		delta, err := inflate(r, size)
+		if err != nil {
+			return 0, nil, err
+		}
+		baseType, base, err := t.readDeltaBase(offset - distance)
+		if err != nil {
+			return 0, nil, err
+		}
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+
+	case objectRefDelta:
+		var baseID objectID
+		if _, err := io.ReadFull(r, baseID[:]); err != nil {
+			return 0, nil, err
+		}
+		delta, err := inflate(r, size)
+		if err != nil {
+			return 0, nil, err
+		}
+		var baseType objectType
+		var base []byte
+		if baseOffset, ok := t.find(baseID); ok {
+			baseType, base, err = t.readDeltaBase(baseOffset)
+		} else {
+			baseType, base, err = t.store.readObject(baseID)
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+	}
+	return 0, nil, fmt.Errorf("unknown pack object type %d at %d", typ, offset)
+}
+
+// readDeltaBase reads an object, which other objects are deltas of, and caches it since bases
+// are usually shared by several objects
+func (t *packFile) readDeltaBase(offset int64) (objectType, []byte, error) {
+	t.cacheMutex.Lock()
+	cached, ok := t.cache[offset]
+	t.cacheMutex.Unlock()
+	if ok {
+		return cached.typ, cached.data, nil
+	}
+
+	typ, data, err := t.readAt(offset)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	t.cacheMutex.Lock()
+	if len(t.cache) >= maxDeltaBaseCache {
+		t.cache = make(map[int64]cachedObject)
+	}
+	t.cache[offset] = cachedObject{typ: typ, data: data}
+	t.cacheMutex.Unlock()
+	return typ, data, nil
+}
+
+// readPackObjectHeader reads the type (3 bits) and the inflated size (variable length, 4 bits in
+// the first byte and then 7 bits per byte as long as the high bit is set)
+func readPackObjectHeader(r io.ByteReader) (objectType, int64, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	typ := objectType((c >> 4) & 7)
+	size := int64(c & 0x0f)
+	shift := 4
+	for c&0x80 != 0 {
+		if c, err = r.ReadByte(); err != nil {
+			return 0, 0, err
+		}
+		size |= int64(c&0x7f) << shift
+		shift += 7
+	}
+	return typ, size, nil
+}
+
+// readOffsetDistance reads the negative base offset of an ofs-delta, where each continuation byte
+// adds one before shifting to avoid redundant encodings
+func readOffsetDistance(r io.ByteReader) (int64, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	distance := int64(c & 0x7f)
+	for c&0x80 != 0 {
+		if c, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+		distance = ((distance + 1) << 7) | int64(c&0x7f)
+	}
+	return distance, nil
+}
+
+// applyDelta creates an object from the base object and a delta, which starts with the base and
+// result sizes followed by instructions to either copy a base range or insert new data
+func applyDelta(base, delta []byte) ([]byte, error) {
+	pos := 0
+	readSize := func() int {
+		size, shift := 0, 0
+		for pos < len(delta) {
+			c := delta[pos]
+			pos++
+			size |= int(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				break
+			}
+		}
+		return size
+	}
+
+	if baseSize := readSize(); baseSize != len(base) {
+		return nil, fmt.Errorf("delta base size %d does not match %d", baseSize, len(base))
+	}
+	result := make([]byte, 0, readSize())
+
+	for pos < len(delta) {
+		op := delta[pos]
+		pos++
+		switch {
+		case op&0x80 != 0:
+			// Copy from base, where bits 0-3 select offset bytes and bits 4-6 select size bytes
+			offset, size := 0, 0
+			for i := 0; i < 4; i++ {
+				if op&(1<<i) != 0 && pos < len(delta) {
+					offset |= int(delta[pos]) << (8 * i)
+					pos++
+				}
+			}
+			for i := 0; i < 3; i++ {
+				if op&(1<<(4+i)) != 0 && pos < len(delta) {
+					size |= int(delta[pos]) << (8 * i)
+					pos++
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > len(base) {
+				return nil, fmt.Errorf("delta copy outside base object")
+			}
+			result = append(result, base[offset:offset+size]...)
+		case op != 0:
+			// Insert the next op bytes
+			if pos+int(op) > len(delta) {
+				return nil, fmt.Errorf("delta insert outside delta")
+			}
+			result = append(result, delta[pos:pos+int(op)]...)
+			pos += int(op)
+		default:
+			return nil, fmt.Errorf("invalid delta instruction")
+		}
+	}
+	if len(result) != cap(result) {
+		return nil, fmt.Errorf("delta result size %d does not match %d", len(result), cap(result))
+	}
+	return result, nil
+}
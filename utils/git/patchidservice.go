@@ -0,0 +1,105 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// detection of branches, which were merged by a squash merge or a rebase, e.g. of a pull request
+// on the server, where the branch commits are not part of the target branch, but the changes are.
+// Changes are compared by patch ids (git patch-id), which are the same for the same changes
+type patchIDService struct {
+	cmd gitCommander
+}
+
+func newPatchIDService(cmd gitCommander) *patchIDService {
+	return &patchIDService{cmd: cmd}
+}
+
+// maxPatchCommits is the max number of branch and target commits, which are compared by patch ids,
+// to limit the time for long-lived branches and targets with many commits since the merge base
+const maxPatchCommits = 500
+
+// isSquashMerged returns true if the changes of the branch commits exist in the target commits
+// after since (e.g. the merge base), either commit by commit (rebased) or as one commit with the
+// combined changes (squashed). Returns false if all branch commits are part of the target
+// branch (e.g. a normal merge)
+func (t *patchIDService) isSquashMerged(name, target, since string) (bool, error) {
+	// Patch ids of the branch commits, which are not in the target
+	branchLog, err := t.cmd.Git("log", "-p", "--no-color", "--no-ext-diff", "--no-merges",
+		fmt.Sprintf("--max-count=%d", maxPatchCommits), target+".."+name)
+	if err != nil {
+		return false, fmt.Errorf("failed to get log of %q, %v", name, err)
+	}
+	commitIDs, err := t.patchIDs(branchLog)
+	if err != nil || len(commitIDs) == 0 {
+		// No branch commits, which are not in the target
+		return false, err
+	}
+
+	// Patch id of the combined branch changes since the merge base
+	diff, err := t.cmd.Git("diff", "--no-color", "--no-ext-diff", target+"..."+name)
+	if err != nil {
+		return false, fmt.Errorf("failed to get diff of %q, %v", name, err)
+	}
+	branchIDs, err := t.patchIDs(diff)
+	if err != nil {
+		return false, err
+	}
+
+	// Patch ids of the latest target commits since the merge base or the previously compared commits
+	targetLog, err := t.cmd.Git("log", "-p", "--no-color", "--no-ext-diff", "--no-merges",
+		fmt.Sprintf("--max-count=%d", maxPatchCommits), since+".."+target)
+	if err != nil {
+		return false, fmt.Errorf("failed to get log of %q, %v", target, err)
+	}
+	targetIDs, err := t.patchIDs(targetLog)
+	if err != nil {
+		return false, err
+	}
+
+	if len(branchIDs) > 0 && lo.Contains(targetIDs, branchIDs[0]) {
+		// Squashed
+		return true, nil
+	}
+	// Rebased, if all branch commits have a target commit with the same patch id
+	return lo.Every(targetIDs, commitIDs), nil
+}
+
+// mergeBase returns the best common ancestor commit id of the two commits
+func (t *patchIDService) mergeBase(name1, name2 string) (string, error) {
+	output, err := t.cmd.Git("merge-base", name1, name2)
+	if err != nil {
+		return "", fmt.Errorf("failed to get merge base of %q and %q, %v", name1, name2, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// patchIDs returns the patch ids of the diff or the log with diffs
+func (t *patchIDService) patchIDs(diff string) ([]string, error) {
+	if strings.TrimSpace(diff) == "" {
+		return nil, nil
+	}
+	output, err := t.cmd.GitWithInput(diff, "patch-id", "--stable")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get patch ids, %v", err)
+	}
+	var ids []string
+	for _, line := range nonEmptyLines(output) {
+		// Each line is "<patch id> <commit id>"
+		ids = append(ids, strings.Fields(line)[0])
+	}
+	return ids, nil
+}
+
+func nonEmptyLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
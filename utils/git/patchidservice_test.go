@@ -0,0 +1,88 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSquashMerged(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	g := New(wf.Path())
+	assert.NoError(t, g.InitRepo())
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	wf.File("a.txt").Write("1")
+	assert.NoError(t, g.Commit("initial"))
+
+	// Branches with two commits each, on different files
+	for _, name := range []string{"squashed", "rebased", "merged", "open"} {
+		assert.NoError(t, g.Checkout("master"))
+		assert.NoError(t, g.CreateBranch(name))
+		wf.File(name + ".txt").Write("1")
+		assert.NoError(t, g.Commit(name+" 1"))
+		wf.File(name + ".txt").Write("2")
+		assert.NoError(t, g.Commit(name+" 2"))
+	}
+	assert.NoError(t, g.Checkout("master"))
+
+	// Squash merge, rebase (cherry-pick) and normal merge into master
+	assert.NoError(t, g.MergeSquashBranch("squashed"))
+	assert.NoError(t, g.Commit("Squashed (#1)"))
+	cs, err := g.GetLog()
+	assert.NoError(t, err)
+	assert.NoError(t, g.CherryPick([]string{cs.MustBySubject("rebased 1").ID, cs.MustBySubject("rebased 2").ID}))
+	assert.NoError(t, g.MergeBranch("merged"))
+	assert.NoError(t, g.Commit("Merged"))
+
+	cases := []struct {
+		name       string
+		isSquashed bool
+	}{
+		{"squashed", true},
+		{"rebased", true},
+		{"merged", false},
+		{"open", false},
+	}
+	for _, c := range cases {
+		base, err := g.MergeBase(c.name, "master")
+		assert.NoError(t, err)
+		isSquashed, err := g.IsSquashMerged(c.name, "master", base)
+		assert.NoError(t, err)
+		assert.Equal(t, c.isSquashed, isSquashed, c.name)
+	}
+}
+
+func TestIsSquashMergedSince(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	g := New(wf.Path())
+	assert.NoError(t, g.InitRepo())
+	assert.NoError(t, g.ConfigUser("test", "test@test.com"))
+	wf.File("a.txt").Write("1")
+	assert.NoError(t, g.Commit("initial"))
+	assert.NoError(t, g.CreateBranch("feature"))
+	wf.File("feature.txt").Write("1")
+	assert.NoError(t, g.Commit("feature 1"))
+	assert.NoError(t, g.Checkout("master"))
+	base, err := g.MergeBase("feature", "master")
+	assert.NoError(t, err)
+
+	// Not merged, when compared with the master commits since the merge base
+	wf.File("b.txt").Write("1")
+	assert.NoError(t, g.Commit("other"))
+	isSquashed, err := g.IsSquashMerged("feature", "master", base)
+	assert.NoError(t, err)
+	assert.False(t, isSquashed)
+	cs, err := g.GetLog()
+	assert.NoError(t, err)
+	since := cs.MustBySubject("other").ID
+
+	// Merged, when compared only with newer master commits
+	assert.NoError(t, g.MergeSquashBranch("feature"))
+	assert.NoError(t, g.Commit("Feature (#1)"))
+	isSquashed, err = g.IsSquashMerged("feature", "master", since)
+	assert.NoError(t, err)
+	assert.True(t, isSquashed)
+}
@@ -0,0 +1,106 @@
+package git
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const progressInterval = 200 * time.Millisecond
+
+// RemoteProgress is the progress of a remote command, e.g. a clone, parsed from the git --progress
+// output, like "Receiving objects:  45% (4500/10000), 1.20 MiB | 2.40 MiB/s"
+type RemoteProgress struct {
+	Phase       string // E.g. "Receiving objects" or "Resolving deltas"
+	Percent     int
+	Current     int
+	Total       int
+	Transferred string // E.g. "1.20 MiB", if data is transferred in this phase
+	Throughput  string // E.g. "2.40 MiB/s", if data is transferred in this phase
+}
+
+var progressRegExp = regexp.MustCompile(
+	`^(?:remote: )?([A-Za-z][A-Za-z ]*):\s+(\d+)% \((\d+)/(\d+)\)` +
+		`(?:, ([\d.]+ (?:bytes|[KMGT]iB))(?: \| ([\d.]+ (?:bytes|[KMGT]iB)/s))?)?`)
+
+// progressWriter parses the git --progress output on stderr, where progress lines end with "\r",
+// while git updates the line. Progress lines are reported (at most every progressInterval, unless
+// the phase changes or is done) and other lines are written to the stderr writer, e.g. for errors
+type progressWriter struct {
+	stderr     io.Writer
+	onProgress func(p RemoteProgress)
+	line       []byte
+	lastPhase  string
+	lastTime   time.Time
+}
+
+func newProgressWriter(stderr io.Writer, onProgress func(p RemoteProgress)) *progressWriter {
+	return &progressWriter{stderr: stderr, onProgress: onProgress}
+}
+
+func (t *progressWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b == '\r' || b == '\n' {
+			if err := t.writeLine(b); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		t.line = append(t.line, b)
+	}
+	return len(p), nil
+}
+
+// flush writes the last line, which had no line ending
+func (t *progressWriter) flush() error {
+	return t.writeLine('\n')
+}
+
+func (t *progressWriter) writeLine(end byte) error {
+	line := t.line
+	t.line = nil
+	if len(bytes.TrimSpace(line)) == 0 {
+		return nil
+	}
+	if p, ok := parseProgress(string(line)); ok {
+		t.report(p)
+		return nil
+	}
+	if end == '\r' {
+		end = '\n'
+	}
+	_, err := t.stderr.Write(append(line, end))
+	return err
+}
+
+func (t *progressWriter) report(p RemoteProgress) {
+	if t.onProgress == nil {
+		return
+	}
+	if p.Phase == t.lastPhase && p.Percent != 100 && time.Since(t.lastTime) < progressInterval {
+		return
+	}
+	t.lastPhase = p.Phase
+	t.lastTime = time.Now()
+	t.onProgress(p)
+}
+
+func parseProgress(line string) (RemoteProgress, bool) {
+	matches := progressRegExp.FindStringSubmatch(line)
+	if matches == nil {
+		return RemoteProgress{}, false
+	}
+	percent, _ := strconv.Atoi(matches[2])
+	current, _ := strconv.Atoi(matches[3])
+	total, _ := strconv.Atoi(matches[4])
+	return RemoteProgress{
+		Phase:       matches[1],
+		Percent:     percent,
+		Current:     current,
+		Total:       total,
+		Transferred: matches[5],
+		Throughput:  matches[6],
+	}, true
+}
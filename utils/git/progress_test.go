@@ -0,0 +1,66 @@
+package git
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/linq"
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProgress(t *testing.T) {
+	p, ok := parseProgress("Receiving objects:  45% (4500/10000), 1.20 MiB | 2.40 MiB/s")
+	assert.True(t, ok)
+	assert.Equal(t, RemoteProgress{Phase: "Receiving objects", Percent: 45, Current: 4500, Total: 10000,
+		Transferred: "1.20 MiB", Throughput: "2.40 MiB/s"}, p)
+
+	p, ok = parseProgress("remote: Compressing objects: 100% (3/3), done.")
+	assert.True(t, ok)
+	assert.Equal(t, RemoteProgress{Phase: "Compressing objects", Percent: 100, Current: 3, Total: 3}, p)
+
+	p, ok = parseProgress("Writing objects:  50% (2/4), 300 bytes | 300.00 KiB/s")
+	assert.True(t, ok)
+	assert.Equal(t, "300 bytes", p.Transferred)
+	assert.Equal(t, "300.00 KiB/s", p.Throughput)
+
+	_, ok = parseProgress("remote: Enumerating objects: 5, done.")
+	assert.False(t, ok)
+	_, ok = parseProgress("fatal: repository 'x' does not exist")
+	assert.False(t, ok)
+
+	// Progress lines are reported, while other lines are kept, e.g. for error texts
+	var stderr bytes.Buffer
+	var progress []RemoteProgress
+	w := newProgressWriter(&stderr, func(p RemoteProgress) { progress = append(progress, p) })
+	_, err := w.Write([]byte("Cloning into 'x'...\nReceiving objects:  10% (1/10)\rReceiving objects:  20% (2/"))
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("10)\rReceiving objects: 100% (10/10), done.\nResolving deltas:   0% (0/2)\rfatal: failed"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.flush())
+	assert.Equal(t, "Cloning into 'x'...\nfatal: failed\n", stderr.String())
+	// The 20% is skipped, since it is reported too soon after 10% in the same phase
+	assert.Equal(t, []int{10, 100, 0}, linq.Map(progress, func(p RemoteProgress) int { return p.Percent }))
+}
+
+func TestCloneProgress(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	originPath := wf.MkDir("origin").Path()
+	origin := New(originPath)
+	assert.NoError(t, origin.InitRepo())
+	assert.NoError(t, origin.ConfigUser("test", "test@test.com"))
+	wf.File("origin", "a.txt").Write("1")
+	assert.NoError(t, origin.Commit("initial"))
+
+	// A file:// uri, since a local path clone copies files without a progress
+	var progress []RemoteProgress
+	g := New(wf.Path())
+	g.SetProgressHandler(func(p RemoteProgress) { progress = append(progress, p) })
+	assert.NoError(t, g.Clone("file:///"+strings.TrimPrefix(filepath.ToSlash(originPath), "/"), wf.Path("clone")))
+	done, ok := linq.Find(progress, func(p RemoteProgress) bool { return p.Phase == "Receiving objects" && p.Percent == 100 })
+	assert.True(t, ok, "%v", progress)
+	assert.Equal(t, 3, done.Total)
+}
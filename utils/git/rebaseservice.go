@@ -0,0 +1,71 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rebase of branches onto other branches
+type rebaseService struct {
+	cmd gitCommander
+}
+
+func newRebaseService(cmd gitCommander) *rebaseService {
+	return &rebaseService{cmd: cmd}
+}
+
+// rebase rebases the branch onto the onto branch, the branch is checked out if not current.
+// The rebase is kept in progress if there are conflicts, until continued, skipped or aborted
+func (t *rebaseService) rebase(name, onto string) error {
+	output, err := t.cmd.Git("rebase", onto, name)
+	if err != nil {
+		if t.isConflicts(err, output) {
+			return ErrConflicts
+		}
+		return fmt.Errorf("failed to rebase %s onto %s, %w", name, onto, err)
+	}
+	return nil
+}
+
+func (t *rebaseService) continueRebase() error {
+	// Stage resolved conflicts before continuing
+	_, err := t.cmd.Git("add", ".")
+	if err != nil {
+		return fmt.Errorf("failed to stage before continue rebase, %w", err)
+	}
+
+	// Use a no-op editor to keep the commit message of the rebased commit
+	output, err := t.cmd.Git("-c", "core.editor=true", "rebase", "--continue")
+	if err != nil {
+		if t.isConflicts(err, output) {
+			// Next commit resulted in new conflicts
+			return ErrConflicts
+		}
+		return fmt.Errorf("failed to continue rebase, %w", err)
+	}
+	return nil
+}
+
+// skipRebase skips the current (conflicting) commit and continues with the next commit
+func (t *rebaseService) skipRebase() error {
+	output, err := t.cmd.Git("rebase", "--skip")
+	if err != nil {
+		if t.isConflicts(err, output) {
+			return ErrConflicts
+		}
+		return fmt.Errorf("failed to skip commit in rebase, %w", err)
+	}
+	return nil
+}
+
+func (t *rebaseService) abortRebase() error {
+	_, err := t.cmd.Git("rebase", "--abort")
+	if err != nil {
+		return fmt.Errorf("failed to abort rebase, %w", err)
+	}
+	return nil
+}
+
+func (t *rebaseService) isConflicts(err error, output string) bool {
+	return strings.Contains(err.Error(), "exit status 1") && strings.Contains(output, "CONFLICT")
+}
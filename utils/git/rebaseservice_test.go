@@ -0,0 +1,96 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebase(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	git := New(wf.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	wf.File("a.txt").Write("1")
+	assert.NoError(t, git.Commit("initial"))
+
+	assert.NoError(t, git.CreateBranch("feature"))
+	wf.File("b.txt").Write("feature")
+	assert.NoError(t, git.Commit("feature1"))
+
+	assert.NoError(t, git.Checkout("master"))
+	wf.File("a.txt").Write("2")
+	assert.NoError(t, git.Commit("master1"))
+
+	// Rebase feature (not current) onto master, feature is checked out
+	assert.NoError(t, git.RebaseBranch("feature", "master"))
+	bs, _ := git.GetBranches()
+	assert.Equal(t, "feature", bs.MustCurrent().Name)
+	cs, _ := git.GetLog()
+	assert.Equal(t, "feature1", cs[0].Subject)
+	assert.Equal(t, "master1", cs[1].Subject)
+	assert.Equal(t, 3, len(cs))
+	st, _ := git.GetStatus()
+	assert.False(t, st.IsRebasing)
+}
+
+func TestRebaseConflicts(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	git := New(wf.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	wf.File("a.txt").Write("1")
+	assert.NoError(t, git.Commit("initial"))
+
+	assert.NoError(t, git.CreateBranch("feature"))
+	wf.File("a.txt").Write("feature")
+	assert.NoError(t, git.Commit("feature1"))
+	wf.File("b.txt").Write("feature")
+	assert.NoError(t, git.Commit("feature2"))
+
+	assert.NoError(t, git.Checkout("master"))
+	wf.File("a.txt").Write("master")
+	assert.NoError(t, git.Commit("master1"))
+
+	// Abort keeps the feature branch unchanged
+	assert.ErrorIs(t, git.RebaseBranch("feature", "master"), ErrConflicts)
+	st, _ := git.GetStatus()
+	assert.True(t, st.IsRebasing)
+	assert.Equal(t, "Rebasing feature (1/2)", st.RebaseMessage)
+	assert.Equal(t, 1, st.Conflicted)
+	bs, _ := git.GetBranches()
+	assert.Equal(t, "(feature)", bs.MustCurrent().Name)
+	assert.True(t, bs.MustCurrent().IsDetached)
+
+	assert.NoError(t, git.RebaseAbort())
+	st, _ = git.GetStatus()
+	assert.False(t, st.IsRebasing)
+	assert.Equal(t, "feature", wf.File("a.txt").Read())
+
+	// Skip the conflicting commit
+	assert.ErrorIs(t, git.RebaseBranch("feature", "master"), ErrConflicts)
+	assert.NoError(t, git.RebaseSkip())
+	cs, _ := git.GetLog()
+	assert.Equal(t, "feature2", cs[0].Subject)
+	assert.Equal(t, "master1", cs[1].Subject)
+	assert.Equal(t, "master", wf.File("a.txt").Read())
+
+	// Resolve conflicts and continue
+	assert.NoError(t, git.Checkout("master"))
+	wf.File("b.txt").Write("master")
+	assert.NoError(t, git.Commit("master2"))
+	assert.ErrorIs(t, git.RebaseBranch("feature", "master"), ErrConflicts)
+	wf.File("b.txt").Write("resolved")
+	assert.NoError(t, git.RebaseContinue())
+	st, _ = git.GetStatus()
+	assert.False(t, st.IsRebasing)
+	cs, _ = git.GetLog()
+	assert.Equal(t, "feature2", cs[0].Subject)
+	assert.Equal(t, "master2", cs[1].Subject)
+	assert.Equal(t, "resolved", wf.File("b.txt").Read())
+}
@@ -0,0 +1,87 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReflogEntry is an entry in the reflog of HEAD or a branch, i.e. a commit the ref pointed to
+type ReflogEntry struct {
+	Ref      string // HEAD or branch name
+	Index    int
+	Selector string // E.g. HEAD@{2}, which can be used as a ref
+	CommitID string
+	SID      string
+	Time     time.Time // The time the ref was updated
+	Action   string    // E.g. "commit", "checkout", "reset" or "merge feature"
+	Message  string    // E.g. "moving from feature to main"
+	Subject  string    // The commit subject
+}
+
+// reading of the reflog, which can be used to find commits of deleted branches or reset commits
+type reflogService struct {
+	cmd gitCommander
+}
+
+func newReflogService(cmd gitCommander) *reflogService {
+	return &reflogService{cmd: cmd}
+}
+
+// getReflog returns the reflog entries (latest first) of the ref, which is HEAD if ref is empty
+func (t *reflogService) getReflog(ref string) ([]ReflogEntry, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	fullRef := ref
+	if ref != "HEAD" {
+		fullRef = "refs/heads/" + ref
+	}
+
+	output, err := t.cmd.Git("log", "--walk-reflogs", "--date=unix", "--format=%H%x00%gd%x00%gs%x00%s",
+		fullRef, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reflog for %q, %v", ref, err)
+	}
+	entries, err := parseReflog(ref, output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse reflog for %q, %v", ref, err)
+	}
+	return entries, nil
+}
+
+func parseReflog(ref, output string) ([]ReflogEntry, error) {
+	var entries []ReflogEntry
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parts := strings.Split(line, "\x00")
+		if len(parts) < 4 {
+			return nil, fmt.Errorf("invalid reflog line %q", line)
+		}
+
+		// The selector is <ref>@{<unix time>}, since --date=unix was used
+		_, timeText, _ := strings.Cut(parts[1], "@{")
+		sec, err := strconv.ParseInt(strings.TrimSuffix(timeText, "}"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reflog time %q, %v", parts[1], err)
+		}
+
+		action, message, _ := strings.Cut(parts[2], ": ")
+		index := len(entries)
+		entries = append(entries, ReflogEntry{
+			Ref:      ref,
+			Index:    index,
+			Selector: fmt.Sprintf("%s@{%d}", ref, index),
+			CommitID: parts[0],
+			SID:      ToSid(parts[0]),
+			Time:     time.Unix(sec, 0),
+			Action:   action,
+			Message:  message,
+			Subject:  parts[3],
+		})
+	}
+	return entries, nil
+}
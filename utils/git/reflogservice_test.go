@@ -0,0 +1,51 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReflogRecoverDeletedBranch(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	git := New(wf.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	wf.File("a.txt").Write("1")
+	assert.NoError(t, git.Commit("initial"))
+	assert.NoError(t, git.CreateBranch("feature"))
+	wf.File("b.txt").Write("feature")
+	assert.NoError(t, git.Commit("feature1"))
+	cs, _ := git.GetLog()
+	featureID := cs.MustBySubject("feature1").ID
+	assert.NoError(t, git.Checkout("master"))
+	assert.NoError(t, git.DeleteLocalBranch("feature", true))
+
+	entries, err := git.GetReflog("")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, "HEAD@{0}", entries[0].Selector)
+	assert.Equal(t, "checkout", entries[0].Action)
+	assert.Equal(t, "moving from feature to master", entries[0].Message)
+	assert.Equal(t, "HEAD@{1}", entries[1].Selector)
+	assert.Equal(t, "commit", entries[1].Action)
+	assert.Equal(t, "feature1", entries[1].Message)
+	assert.Equal(t, "feature1", entries[1].Subject)
+	assert.Equal(t, featureID, entries[1].CommitID)
+	assert.Equal(t, "commit (initial)", entries[3].Action)
+	assert.False(t, entries[1].Time.IsZero())
+
+	entries, err = git.GetReflog("master")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "master@{0}", entries[0].Selector)
+
+	// Recover the deleted branch from the reflog entry
+	assert.NoError(t, git.CreateBranchAt("feature", featureID))
+	bs, _ := git.GetBranches()
+	assert.Equal(t, "feature", bs.MustCurrent().Name)
+	assert.Equal(t, "feature", wf.File("b.txt").Read())
+}
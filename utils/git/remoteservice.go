@@ -2,9 +2,23 @@ package git
 
 import (
 	"fmt"
+	"os"
+	"strings"
+
+	"github.com/michael-reichenauer/gmc/utils"
+	"github.com/michael-reichenauer/gmc/utils/log"
 )
 
-// fetch/push from remote origin
+// DefaultRemote is the remote used for meta data, tags and for branches without an upstream remote
+const DefaultRemote = "origin"
+
+type Remote struct {
+	Name     string
+	FetchURL string
+	PushURL  string
+}
+
+// fetch/push from remotes
 type remoteService struct {
 	cmd gitCommander
 }
@@ -13,53 +27,125 @@ func newRemoteService(cmd gitCommander) *remoteService {
 	return &remoteService{cmd: cmd}
 }
 
+func (t *remoteService) getRemotes() ([]Remote, error) {
+	output, err := t.cmd.Git("remote", "-v")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get remotes, %v", err)
+	}
+	return t.parseRemotes(output), nil
+}
+
+// fetch fetches all remotes, tags are only pruned for the default remote, since other remotes
+// (e.g. an upstream repo of a fork) usually do not have all tags
 func (t *remoteService) fetch() error {
-	// fetch force, prune deleted remote refs, fetch tags, prune deleted tags,
-	_, err := t.cmd.Git("fetch", "--force", "--prune", "--tags", "--prune-tags", "origin")
+	remotes, err := t.getRemotes()
+	if err != nil {
+		return err
+	}
+
+	var fetchErr error
+	for _, r := range remotes {
+		if err := t.fetchRemote(r.Name); err != nil && fetchErr == nil {
+			// Remember first error, but try to fetch the other remotes as well
+			fetchErr = err
+		}
+	}
+	return fetchErr
+}
+
+func (t *remoteService) fetchRemote(remote string) error {
+	if remote == DefaultRemote {
+		// fetch force, prune deleted remote refs, fetch tags, prune deleted tags,
+		_, err := t.cmd.Git("fetch", "--progress", "--force", "--prune", "--tags", "--prune-tags", remote)
+		return err
+	}
+
+	_, err := t.cmd.Git("fetch", "--progress", "--force", "--prune", remote)
 	return err
 }
 
-func (t *remoteService) pushBranch(name string) error {
+func (t *remoteService) pushBranch(remote, name string) error {
 	// push set upstream
 	refs := fmt.Sprintf("refs/heads/%s:refs/heads/%s", name, name)
-	_, err := t.cmd.Git("push", "--porcelain", "origin", "--set-upstream", refs)
+	_, err := t.cmd.Git("push", "--porcelain", "--progress", remote, "--set-upstream", refs)
 	return err
 }
 
 func (t *remoteService) pushRefForce(ref string) error {
 	// push set upstream
 	refs := fmt.Sprintf("%s:%s", ref, ref)
-	_, err := t.cmd.Git("push", "--porcelain", "origin", "--set-upstream", "--force", refs)
+	_, err := t.cmd.Git("push", "--porcelain", "--progress", DefaultRemote, "--set-upstream", "--force", refs)
 	return err
 }
 
 func (t *remoteService) pullRef(ref string) error {
 	// fetch origin
 	refs := fmt.Sprintf("%s:%s", ref, ref)
-	_, err := t.cmd.Git("fetch", "origin", refs)
+	_, err := t.cmd.Git("fetch", "--progress", DefaultRemote, refs)
 	return err
 }
 
-func (t *remoteService) deleteRemoteBranch(name string) error {
-	name = StripRemotePrefix(name)
-	_, err := t.cmd.Git("push", "--porcelain", "origin", "--delete", name)
+func (t *remoteService) deleteRemoteBranch(remote, name string) error {
+	name = strings.TrimPrefix(name, remote+"/")
+	_, err := t.cmd.Git("push", "--porcelain", remote, "--delete", name)
 	return err
 }
 
 func (t *remoteService) pullCurrentBranch() error {
-	// "pull --ff --no-rebase --progress"
-	_, err := t.cmd.Git("pull", "--ff", "--no-rebase")
+	_, err := t.cmd.Git("pull", "--ff", "--no-rebase", "--progress")
 	return err
 }
 
-func (t *remoteService) pullBranch(name string) error {
-	// fetch origin
+func (t *remoteService) pullCurrentBranchFrom(remote, name string) error {
+	_, err := t.cmd.Git("pull", "--ff", "--no-rebase", "--progress", remote, name)
+	return err
+}
+
+func (t *remoteService) pullBranch(remote, name string) error {
+	// fetch remote branch into the local branch (fast forward only)
 	branchRefs := fmt.Sprintf("%s:%s", name, name)
-	_, err := t.cmd.Git("fetch", "origin", branchRefs)
+	_, err := t.cmd.Git("fetch", "--progress", remote, branchRefs)
 	return err
 }
 
 func (t *remoteService) clone(uri, path string) error {
-	_, err := t.cmd.Git("clone", uri, path)
+	isExisting := utils.DirExists(path)
+	_, err := t.cmd.Git("clone", "--progress", uri, path)
+	if err == ErrCanceled && !isExisting {
+		// The killed clone did not remove the partially cloned repo
+		if err := os.RemoveAll(path); err != nil {
+			log.Warnf("Failed to remove canceled clone %s, %v", path, err)
+		}
+	}
 	return err
 }
+
+// parseRemotes parses 'git remote -v' output lines like "origin	https://host/repo.git (fetch)"
+func (t *remoteService) parseRemotes(output string) []Remote {
+	var remotes []Remote
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.Fields(line)
+		if len(parts) < 3 {
+			continue
+		}
+		name, url, kind := parts[0], parts[1], parts[2]
+
+		index := -1
+		for i, r := range remotes {
+			if r.Name == name {
+				index = i
+			}
+		}
+		if index == -1 {
+			remotes = append(remotes, Remote{Name: name})
+			index = len(remotes) - 1
+		}
+
+		if kind == "(push)" {
+			remotes[index].PushURL = url
+		} else {
+			remotes[index].FetchURL = url
+		}
+	}
+	return remotes
+}
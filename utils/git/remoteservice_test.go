@@ -61,3 +61,74 @@ func TestClonePushPull(t *testing.T) {
 	assert.Equal(t, "second", l2[0].Subject)
 	assert.Equal(t, "initial", l2[1].Subject)
 }
+
+func TestMultipleRemotes(t *testing.T) {
+	defer tests.CleanTemp()
+
+	// Prepare origin server repo and upstream server repo
+	wfOrigin := tests.CreateTempFolder()
+	gitOrigin := New(wfOrigin.Path())
+	assert.NoError(t, gitOrigin.InitRepoBare())
+	wfUpstream := tests.CreateTempFolder()
+	gitUpstream := New(wfUpstream.Path())
+	assert.NoError(t, gitUpstream.InitRepoBare())
+
+	// Prepare cloned repo with an additional upstream remote
+	wf := tests.CreateTempFolder()
+	git := New(wf.Path())
+	assert.NoError(t, git.Clone(gitOrigin.RepoPath(), wf.Path()))
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+	_, err := newGitCmd(wf.Path()).Git("remote", "add", "upstream", gitUpstream.RepoPath())
+	assert.NoError(t, err)
+
+	remotes, err := git.GetRemotes()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(remotes))
+	assert.Equal(t, "origin", remotes[0].Name)
+	assert.Equal(t, "upstream", remotes[1].Name)
+	assert.Equal(t, gitUpstream.RepoPath(), remotes[1].FetchURL)
+	assert.Equal(t, gitUpstream.RepoPath(), remotes[1].PushURL)
+
+	// Push to both remotes, last push sets upstream remote
+	wf.File("a.txt").Write("1")
+	assert.NoError(t, git.Commit("initial"))
+	assert.NoError(t, git.PushBranch("master"))
+	assert.NoError(t, git.PushBranchTo("upstream", "master"))
+	assert.NoError(t, git.Fetch())
+
+	branches, err := git.GetBranches()
+	assert.NoError(t, err)
+	local := branches.MustByName("master")
+	assert.Equal(t, "upstream/master", local.RemoteName)
+	assert.Equal(t, "upstream", local.Remote)
+	originBranch := branches.MustByName("origin/master")
+	assert.True(t, originBranch.IsRemote)
+	assert.Equal(t, "origin", originBranch.Remote)
+	assert.Equal(t, "master", originBranch.DisplayName)
+	upstreamBranch := branches.MustByName("upstream/master")
+	assert.True(t, upstreamBranch.IsRemote)
+	assert.Equal(t, "upstream", upstreamBranch.Remote)
+	assert.Equal(t, "upstream/master", upstreamBranch.DisplayName)
+
+	// Commit in another clone of upstream and pull that from upstream
+	wf2 := tests.CreateTempFolder()
+	git2 := New(wf2.Path())
+	assert.NoError(t, git2.Clone(gitUpstream.RepoPath(), wf2.Path()))
+	assert.NoError(t, git2.ConfigUser("test", "test@test.com"))
+	wf2.File("a.txt").Write("2")
+	assert.NoError(t, git2.Commit("second"))
+	assert.NoError(t, git2.PushBranch("master"))
+
+	assert.NoError(t, git.PullCurrentBranchFrom("upstream", "master"))
+	assert.Equal(t, "2", wf.File("a.txt").Read())
+
+	// Delete branch on upstream
+	assert.NoError(t, git.CreateBranch("feature"))
+	assert.NoError(t, git.PushBranchTo("upstream", "feature"))
+	assert.NoError(t, git.DeleteRemoteBranch("upstream", "upstream/feature"))
+	assert.NoError(t, git.Fetch())
+	branches, _ = git.GetBranches()
+	for _, b := range branches {
+		assert.NotEqual(t, "upstream/feature", b.Name)
+	}
+}
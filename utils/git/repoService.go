@@ -1,5 +1,12 @@
 package git
 
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
 type repoService struct {
 	cmd gitCommander
 }
@@ -17,3 +24,24 @@ func (t *repoService) InitRepoBare() error {
 	_, err := t.cmd.Git("init", "--bare", t.cmd.WorkingDir())
 	return err
 }
+
+// RemoveLockFile removes a stale lock file, e.g. an index.lock left by a crashed git process.
+// Only lock files in the git folders of the repo can be removed
+func (t *repoService) RemoveLockFile(path string) error {
+	if !strings.HasSuffix(path, ".lock") {
+		return fmt.Errorf("not a lock file: %s", path)
+	}
+	gitDir := GitDir(t.cmd.WorkingDir())
+	if !isInFolder(path, gitDir) && !isInFolder(path, CommonGitDir(gitDir)) {
+		return fmt.Errorf("lock file %s is not in the repo git folder", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove lock file %s, %w", path, err)
+	}
+	return nil
+}
+
+func isInFolder(path, folder string) bool {
+	rel, err := filepath.Rel(folder, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
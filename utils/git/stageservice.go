@@ -0,0 +1,243 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/michael-reichenauer/gmc/utils"
+)
+
+const stagePatchFileName = "gmc_stage.patch"
+
+// stage/unstage files and sections (hunks) in the index
+type stageService struct {
+	cmd gitCommander
+}
+
+func newStageService(cmd gitCommander) *stageService {
+	return &stageService{cmd: cmd}
+}
+
+func (t *stageService) stageFile(path string) error {
+	// --all to stage deleted files as well
+	_, err := t.cmd.Git("add", "--all", "--", path)
+	if err != nil {
+		return fmt.Errorf("failed to stage %s, %w", path, err)
+	}
+	return nil
+}
+
+func (t *stageService) unstageFile(path string) error {
+	_, err := t.cmd.Git("reset", "--quiet", "HEAD", "--", path)
+	if err != nil {
+		// Repo without commits has no HEAD, just remove from the index
+		if _, err2 := t.cmd.Git("rm", "--cached", "--quiet", "--", path); err2 != nil {
+			return fmt.Errorf("failed to unstage %s, %w", path, err)
+		}
+	}
+	return nil
+}
+
+// stageSection stages the changed lines from index 'from' to 'to' (inclusive) in the section,
+// where the section is part of the uncommitted diff (working folder compared to HEAD)
+func (t *stageService) stageSection(path string, section SectionDiff, from, to int) error {
+	if !t.isTracked(path) {
+		// Untracked (new) files have no base in the index to apply a patch on, stage whole file
+		return t.stageFile(path)
+	}
+
+	// The index might differ from HEAD, e.g. a partially staged file, so the patch is based on
+	// the unstaged changes (working folder compared to the index)
+	diff, err := t.cmd.Git("diff", "--no-color", "--no-ext-diff", "--", path)
+	if err != nil {
+		return fmt.Errorf("failed to stage section in %s, %w", path, err)
+	}
+	patch, err := t.toPatch(path, diff, section, from, to, false)
+	if err != nil {
+		return err
+	}
+	if err := t.applyToIndex(patch, false); err != nil {
+		return fmt.Errorf("failed to stage section in %s, %w", path, err)
+	}
+	return nil
+}
+
+// unstageSection unstages the changed lines from index 'from' to 'to' (inclusive) in the section,
+// which requires the lines to be staged.
+func (t *stageService) unstageSection(path string, section SectionDiff, from, to int) error {
+	// The patch is based on the staged changes (index compared to HEAD), which is applied in reverse
+	diff, err := t.cmd.Git("diff", "--cached", "--no-color", "--no-ext-diff", "--", path)
+	if err != nil {
+		return fmt.Errorf("failed to unstage section in %s, %w", path, err)
+	}
+	patch, err := t.toPatch(path, diff, section, from, to, true)
+	if err != nil {
+		return err
+	}
+	if err := t.applyToIndex(patch, true); err != nil {
+		return fmt.Errorf("failed to unstage section in %s, %w", path, err)
+	}
+	return nil
+}
+
+func (t *stageService) applyToIndex(patch string, isReverse bool) error {
+	patchPath := filepath.Join(GitDir(t.cmd.WorkingDir()), stagePatchFileName)
+	if err := utils.FileWrite(patchPath, []byte(patch)); err != nil {
+		return err
+	}
+	defer os.Remove(patchPath)
+
+	args := []string{"apply", "--cached", "--recount", "--ignore-whitespace"}
+	if isReverse {
+		args = append(args, "--reverse")
+	}
+	args = append(args, patchPath)
+
+	_, err := t.cmd.Git(args...)
+	return err
+}
+
+func (t *stageService) isTracked(path string) bool {
+	_, err := t.cmd.Git("ls-files", "--error-unmatch", "--", path)
+	return err == nil
+}
+
+// toPatch creates a patch of the diff (unstaged or staged changes), with only the changes, which
+// are selected in the range of the section (uncommitted changes compared to HEAD). Not selected
+// changes are kept as they are in the index, i.e. for staging, not selected removed lines are kept
+// as context and added lines are skipped. When unstaging it is the reverse.
+func (t *stageService) toPatch(path, diff string, section SectionDiff, from, to int, isReverse bool) (string, error) {
+	selected, err := selectedChanges(path, section, from, to, isReverse)
+	if err != nil {
+		return "", err
+	}
+
+	var header, hunk, lines []string
+	isHunkChanged := false
+	isKept := false // If the previous line was kept, i.e. if a following "\ No newline" line is kept
+	oldLine, newLine := 0, 0
+	addHunk := func() {
+		if isHunkChanged {
+			lines = append(lines, hunk...)
+		}
+		hunk, isHunkChanged = nil, false
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		if sd, ok := tryParseSectionHead(line); ok {
+			addHunk()
+			hunk = []string{line}
+			oldLine, newLine = sd.LeftLine, sd.RightLine
+			continue
+		}
+		if hunk == nil {
+			// The file header lines before the first hunk
+			header = append(header, line)
+			continue
+		}
+
+		// Changes are identified by the line numbers of the side, which the diff shares with the
+		// section, i.e. the working folder lines when staging and the HEAD lines when unstaging
+		pos := newLine
+		if isReverse {
+			pos = oldLine
+		}
+		kept := line
+		switch {
+		case strings.HasPrefix(line, "-"):
+			if selected.take(DiffRemoved, pos, line) {
+				isHunkChanged = true
+			} else if !isReverse {
+				kept = " " + line[1:]
+			} else {
+				kept = ""
+			}
+			oldLine++
+		case strings.HasPrefix(line, "+"):
+			if selected.take(DiffAdded, pos, line) {
+				isHunkChanged = true
+			} else if isReverse {
+				kept = " " + line[1:]
+			} else {
+				kept = ""
+			}
+			newLine++
+		case strings.HasPrefix(line, "\\"):
+			if !isKept {
+				kept = ""
+			}
+		default:
+			oldLine++
+			newLine++
+		}
+		isKept = kept != ""
+		if isKept {
+			hunk = append(hunk, kept)
+		}
+	}
+	addHunk()
+
+	if len(lines) == 0 {
+		return "", fmt.Errorf("failed to stage %s, no changes in the selected lines", path)
+	}
+	return strings.Join(append(header, lines...), "\n") + "\n", nil
+}
+
+// changeSet counts changed lines by mode, line text and position, where the position is the line
+// number on the shared side, or for lines on the other side, the number of the next line
+type changeSet map[changeKey]int
+
+type changeKey struct {
+	mode DiffMode
+	pos  int
+	line string
+}
+
+// selectedChanges returns the changed lines in the range of the section, positioned by the working
+// folder lines when staging or by the HEAD lines when unstaging
+func selectedChanges(path string, section SectionDiff, from, to int, isReverse bool) (changeSet, error) {
+	if to < 0 || to >= len(section.LinesDiffs) {
+		to = len(section.LinesDiffs) - 1
+	}
+
+	selected := changeSet{}
+	leftLine, rightLine := section.LeftLine, section.RightLine
+	for i, ld := range section.LinesDiffs {
+		pos := rightLine
+		if isReverse {
+			pos = leftLine
+		}
+		isSelected := i >= from && i <= to
+
+		switch ld.DiffMode {
+		case DiffSame:
+			leftLine++
+			rightLine++
+		case DiffRemoved:
+			if isSelected {
+				selected[changeKey{mode: DiffRemoved, pos: pos, line: ld.Line}]++
+			}
+			leftLine++
+		case DiffAdded:
+			if isSelected {
+				selected[changeKey{mode: DiffAdded, pos: pos, line: ld.Line}]++
+			}
+			rightLine++
+		default:
+			return nil, fmt.Errorf("failed to stage %s, sections with conflicts can not be staged", path)
+		}
+	}
+	return selected, nil
+}
+
+// take returns true if the diff line ("-" or "+" prefixed) is a selected change
+func (t changeSet) take(mode DiffMode, pos int, diffLine string) bool {
+	key := changeKey{mode: mode, pos: pos, line: asLine(diffLine)}
+	if t[key] == 0 {
+		return false
+	}
+	t[key]--
+	return true
+}
@@ -0,0 +1,179 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStageFiles(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	git := New(wf.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	wf.File("a.txt").Write("1")
+	assert.NoError(t, git.Commit("initial"))
+
+	wf.File("a.txt").Write("2")
+	wf.File("b.txt").Write("1")
+	st, _ := git.GetStatus()
+	assert.Equal(t, 0, st.Staged)
+	assert.Equal(t, 2, st.Unstaged)
+
+	// Stage and commit only b.txt
+	assert.NoError(t, git.StageFile("b.txt"))
+	st, _ = git.GetStatus()
+	assert.Equal(t, []string{"b.txt"}, st.StagedFiles)
+	assert.Equal(t, []string{"a.txt"}, st.UnstagedFiles)
+	assert.NoError(t, git.UnstageFile("b.txt"))
+	st, _ = git.GetStatus()
+	assert.Equal(t, 0, st.Staged)
+	assert.NoError(t, git.StageFile("b.txt"))
+
+	assert.NoError(t, git.CommitStaged("add b"))
+	st, _ = git.GetStatus()
+	assert.Equal(t, 0, st.Staged)
+	assert.Equal(t, []string{"a.txt"}, st.UnstagedFiles)
+	assert.Equal(t, "2", wf.File("a.txt").Read())
+}
+
+func TestStageSections(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	git := New(wf.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	wf.File("a.txt").Write(numberLines(30, nil))
+	assert.NoError(t, git.Commit("initial"))
+
+	// Change two lines far apart to get two sections
+	wf.File("a.txt").Write(numberLines(30, map[int]string{2: "x2", 28: "x28"}))
+	diff, err := git.CommitDiff(UncommittedID)
+	assert.NoError(t, err)
+	sections := diff.FileDiffs[0].SectionDiffs
+	assert.Equal(t, 2, len(sections))
+
+	// Stage and unstage the first section
+	assert.NoError(t, git.StageSection("a.txt", sections[0], 0, -1))
+	st, _ := git.GetStatus()
+	assert.Equal(t, 1, st.Staged)
+	assert.Equal(t, 1, st.Unstaged)
+	assert.NoError(t, git.UnstageSection("a.txt", sections[0], 0, -1))
+	st, _ = git.GetStatus()
+	assert.Equal(t, 0, st.Staged)
+
+	// Stage only the first section and commit it, the second section is kept uncommitted
+	assert.NoError(t, git.StageSection("a.txt", sections[0], 0, -1))
+	assert.NoError(t, git.CommitStaged("first section"))
+	cs, _ := git.GetLog()
+	assert.Equal(t, "first section", cs[0].Subject)
+	diff, _ = git.CommitDiff(UncommittedID)
+	assert.Equal(t, 1, len(diff.FileDiffs[0].SectionDiffs))
+	assert.Equal(t, 28, diff.FileDiffs[0].SectionDiffs[0].RightLine+6)
+
+	// Change two close lines in same section, but stage only the change of one line
+	assert.NoError(t, git.Commit("second section"))
+	changes := map[int]string{2: "x2", 28: "x28", 10: "y10", 12: "y12"}
+	wf.File("a.txt").Write(numberLines(30, changes))
+	diff, _ = git.CommitDiff(UncommittedID)
+	assert.Equal(t, 1, len(diff.FileDiffs[0].SectionDiffs))
+	section := diff.FileDiffs[0].SectionDiffs[0]
+	from, to := lineIndexes(section, "10", "y10")
+	assert.NoError(t, git.StageSection("a.txt", section, from, to))
+	assert.NoError(t, git.CommitStaged("line 10"))
+	diff, _ = git.CommitDiff(UncommittedID)
+	changed := changedLines(diff.FileDiffs[0].SectionDiffs[0])
+	assert.Equal(t, []string{"-12", "+y12"}, changed)
+	assert.Equal(t, numberLines(30, changes), wf.File("a.txt").Read())
+}
+
+func TestStageSectionsOfPartiallyStagedFile(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	git := New(wf.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	wf.File("a.txt").Write(numberLines(30, nil))
+	assert.NoError(t, git.Commit("initial"))
+
+	// Stage the change of line 10, while line 12 is changed as well
+	changes := map[int]string{10: "y10", 12: "y12"}
+	wf.File("a.txt").Write(numberLines(30, changes))
+	section := uncommittedSection(t, git)
+	from, to := lineIndexes(section, "10", "y10")
+	assert.NoError(t, git.StageSection("a.txt", section, from, to))
+	assert.Equal(t, numberLines(30, map[int]string{10: "y10"}), indexFile(t, wf.Path(), "a.txt"))
+
+	// Stage line 12 in the section, which is still compared to HEAD and includes the staged line
+	section = uncommittedSection(t, git)
+	from, to = lineIndexes(section, "12", "y12")
+	assert.NoError(t, git.StageSection("a.txt", section, from, to))
+	assert.Equal(t, numberLines(30, changes), indexFile(t, wf.Path(), "a.txt"))
+
+	// Unstage line 10, while line 12 is kept staged
+	section = uncommittedSection(t, git)
+	from, to = lineIndexes(section, "10", "y10")
+	assert.NoError(t, git.UnstageSection("a.txt", section, from, to))
+	assert.Equal(t, numberLines(30, map[int]string{12: "y12"}), indexFile(t, wf.Path(), "a.txt"))
+	assert.Equal(t, numberLines(30, changes), wf.File("a.txt").Read())
+}
+
+func uncommittedSection(t *testing.T, git Git) SectionDiff {
+	diff, err := git.CommitDiff(UncommittedID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(diff.FileDiffs[0].SectionDiffs))
+	return diff.FileDiffs[0].SectionDiffs[0]
+}
+
+// indexFile returns the staged content of the file
+func indexFile(t *testing.T, path, name string) string {
+	content, err := newGitCmd(path).Git("show", ":"+name)
+	assert.NoError(t, err)
+	return content
+}
+
+func numberLines(count int, changes map[int]string) string {
+	var lines []string
+	for i := 1; i <= count; i++ {
+		line := fmt.Sprintf("%d", i)
+		if c, ok := changes[i]; ok {
+			line = c
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// lineIndexes returns the indexes of the removed and added lines in the section
+func lineIndexes(section SectionDiff, removed, added string) (int, int) {
+	from, to := -1, -1
+	for i, ld := range section.LinesDiffs {
+		if ld.DiffMode == DiffRemoved && ld.Line == removed {
+			from = i
+		}
+		if ld.DiffMode == DiffAdded && ld.Line == added {
+			to = i
+		}
+	}
+	return from, to
+}
+
+func changedLines(section SectionDiff) []string {
+	var lines []string
+	for _, ld := range section.LinesDiffs {
+		if ld.DiffMode == DiffRemoved {
+			lines = append(lines, "-"+ld.Line)
+		}
+		if ld.DiffMode == DiffAdded {
+			lines = append(lines, "+"+ld.Line)
+		}
+	}
+	return lines
+}
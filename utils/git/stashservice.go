@@ -0,0 +1,152 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Stash struct {
+	ID       string
+	SID      string
+	Name     string // E.g. "stash@{0}"
+	Index    int
+	Branch   string
+	Message  string
+	ParentID string
+	Time     time.Time
+}
+
+// stashes, list, push, apply, pop, drop and show
+type stashService struct {
+	cmd         gitCommander
+	diffService *diffService
+	logService  *logService
+}
+
+func newStashService(cmd gitCommander, diffService *diffService, logService *logService) *stashService {
+	return &stashService{cmd: cmd, diffService: diffService, logService: logService}
+}
+
+func (t *stashService) getStashes() ([]Stash, error) {
+	output, err := t.cmd.Git("stash", "list", "--pretty=%H|%ai|%P|%gs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stashes, %v", err)
+	}
+
+	return t.parseStashes(output)
+}
+
+func (t *stashService) push(message string, includeUntracked bool) error {
+	args := []string{"stash", "push"}
+	if includeUntracked {
+		args = append(args, "--include-untracked")
+	}
+	if message != "" {
+		args = append(args, "--message", message)
+	}
+
+	_, err := t.cmd.Git(args...)
+	if err != nil {
+		return fmt.Errorf("failed to stash, %w", err)
+	}
+	return nil
+}
+
+func (t *stashService) apply(name string) error {
+	output, err := t.cmd.Git("stash", "apply", name)
+	if err != nil {
+		if strings.Contains(err.Error(), "exit status 1") && strings.Contains(output, "CONFLICT") {
+			return ErrConflicts
+		}
+		return fmt.Errorf("failed to apply stash %s, %w", name, err)
+	}
+	return nil
+}
+
+func (t *stashService) pop(name string) error {
+	output, err := t.cmd.Git("stash", "pop", name)
+	if err != nil {
+		if strings.Contains(err.Error(), "exit status 1") && strings.Contains(output, "CONFLICT") {
+			// Git keeps the stash when pop results in conflicts
+			return ErrConflicts
+		}
+		return fmt.Errorf("failed to pop stash %s, %w", name, err)
+	}
+	return nil
+}
+
+func (t *stashService) drop(name string) error {
+	_, err := t.cmd.Git("stash", "drop", name)
+	if err != nil {
+		return fmt.Errorf("failed to drop stash %s, %w", name, err)
+	}
+	return nil
+}
+
+func (t *stashService) show(name string) (CommitDiff, error) {
+	diffText, err := t.cmd.Git("stash", "show", "--patch", "--ignore-space-change", "--no-color", "--submodule=short",
+		"--find-renames", "--unified=6", name)
+	if err != nil {
+		return CommitDiff{}, fmt.Errorf("failed to show stash %s, %v", name, err)
+	}
+
+	// Stash diffs do not have a commit header, parse it like an uncommitted diff
+	commitDiffs, err := t.diffService.parse(diffText, "", true)
+	if err != nil {
+		return CommitDiff{}, err
+	}
+
+	commitDiff := commitDiffs[0]
+	commitDiff.Id = name
+	return commitDiff, nil
+}
+
+func (t *stashService) parseStashes(output string) ([]Stash, error) {
+	var stashes []Stash
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "|", 4)
+		if len(parts) < 4 {
+			return nil, fmt.Errorf("failed to parse git stash %q", line)
+		}
+
+		stashTime, err := time.Parse(customRFC3339, t.logService.toCustomRFC3339Text(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse stash time, %q, %v", parts[1], err)
+		}
+
+		parentID := ""
+		if parents := strings.Split(parts[2], " "); len(parents) > 0 {
+			parentID = parents[0]
+		}
+
+		index := len(stashes)
+		stashes = append(stashes, Stash{
+			ID:       parts[0],
+			SID:      ToSid(parts[0]),
+			Name:     fmt.Sprintf("stash@{%d}", index),
+			Index:    index,
+			Branch:   parseStashBranch(parts[3]),
+			Message:  parts[3],
+			ParentID: parentID,
+			Time:     stashTime,
+		})
+	}
+	return stashes, nil
+}
+
+// parseStashBranch returns the branch name from a stash subject like
+// "WIP on master: 1234567 subject" or "On master: some message"
+func parseStashBranch(subject string) string {
+	text := strings.TrimPrefix(subject, "WIP on ")
+	text = strings.TrimPrefix(text, "On ")
+	i := strings.Index(text, ":")
+	if i == -1 {
+		return ""
+	}
+	return text[:i]
+}
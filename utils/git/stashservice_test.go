@@ -0,0 +1,76 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStashes(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	git := New(wf.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	file1 := "a.txt"
+	file2 := "b.txt"
+	wf.File(file1).Write("1")
+	assert.NoError(t, git.Commit("initial"))
+	cs, _ := git.GetLog()
+
+	ss, err := git.GetStashes()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(ss))
+
+	// Stash tracked change, untracked file is kept
+	wf.File(file1).Write("2")
+	wf.File(file2).Write("new")
+	assert.NoError(t, git.StashPush("first", false))
+	assert.Equal(t, "1", wf.File(file1).Read())
+	_, err = wf.File(file2).TryRead()
+	assert.NoError(t, err)
+
+	// Stash untracked file as well
+	assert.NoError(t, git.StashPush("second", true))
+	_, err = wf.File(file2).TryRead()
+	assert.Error(t, err)
+
+	ss, err = git.GetStashes()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ss))
+	assert.Equal(t, "stash@{0}", ss[0].Name)
+	assert.Equal(t, "On master: second", ss[0].Message)
+	assert.Equal(t, "master", ss[0].Branch)
+	assert.Equal(t, cs[0].ID, ss[0].ParentID)
+	assert.Equal(t, "stash@{1}", ss[1].Name)
+	assert.Equal(t, "On master: first", ss[1].Message)
+
+	diff, err := git.StashDiff("stash@{1}")
+	assert.NoError(t, err)
+	assert.Equal(t, "stash@{1}", diff.Id)
+	assert.Equal(t, 1, len(diff.FileDiffs))
+	assert.Equal(t, file1, diff.FileDiffs[0].PathAfter)
+
+	assert.NoError(t, git.StashPop("stash@{0}"))
+	_, err = wf.File(file2).TryRead()
+	assert.NoError(t, err)
+	ss, _ = git.GetStashes()
+	assert.Equal(t, 1, len(ss))
+
+	assert.NoError(t, git.StashApply("stash@{0}"))
+	assert.Equal(t, "2", wf.File(file1).Read())
+	ss, _ = git.GetStashes()
+	assert.Equal(t, 1, len(ss))
+
+	assert.NoError(t, git.StashDrop("stash@{0}"))
+	ss, _ = git.GetStashes()
+	assert.Equal(t, 0, len(ss))
+}
+
+func TestParseStashBranch(t *testing.T) {
+	assert.Equal(t, "master", parseStashBranch("WIP on master: 1234567 some subject"))
+	assert.Equal(t, "feature/a", parseStashBranch("On feature/a: some: message"))
+	assert.Equal(t, "", parseStashBranch("some text"))
+}
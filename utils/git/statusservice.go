@@ -4,25 +4,37 @@ import (
 	"fmt"
 	"path"
 	"strings"
+
+	"github.com/michael-reichenauer/gmc/utils"
 )
 
 type Status struct {
-	Modified       int
-	Added          int
-	Deleted        int
-	Conflicted     int
-	IsMerging      bool
-	MergeMessage   string
-	AddedFiles     []string
-	ConflictsFiles []string
+	Modified        int
+	Added           int
+	Deleted         int
+	Conflicted      int
+	IsMerging       bool
+	IsCherryPicking bool
+	IsRebasing      bool
+	MergeMessage    string
+	RebaseMessage   string // E.g. "Rebasing feature (2/5)"
+	AddedFiles      []string
+	ConflictsFiles  []string
+	Submodules      int // Submodules with a changed commit or content (not counted as Modified)
+	SubmoduleFiles  []string
+	Staged          int      // Files with changes in the index
+	Unstaged        int      // Files with changes in the working folder not in the index (incl untracked)
+	StagedFiles     []string // A partially staged file is in both StagedFiles and UnstagedFiles
+	UnstagedFiles   []string
 }
 
 type statusService struct {
-	cmd gitCommander
+	cmd              gitCommander
+	submoduleService *submoduleService
 }
 
 func newStatus(cmd gitCommander) *statusService {
-	return &statusService{cmd: cmd}
+	return &statusService{cmd: cmd, submoduleService: newSubmoduleService(cmd)}
 }
 
 func (t *Status) String() string {
@@ -34,10 +46,10 @@ func (t *statusService) getStatus() (Status, error) {
 	if err != nil {
 		return Status{}, err
 	}
-	return t.parseStatus(gitStatus)
+	return t.parseStatus(gitStatus, t.submoduleService.getSubmodulePaths())
 }
 
-func (t *statusService) parseStatus(statusText string) (Status, error) {
+func (t *statusService) parseStatus(statusText string, submodulePaths []string) (Status, error) {
 	status := Status{}
 	lines := strings.Split(statusText, "\n")
 	for _, line := range lines {
@@ -63,23 +75,103 @@ func (t *statusService) parseStatus(statusText string) (Status, error) {
 		} else if strings.HasPrefix(line, "DU ") {
 			status.Conflicted++
 			status.ConflictsFiles = append(status.ConflictsFiles, line[3:])
-		} else if strings.HasPrefix(line, "?? ") || strings.HasPrefix(line, " A ") {
-			status.Added++
-			status.AddedFiles = append(status.AddedFiles, line[3:])
-		} else if strings.HasPrefix(line, " D ") || strings.HasPrefix(line, "D") {
-			status.Deleted++
 		} else {
-			status.Modified++
+			if utils.StringsContains(submodulePaths, line[3:]) && !strings.HasPrefix(line, "?? ") {
+				// Submodule with a changed commit (or content) is a distinct status
+				status.Submodules++
+				status.SubmoduleFiles = append(status.SubmoduleFiles, line[3:])
+			} else if strings.HasPrefix(line, "?? ") || strings.HasPrefix(line, " A ") {
+				status.Added++
+				status.AddedFiles = append(status.AddedFiles, line[3:])
+			} else if strings.HasPrefix(line, " D ") || strings.HasPrefix(line, "D") {
+				status.Deleted++
+			} else {
+				status.Modified++
+			}
+			t.parseStaged(line, &status)
 		}
 	}
 	status.MergeMessage, status.IsMerging = t.getMergeStatus()
+	status.IsCherryPicking = t.isCherryPicking()
+	status.RebaseMessage, status.IsRebasing = t.getRebaseStatus()
 	return status, nil
 }
 
+// parseStaged parses the 'XY path' status, where X is the index status and Y the working folder
+func (t *statusService) parseStaged(line string, status *Status) {
+	if len(line) < 4 {
+		return
+	}
+	path := line[3:]
+	if i := strings.Index(path, " -> "); i != -1 {
+		// Renamed file, use new path
+		path = path[i+4:]
+	}
+
+	if line[0] != ' ' && line[0] != '?' {
+		status.Staged++
+		status.StagedFiles = append(status.StagedFiles, path)
+	}
+	if line[1] != ' ' {
+		status.Unstaged++
+		status.UnstagedFiles = append(status.UnstagedFiles, path)
+	}
+}
+
+// isCherryPicking returns true while a cherry-pick is paused, either due to conflicts for
+// the current commit or when more commits in a range remains to be picked
+func (t *statusService) isCherryPicking() bool {
+	gitPath := GitDir(t.cmd.WorkingDir())
+	if _, err := t.cmd.ReadFile(path.Join(gitPath, "CHERRY_PICK_HEAD")); err == nil {
+		return true
+	}
+	if _, err := t.cmd.ReadFile(path.Join(gitPath, "sequencer", "todo")); err == nil {
+		return true
+	}
+	return false
+}
+
+// getRebaseStatus returns a message like "Rebasing feature (2/5)" and true while a rebase is
+// in progress
+func (t *statusService) getRebaseStatus() (string, bool) {
+	gitPath := GitDir(t.cmd.WorkingDir())
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		rebasePath := path.Join(gitPath, dir)
+		headName, err := t.cmd.ReadFile(path.Join(rebasePath, "head-name"))
+		if err != nil {
+			continue
+		}
+
+		name := strings.TrimPrefix(strings.TrimSpace(headName), "refs/heads/")
+		message := fmt.Sprintf("Rebasing %s", name)
+		step, err1 := t.readRebaseFile(rebasePath, "msgnum", "next")
+		steps, err2 := t.readRebaseFile(rebasePath, "end", "last")
+		if err1 == nil && err2 == nil {
+			message = fmt.Sprintf("%s (%s/%s)", message, step, steps)
+		}
+		return message, true
+	}
+	return "", false
+}
+
+// readRebaseFile reads the first existing file, since rebase-merge and rebase-apply use
+// different file names
+func (t *statusService) readRebaseFile(rebasePath string, names ...string) (string, error) {
+	var err error
+	for _, name := range names {
+		var text string
+		text, err = t.cmd.ReadFile(path.Join(rebasePath, name))
+		if err == nil {
+			return strings.TrimSpace(text), nil
+		}
+	}
+	return "", err
+}
+
 func (t *statusService) getMergeStatus() (string, bool) {
 	mergeMessage := ""
 	//mergeIpPath := path.Join(h.cmd.RepoPath(), ".git", "MERGE_HEAD")
-	mergeMsgPath := path.Join(t.cmd.WorkingDir(), ".git", "MERGE_MSG")
+	mergeMsgPath := path.Join(GitDir(t.cmd.WorkingDir()), "MERGE_MSG")
 	msg, err := t.cmd.ReadFile(mergeMsgPath)
 	if err != nil {
 		return "", false
@@ -0,0 +1,209 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/michael-reichenauer/gmc/utils"
+)
+
+const subprojectCommitPrefix = "Subproject commit "
+
+// Submodule is a submodule as listed by 'git submodule status'
+type Submodule struct {
+	Path          string
+	CommitID      string // The checked out commit, or the recorded commit if not initialized
+	IsInitialized bool
+	IsChanged     bool // The checked out commit differs from the commit recorded in the index
+	HasConflicts  bool
+}
+
+// listing and updating of submodules and describing submodule commit changes in diffs
+type submoduleService struct {
+	cmd gitCommander
+}
+
+func newSubmoduleService(cmd gitCommander) *submoduleService {
+	return &submoduleService{cmd: cmd}
+}
+
+func (t *submoduleService) getSubmodules() ([]Submodule, error) {
+	if !t.hasSubmodules() {
+		return nil, nil
+	}
+	output, err := t.cmd.Git("submodule", "status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submodules, %v", err)
+	}
+	return parseSubmodules(output), nil
+}
+
+// getSubmodulePaths returns the submodule paths in .gitmodules, which is faster than
+// 'git submodule status', since the submodules are not read
+func (t *submoduleService) getSubmodulePaths() []string {
+	if !t.hasSubmodules() {
+		return nil
+	}
+	output, err := t.cmd.Git("config", "--file", ".gitmodules", "--get-regexp", `^submodule\..*\.path$`)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, line := range strings.Split(output, "\n") {
+		if _, path, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// updateSubmodules initializes and updates all submodules (recursively) to the recorded commits
+func (t *submoduleService) updateSubmodules() error {
+	if _, err := t.cmd.Git("submodule", "update", "--init", "--recursive"); err != nil {
+		return fmt.Errorf("failed to update submodules, %w", err)
+	}
+	return nil
+}
+
+func (t *submoduleService) hasSubmodules() bool {
+	return utils.FileExists(filepath.Join(t.cmd.WorkingDir(), ".gitmodules"))
+}
+
+// parseSubmodules parses lines like '<state><sha> <path> (<describe>)', where state is ' ',
+// '-' (not initialized), '+' (checked out commit differs from index) or 'U' (conflicts)
+func parseSubmodules(output string) []Submodule {
+	var submodules []Submodule
+	for _, line := range strings.Split(output, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		parts := strings.Fields(line[1:])
+		if len(parts) < 2 {
+			continue
+		}
+		submodules = append(submodules, Submodule{
+			Path:          parts[1],
+			CommitID:      parts[0],
+			IsInitialized: line[0] != '-',
+			IsChanged:     line[0] == '+',
+			HasConflicts:  line[0] == 'U',
+		})
+	}
+	return submodules
+}
+
+// setSubmoduleDiffs replaces the "Subproject commit <sha>" lines of submodule file diffs with
+// "Submodule <path> moved from <sha> to <sha>" and the commits in between
+func (t *submoduleService) setSubmoduleDiffs(commitDiffs []CommitDiff) {
+	for i := range commitDiffs {
+		for j := range commitDiffs[i].FileDiffs {
+			t.setSubmoduleDiff(&commitDiffs[i].FileDiffs[j])
+		}
+	}
+}
+
+func (t *submoduleService) setSubmoduleDiff(fd *FileDiff) {
+	before, after, ok := submoduleCommits(*fd)
+	if !ok {
+		return
+	}
+	fd.IsSubmodule = true
+	isDirty := strings.HasSuffix(after, "-dirty")
+	after = strings.TrimSuffix(after, "-dirty")
+	path := fd.PathAfter
+
+	var lines []LinesDiff
+	add := func(mode DiffMode, text string) {
+		lines = append(lines, LinesDiff{DiffMode: mode, Line: text})
+	}
+	switch {
+	case before == "":
+		add(DiffSame, fmt.Sprintf("Submodule %s added at %s", path, ToSid(after)))
+	case after == "":
+		add(DiffSame, fmt.Sprintf("Submodule %s removed, was at %s", path, ToSid(before)))
+	case before != after:
+		add(DiffSame, fmt.Sprintf("Submodule %s moved from %s to %s", path, ToSid(before), ToSid(after)))
+		added, removed, err := t.getCommitsBetween(path, before, after)
+		if err != nil {
+			add(DiffSame, "  (commits not available, submodule is not initialized or fetched)")
+		}
+		for _, c := range added {
+			add(DiffAdded, "  "+c)
+		}
+		for _, c := range removed {
+			add(DiffRemoved, "  "+c)
+		}
+	}
+	if isDirty {
+		add(DiffSame, fmt.Sprintf("Submodule %s has uncommitted changes", path))
+	}
+
+	leftCount := len(lines)
+	rightCount := len(lines)
+	for _, l := range lines {
+		if l.DiffMode == DiffAdded {
+			leftCount--
+		} else if l.DiffMode == DiffRemoved {
+			rightCount--
+		}
+	}
+	fd.SectionDiffs = []SectionDiff{{
+		ChangedIndexes: fmt.Sprintf("-1,%d +1,%d", leftCount, rightCount),
+		LeftLine:       1,
+		LeftCount:      leftCount,
+		RightLine:      1,
+		RightCount:     rightCount,
+		LinesDiffs:     lines,
+	}}
+}
+
+// getCommitsBetween returns the commits added and removed (if moved back or to another branch)
+// between the before and after commits, as read from the submodule repo
+func (t *submoduleService) getCommitsBetween(path, before, after string) ([]string, []string, error) {
+	cmd := newGitCmd(filepath.Join(t.cmd.WorkingDir(), path))
+	log := func(rangeText string) ([]string, error) {
+		output, err := cmd.Git("log", "--format=%h %s", rangeText)
+		if err != nil {
+			return nil, err
+		}
+		var commits []string
+		for _, line := range strings.Split(output, "\n") {
+			if line != "" {
+				commits = append(commits, line)
+			}
+		}
+		return commits, nil
+	}
+
+	added, err := log(before + ".." + after)
+	if err != nil {
+		return nil, nil, err
+	}
+	removed, err := log(after + ".." + before)
+	if err != nil {
+		return nil, nil, err
+	}
+	return added, removed, nil
+}
+
+// submoduleCommits returns the before and after commits of a submodule file diff, i.e.
+// a diff with only "Subproject commit <sha>" lines
+func submoduleCommits(fd FileDiff) (string, string, bool) {
+	before, after := "", ""
+	for _, sd := range fd.SectionDiffs {
+		for _, l := range sd.LinesDiffs {
+			if !strings.HasPrefix(l.Line, subprojectCommitPrefix) {
+				return "", "", false
+			}
+			id := strings.TrimPrefix(l.Line, subprojectCommitPrefix)
+			switch l.DiffMode {
+			case DiffRemoved:
+				before = id
+			case DiffAdded:
+				after = id
+			}
+		}
+	}
+	return before, after, before != "" || after != ""
+}
@@ -0,0 +1,81 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubmodules(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+
+	subFolder := wf.MkDir("sub")
+	subGit := New(subFolder.Path())
+	assert.NoError(t, subGit.InitRepo())
+	assert.NoError(t, subGit.ConfigUser("test", "test@test.com"))
+	subFolder.File("s.txt").Write("1")
+	assert.NoError(t, subGit.Commit("sub1"))
+
+	mainFolder := wf.MkDir("main")
+	git := New(mainFolder.Path())
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+	mainFolder.File("a.txt").Write("1")
+	assert.NoError(t, git.Commit("initial"))
+	_, err := newGitCmd(mainFolder.Path()).Git("-c", "protocol.file.allow=always",
+		"submodule", "add", subFolder.Path(), "sub")
+	assert.NoError(t, err)
+	assert.NoError(t, git.Commit("add sub"))
+
+	sms, err := git.GetSubmodules()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sms))
+	assert.Equal(t, "sub", sms[0].Path)
+	assert.True(t, sms[0].IsInitialized)
+	assert.False(t, sms[0].IsChanged)
+
+	// Move the submodule commit, which is a distinct status
+	smGit := New(mainFolder.Path("sub"))
+	assert.NoError(t, smGit.ConfigUser("test", "test@test.com"))
+	mainFolder.File("sub", "s.txt").Write("2")
+	assert.NoError(t, smGit.Commit("sub2"))
+	mainFolder.File("sub", "s.txt").Write("3")
+	assert.NoError(t, smGit.Commit("sub3"))
+
+	st, err := git.GetStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, st.Submodules)
+	assert.Equal(t, []string{"sub"}, st.SubmoduleFiles)
+	assert.Equal(t, 0, st.Modified)
+	sms, _ = git.GetSubmodules()
+	assert.True(t, sms[0].IsChanged)
+
+	diff, err := git.CommitDiff(UncommittedID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(diff.FileDiffs))
+	fd := diff.FileDiffs[0]
+	assert.True(t, fd.IsSubmodule)
+	lines := fd.SectionDiffs[0].LinesDiffs
+	assert.Equal(t, 3, len(lines))
+	assert.Contains(t, lines[0].Line, "Submodule sub moved from ")
+	assert.Equal(t, DiffAdded, lines[1].DiffMode)
+	assert.Contains(t, lines[1].Line, "sub3")
+	assert.Contains(t, lines[2].Line, "sub2")
+
+	// Commit the moved submodule and then update it back to the previous commit
+	assert.NoError(t, git.StageFile("sub"))
+	assert.NoError(t, git.CommitStaged("move sub"))
+	cs, _ := git.GetLog()
+	diff, err = git.CommitDiff(cs.MustBySubject("move sub").ID)
+	assert.NoError(t, err)
+	assert.True(t, diff.FileDiffs[0].IsSubmodule)
+
+	assert.NoError(t, smGit.Checkout("HEAD~2"))
+	st, _ = git.GetStatus()
+	assert.Equal(t, 1, st.Submodules)
+	assert.NoError(t, git.UpdateSubmodules())
+	st, _ = git.GetStatus()
+	assert.Equal(t, 0, st.Submodules)
+}
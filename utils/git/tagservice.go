@@ -1,6 +1,7 @@
 package git
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -27,6 +28,45 @@ func (t *tagService) getTags() ([]Tag, error) {
 	return tags, nil
 }
 
+// createTag creates an annotated tag if a message is specified, otherwise a lightweight tag
+func (t *tagService) createTag(name, commitID, message string) error {
+	args := []string{"tag", name, commitID}
+	if message != "" {
+		args = []string{"tag", "--annotate", "--message", message, name, commitID}
+	}
+
+	_, err := t.cmd.Git(args...)
+	if err != nil {
+		return fmt.Errorf("failed to create tag %s, %w", name, err)
+	}
+	return nil
+}
+
+func (t *tagService) deleteTag(name string) error {
+	_, err := t.cmd.Git("tag", "--delete", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag %s, %w", name, err)
+	}
+	return nil
+}
+
+func (t *tagService) pushTag(name string) error {
+	refs := fmt.Sprintf("refs/tags/%s:refs/tags/%s", name, name)
+	_, err := t.cmd.Git("push", "--porcelain", DefaultRemote, refs)
+	if err != nil {
+		return fmt.Errorf("failed to push tag %s, %w", name, err)
+	}
+	return nil
+}
+
+func (t *tagService) deleteRemoteTag(name string) error {
+	_, err := t.cmd.Git("push", "--porcelain", DefaultRemote, "--delete", "refs/tags/"+name)
+	if err != nil {
+		return fmt.Errorf("failed to delete remote tag %s, %w", name, err)
+	}
+	return nil
+}
+
 func (t *tagService) parseTags(output string) []Tag {
 	var tags []Tag
 	lines := strings.Split(output, "\n")
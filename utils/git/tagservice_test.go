@@ -0,0 +1,59 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTags(t *testing.T) {
+	defer tests.CleanTemp()
+
+	// Prepare server repo 1
+	wf1 := tests.CreateTempFolder()
+	git1 := New(wf1.Path())
+	assert.NoError(t, git1.InitRepoBare())
+
+	// Prepare cloned repo 2
+	wf2 := tests.CreateTempFolder()
+	git2 := New(wf2.Path())
+	assert.NoError(t, git2.Clone(git1.RepoPath(), wf2.Path()))
+	assert.NoError(t, git2.ConfigUser("test", "test@test.com"))
+
+	wf2.File("a.txt").Write("1")
+	assert.NoError(t, git2.Commit("initial"))
+	cs, _ := git2.GetLog()
+	id := cs[0].ID
+
+	// Create lightweight and annotated tags
+	assert.NoError(t, git2.CreateTag("v1", id, ""))
+	assert.NoError(t, git2.CreateTag("v2", id, "Release 2"))
+	assert.Error(t, git2.CreateTag("v1", id, ""))
+	tags, err := git2.GetTags()
+	assert.NoError(t, err)
+	assert.True(t, hasTag(tags, "v1", id))
+	assert.True(t, hasTag(tags, "v2", id))
+
+	// Push tags to server
+	assert.NoError(t, git2.PushBranch("master"))
+	assert.NoError(t, git2.PushTag("v1"))
+	assert.NoError(t, git2.PushTag("v2"))
+	tags, _ = git1.GetTags()
+	assert.True(t, hasTag(tags, "v1", id))
+	assert.True(t, hasTag(tags, "v2", id))
+
+	// Delete tag both local and remote
+	assert.NoError(t, git2.DeleteTag("v2"))
+	assert.NoError(t, git2.DeleteRemoteTag("v2"))
+	tags, _ = git2.GetTags()
+	assert.False(t, hasTag(tags, "v2", id))
+	tags, _ = git1.GetTags()
+	assert.False(t, hasTag(tags, "v2", id))
+	assert.True(t, hasTag(tags, "v1", id))
+}
+
+func hasTag(tags []Tag, name, commitID string) bool {
+	return lo.ContainsBy(tags, func(v Tag) bool { return v.TagName == name && v.CommitID == commitID })
+}
@@ -0,0 +1,162 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/michael-reichenauer/gmc/utils"
+)
+
+const gitDirPrefix = "gitdir:"
+
+// Worktree is a working tree of the repo, i.e. the main working tree or a linked worktree
+type Worktree struct {
+	Path       string
+	HeadID     string
+	BranchName string // Empty if detached
+	IsMain     bool   // The main working tree (first listed)
+	IsCurrent  bool   // The working tree of this repo instance
+	IsDetached bool
+	IsBare     bool
+	IsLocked   bool
+	IsPrunable bool // The worktree folder is missing
+}
+
+// listing, adding and removing of linked worktrees
+type worktreeService struct {
+	cmd gitCommander
+}
+
+func newWorktreeService(cmd gitCommander) *worktreeService {
+	return &worktreeService{cmd: cmd}
+}
+
+func (t *worktreeService) getWorktrees() ([]Worktree, error) {
+	output, err := t.cmd.Git("worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees, %v", err)
+	}
+	return parseWorktrees(output, t.cmd.WorkingDir()), nil
+}
+
+// addWorktree adds a linked worktree at the path, with the branch checked out
+func (t *worktreeService) addWorktree(path, branchName string) error {
+	_, err := t.cmd.Git("worktree", "add", path, StripRemotePrefix(branchName))
+	if err != nil {
+		return fmt.Errorf("failed to add worktree %q for %q, %w", path, branchName, err)
+	}
+	return nil
+}
+
+func (t *worktreeService) removeWorktree(path string, isForced bool) error {
+	args := []string{"worktree", "remove"}
+	if isForced {
+		args = append(args, "--force")
+	}
+	args = append(args, path)
+	if _, err := t.cmd.Git(args...); err != nil {
+		return fmt.Errorf("failed to remove worktree %q, %w", path, err)
+	}
+	return nil
+}
+
+// parseWorktrees parses 'git worktree list --porcelain' output, which has a block of
+// '<attribute> [value]' lines per worktree, separated by empty lines
+func parseWorktrees(output, workingDir string) []Worktree {
+	var worktrees []Worktree
+	for _, block := range strings.Split(strings.TrimSpace(output), "\n\n") {
+		var w Worktree
+		for _, line := range strings.Split(block, "\n") {
+			key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+			switch key {
+			case "worktree":
+				w.Path = filepath.FromSlash(value)
+			case "HEAD":
+				w.HeadID = value
+			case "branch":
+				w.BranchName = strings.TrimPrefix(value, "refs/heads/")
+			case "detached":
+				w.IsDetached = true
+			case "bare":
+				w.IsBare = true
+			case "locked":
+				w.IsLocked = true
+			case "prunable":
+				w.IsPrunable = true
+			}
+		}
+		if w.Path == "" {
+			continue
+		}
+		w.IsMain = len(worktrees) == 0
+		w.IsCurrent = isSamePath(w.Path, workingDir)
+		worktrees = append(worktrees, w)
+	}
+	return worktrees
+}
+
+// GitDir returns the git folder of the working tree, which for a linked worktree (or a
+// submodule) is the folder referenced by the "gitdir: <path>" line in the .git file
+func GitDir(workingDir string) string {
+	gitPath := filepath.Join(workingDir, ".git")
+	if !utils.FileExists(gitPath) {
+		return gitPath
+	}
+	bytes, err := utils.FileRead(gitPath)
+	if err != nil {
+		return gitPath
+	}
+	text := strings.TrimSpace(string(bytes))
+	if !strings.HasPrefix(text, gitDirPrefix) {
+		return gitPath
+	}
+	dir := filepath.FromSlash(strings.TrimSpace(strings.TrimPrefix(text, gitDirPrefix)))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workingDir, dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// writeGitDirTempFile writes the text to a new temp file in the git folder of the working tree,
+// which the caller removes when done. The file names are unique, so concurrent commands do not
+// overwrite each others files
+func writeGitDirTempFile(workingDir, pattern, text string) (string, error) {
+	file, err := os.CreateTemp(GitDir(workingDir), pattern)
+	if err != nil {
+		return "", err
+	}
+	_, err = file.WriteString(text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// CommonGitDir returns the git folder, which is shared by all worktrees and contains e.g.
+// refs and objects. For a linked worktree, the git dir has a "commondir" file with the path
+func CommonGitDir(gitDir string) string {
+	bytes, err := utils.FileRead(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	dir := filepath.FromSlash(strings.TrimSpace(string(bytes)))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitDir, dir)
+	}
+	return filepath.Clean(dir)
+}
+
+func isSamePath(path1, path2 string) bool {
+	p1, err1 := filepath.EvalSymlinks(path1)
+	p2, err2 := filepath.EvalSymlinks(path2)
+	if err1 != nil || err2 != nil {
+		return filepath.Clean(path1) == filepath.Clean(path2)
+	}
+	return p1 == p2
+}
@@ -0,0 +1,71 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/michael-reichenauer/gmc/utils/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorktrees(t *testing.T) {
+	wf := tests.CreateTempFolder()
+	defer tests.CleanTemp()
+	mainPath := wf.Path()
+	git := New(mainPath)
+	assert.NoError(t, git.InitRepo())
+	assert.NoError(t, git.ConfigUser("test", "test@test.com"))
+
+	wf.File("a.txt").Write("1")
+	assert.NoError(t, git.Commit("initial"))
+	assert.NoError(t, git.CreateBranch("feature"))
+	assert.NoError(t, git.Checkout("master"))
+
+	wtPath := mainPath + "-feature"
+	assert.NoError(t, git.AddWorktree(wtPath, "feature"))
+
+	wts, err := git.GetWorktrees()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(wts))
+	assert.True(t, wts[0].IsMain && wts[0].IsCurrent)
+	assert.Equal(t, "master", wts[0].BranchName)
+	assert.False(t, wts[1].IsMain || wts[1].IsCurrent)
+	assert.Equal(t, "feature", wts[1].BranchName)
+	assert.True(t, isSamePath(wtPath, wts[1].Path))
+
+	// Branch checked out in the other worktree is marked with the worktree path
+	bs, err := git.GetBranches()
+	assert.NoError(t, err)
+	assert.Equal(t, "master", bs.MustCurrent().Name)
+	assert.True(t, isSamePath(wtPath, bs.MustByName("feature").WorktreePath))
+	assert.Equal(t, "", bs.MustByName("master").WorktreePath)
+
+	// The linked worktree has a .git file with a gitdir, which refers to the common git dir
+	root, err := WorkingTreeRoot(wtPath)
+	assert.NoError(t, err)
+	assert.Equal(t, wtPath, root)
+	gitDir := GitDir(wtPath)
+	assert.NotEqual(t, filepath.Join(wtPath, ".git"), gitDir)
+	assert.True(t, isSamePath(filepath.Join(mainPath, ".git"), CommonGitDir(gitDir)))
+	assert.Equal(t, filepath.Join(mainPath, ".git"), GitDir(mainPath))
+
+	// Commit and merge conflicts in the linked worktree
+	wtGit := New(wtPath)
+	tests.TempFolder(wtPath).File("a.txt").Write("feature")
+	assert.NoError(t, wtGit.Commit("feature1"))
+	wf.File("a.txt").Write("master")
+	assert.NoError(t, git.Commit("master1"))
+	assert.ErrorIs(t, wtGit.MergeBranch("master"), ErrConflicts)
+	st, err := wtGit.GetStatus()
+	assert.NoError(t, err)
+	assert.True(t, st.IsMerging)
+	assert.Equal(t, 1, st.Conflicted)
+	bs, _ = wtGit.GetBranches()
+	assert.Equal(t, "feature", bs.MustCurrent().Name)
+	assert.True(t, isSamePath(mainPath, bs.MustByName("master").WorktreePath))
+
+	assert.Error(t, git.RemoveWorktree(wtPath, false))
+	assert.NoError(t, git.RemoveWorktree(wtPath, true))
+	wts, _ = git.GetWorktrees()
+	assert.Equal(t, 1, len(wts))
+}